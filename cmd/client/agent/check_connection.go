@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _checkConnectionFlags = internal.NewGRPCClientConn([]cli.Flag{})
+
+func _checkConnectionAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewAgentServiceClient(grpcClientConn)
+	checkResp, err := client.CheckConnection(ctx, &types.Void{})
+	if err != nil {
+		return err
+	}
+
+	if err := outputs.JSON(cliCtx.App.Writer, checkResp); err != nil {
+		return err
+	}
+	if !checkResp.Success {
+		return errors.Errorf("system agent cannot connect to %s", checkResp.Server)
+	}
+	return nil
+}
+
+func checkConnectionCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "check-connection",
+		Usage:  "Check the connection info of the system agent and whether it can connect to Rancher",
+		Flags:  _checkConnectionFlags,
+		Action: _checkConnectionAction,
+	}
+}
@@ -0,0 +1,17 @@
+package agent
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "agent",
+		Usage: "Inspect the embedded system agent",
+		Subcommands: []*cli.Command{
+			statusCommand(),
+			checkConnectionCommand(),
+			planCommand(),
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/systemagent/plan"
+	"github.com/urfave/cli/v2"
+)
+
+func _planFileParser(cliCtx *cli.Context) error {
+	if cliCtx.NArg() != 1 {
+		return errors.New("exactly one plan file is required")
+	}
+	return nil
+}
+
+func _planValidateAction(cliCtx *cli.Context) error {
+	defer panics.Log()
+
+	validation := plan.Validate(cliCtx.Args().First())
+	if err := outputs.JSON(cliCtx.App.Writer, validation); err != nil {
+		return err
+	}
+	if !validation.Valid {
+		return errors.Errorf("plan %s is invalid", validation.File)
+	}
+	return nil
+}
+
+func _planDryRunAction(cliCtx *cli.Context) error {
+	defer panics.Log()
+
+	path := cliCtx.Args().First()
+	validation := plan.Validate(path)
+	if !validation.Valid {
+		if err := outputs.JSON(cliCtx.App.Writer, validation); err != nil {
+			return err
+		}
+		return errors.Errorf("plan %s is invalid", path)
+	}
+
+	calculated, err := plan.Load(path)
+	if err != nil {
+		return err
+	}
+	return outputs.JSON(cliCtx.App.Writer, plan.Describe(path, calculated))
+}
+
+func planCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "plan",
+		Usage: "Author local plans of the system agent, the plans are checked locally without connecting to the server",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "validate",
+				Usage:     "Check a plan file for mistakes",
+				ArgsUsage: "<file>",
+				Before:    _planFileParser,
+				Action:    _planValidateAction,
+			},
+			{
+				Name:      "dry-run",
+				Usage:     "Show the files, instructions and images a plan file would apply, without applying anything",
+				ArgsUsage: "<file>",
+				Before:    _planFileParser,
+				Action:    _planDryRunAction,
+			},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _statusFlags = internal.NewGRPCClientConn([]cli.Flag{})
+
+func _statusAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewAgentServiceClient(grpcClientConn)
+	statusResp, err := client.Status(ctx, &types.Void{})
+	if err != nil {
+		return err
+	}
+
+	return outputs.JSON(cliCtx.App.Writer, statusResp.Status)
+}
+
+func statusCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "status",
+		Usage:  "Show what the system agent is watching and the last plan it applied, along with its outcome if recorded",
+		Flags:  _statusFlags,
+		Action: _statusAction,
+	}
+}
@@ -14,6 +14,7 @@ func NewCommand() *cli.Command {
 		Usage:   fmt.Sprintf("Manage %s Application", defaults.WindowsServiceDisplayName),
 		Subcommands: []*cli.Command{
 			infoCommand(),
+			reloadCommand(),
 		},
 	}
 }
@@ -12,6 +12,8 @@
 	"github.com/rancher/wins/pkg/paths"
 	"github.com/rancher/wins/pkg/types"
 	"github.com/urfave/cli/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var _infoFlags = internal.NewGRPCClientConn([]cli.Flag{})
@@ -46,16 +48,28 @@ func _infoAction(cliCtx *cli.Context) (err error) {
 		return err
 	}
 
-	return outputs.JSON(cliCtx.App.Writer, map[string]interface{}{
+	output := map[string]interface{}{
 		"Client": &types.ApplicationInfo{Checksum: clientChecksum, Version: defaults.AppVersion, Commit: defaults.AppCommit},
 		"Server": infoResp.Info,
-	})
+	}
+
+	// servers older than the client do not report their status
+	statusResp, err := client.Status(ctx, &types.Void{})
+	if err != nil {
+		if status.Code(err) != codes.Unimplemented {
+			return err
+		}
+	} else {
+		output["Status"] = statusResp.Status
+	}
+
+	return outputs.JSON(cliCtx.App.Writer, output)
 }
 
 func infoCommand() *cli.Command {
 	return &cli.Command{
 		Name:   "info",
-		Usage:  "Get application info",
+		Usage:  "Get application info and status",
 		Flags:  _infoFlags,
 		Action: _infoAction,
 	}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _reloadFlags = internal.NewGRPCClientConn([]cli.Flag{})
+
+func _reloadAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewApplicationServiceClient(grpcClientConn)
+	reloadResp, err := client.Reload(ctx, &types.Void{})
+	if err != nil {
+		return err
+	}
+
+	return outputs.JSON(cliCtx.App.Writer, reloadResp)
+}
+
+func reloadCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "reload",
+		Usage:  "Reload the server configuration, reporting the changes that require a restart",
+		Flags:  _reloadFlags,
+		Action: _reloadAction,
+	}
+}
@@ -3,6 +3,7 @@
 import (
 	"fmt"
 
+	"github.com/rancher/wins/cmd/client/agent"
 	"github.com/rancher/wins/cmd/client/app"
 	"github.com/rancher/wins/cmd/client/hns"
 	"github.com/rancher/wins/cmd/client/host"
@@ -10,6 +11,7 @@
 	"github.com/rancher/wins/cmd/client/process"
 	"github.com/rancher/wins/cmd/client/proxy"
 	"github.com/rancher/wins/cmd/client/route"
+	"github.com/rancher/wins/cmd/client/service"
 	"github.com/rancher/wins/pkg/defaults"
 	"github.com/urfave/cli/v2"
 )
@@ -27,6 +29,8 @@ func NewCommand() *cli.Command {
 			route.NewCommand(),
 			app.NewCommand(),
 			proxy.NewCommand(),
+			service.NewCommand(),
+			agent.NewCommand(),
 		},
 	}
 }
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "service",
+		Aliases: []string{"svc"},
+		Usage:   "Manage the Windows services configured in the server config",
+		Subcommands: []*cli.Command{
+			listCommand(),
+			statusCommand(),
+			restartCommand(),
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _listFlags = internal.NewGRPCClientConn([]cli.Flag{})
+
+func _listAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewManagedServiceServiceClient(grpcClientConn)
+	listResp, err := client.List(ctx, &types.Void{})
+	if err != nil {
+		return err
+	}
+
+	return outputs.JSON(cliCtx.App.Writer, listResp.Services)
+}
+
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "list",
+		Usage:  "List the configured services and their state",
+		Flags:  _listFlags,
+		Action: _listAction,
+	}
+}
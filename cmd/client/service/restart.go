@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _restartFlags = internal.NewGRPCClientConn(
+	[]cli.Flag{
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "[required] Specifies the name of the service",
+		},
+	},
+)
+
+var _restartRequest *types.ManagedServiceRequest
+
+func _restartRequestParser(cliCtx *cli.Context) error {
+	name := cliCtx.String("name")
+	if name == "" {
+		return errors.New("--name is required")
+	}
+
+	_restartRequest = &types.ManagedServiceRequest{
+		Name: name,
+	}
+	return nil
+}
+
+func _restartAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewManagedServiceServiceClient(grpcClientConn)
+
+	_, err = client.Restart(ctx, _restartRequest)
+
+	return
+}
+
+func restartCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "restart",
+		Usage:  "Restart a configured service",
+		Flags:  _restartFlags,
+		Before: _restartRequestParser,
+		Action: _restartAction,
+	}
+}
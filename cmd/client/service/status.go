@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/client/internal"
+	"github.com/rancher/wins/cmd/outputs"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/urfave/cli/v2"
+)
+
+var _statusFlags = internal.NewGRPCClientConn(
+	[]cli.Flag{
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "[required] Specifies the name of the service",
+		},
+	},
+)
+
+var _statusRequest *types.ManagedServiceRequest
+
+func _statusRequestParser(cliCtx *cli.Context) error {
+	name := cliCtx.String("name")
+	if name == "" {
+		return errors.New("--name is required")
+	}
+
+	_statusRequest = &types.ManagedServiceRequest{
+		Name: name,
+	}
+	return nil
+}
+
+func _statusAction(cliCtx *cli.Context) (err error) {
+	defer panics.Log()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// parse grpc client connection
+	grpcClientConn, err := internal.ParseGRPCClientConn(cliCtx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := grpcClientConn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	// start client
+	client := types.NewManagedServiceServiceClient(grpcClientConn)
+	statusResp, err := client.Status(ctx, _statusRequest)
+	if err != nil {
+		return err
+	}
+
+	return outputs.JSON(cliCtx.App.Writer, statusResp.Status)
+}
+
+func statusCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "status",
+		Usage:  "Show the state of a configured service",
+		Flags:  _statusFlags,
+		Before: _statusRequestParser,
+		Action: _statusAction,
+	}
+}
@@ -14,10 +14,15 @@
 	"google.golang.org/grpc/status"
 )
 
-func LogrusStreamServerInterceptor() grpc.StreamServerInterceptor {
+// LogrusStreamServerInterceptor logs stream requests while enabled returns true, it is checked on every request.
+func LogrusStreamServerInterceptor(enabled func() bool) grpc.StreamServerInterceptor {
 	logEntry := logrus.NewEntry(logrus.StandardLogger())
 
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !enabled() {
+			return handler(srv, stream)
+		}
+
 		requestStart := time.Now()
 		responseErr := handler(srv, stream)
 		responseCode := status.Code(responseErr)
@@ -31,10 +36,15 @@ func LogrusStreamServerInterceptor() grpc.StreamServerInterceptor {
 	}
 }
 
-func LogrusUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+// LogrusUnaryServerInterceptor logs unary requests while enabled returns true, it is checked on every request.
+func LogrusUnaryServerInterceptor(enabled func() bool) grpc.UnaryServerInterceptor {
 	logEntry := logrus.NewEntry(logrus.StandardLogger())
 
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !enabled() {
+			return handler(ctx, req)
+		}
+
 		requestStart := time.Now()
 		response, responseErr := handler(ctx, req)
 		responseCode := status.Code(responseErr)
@@ -4,6 +4,7 @@
 	"context"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"github.com/rancher/wins/pkg/types"
 	"google.golang.org/grpc"
@@ -11,18 +12,42 @@
 	"google.golang.org/grpc/status"
 )
 
-func ProcessPathUnaryServerInterceptor(whitelist []string) grpc.UnaryServerInterceptor {
+// ProcessPathWhiteList holds the process paths that are allowed to be started, it can be replaced at runtime.
+// An empty whitelist allows any path to be started.
+type ProcessPathWhiteList struct {
+	index atomic.Pointer[map[string]struct{}]
+}
+
+func NewProcessPathWhiteList(whitelist []string) *ProcessPathWhiteList {
+	w := &ProcessPathWhiteList{}
+	w.Set(whitelist)
+	return w
+}
+
+// Set replaces the whitelisted process paths
+func (w *ProcessPathWhiteList) Set(whitelist []string) {
 	whitelistIndex := make(map[string]struct{}, len(whitelist))
 	for _, wl := range whitelist {
 		path := strings.ToLower(filepath.Clean(wl))
 		whitelistIndex[path] = struct{}{}
 	}
+	w.index.Store(&whitelistIndex)
+}
+
+func (w *ProcessPathWhiteList) allowed(path string) bool {
+	whitelistIndex := *w.index.Load()
+	if len(whitelistIndex) == 0 {
+		return true
+	}
+	_, exist := whitelistIndex[strings.ToLower(filepath.Clean(path))]
+	return exist
+}
 
+func (w *ProcessPathWhiteList) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		if info.FullMethod == "/wins.ProcessService/Start" {
 			if psr, ok := req.(*types.ProcessStartRequest); ok {
-				path := strings.ToLower(filepath.Clean(psr.Path))
-				if _, exist := whitelistIndex[path]; !exist {
+				if !w.allowed(psr.Path) {
 					return nil, status.Errorf(codes.InvalidArgument, "invalid path")
 				}
 			}
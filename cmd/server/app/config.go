@@ -0,0 +1,161 @@
+package app
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/urfave/cli/v2"
+)
+
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Manage the configuration file",
+		Subcommands: []*cli.Command{
+			validateConfigCommand(),
+			showConfigCommand(),
+			migrateConfigCommand(),
+		},
+	}
+}
+
+var _validateConfigFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "[optional] Specifies the path of the configuration",
+		Value: defaults.ConfigPath,
+	},
+}
+
+func _validateConfigAction(cliCtx *cli.Context) error {
+	defer panics.Log()
+
+	cfgPath := cliCtx.String("config")
+	version, err := config.CheckConfig(cfgPath)
+	if err != nil {
+		problems := []error{err}
+		if merr, ok := err.(*multierror.Error); ok {
+			problems = merr.Errors
+		}
+		for _, problem := range problems {
+			if _, err := fmt.Fprintf(cliCtx.App.Writer, "%s: %v\n", cfgPath, problem); err != nil {
+				return err
+			}
+		}
+		return errors.Errorf("found %d problem(s) in config %s", len(problems), cfgPath)
+	}
+
+	if version < config.CurrentVersion {
+		_, err = fmt.Fprintf(cliCtx.App.Writer, "%s: valid, version %d can be migrated to version %d with 'config migrate'\n", cfgPath, version, config.CurrentVersion)
+		return err
+	}
+	_, err = fmt.Fprintf(cliCtx.App.Writer, "%s: valid, version %d\n", cfgPath, version)
+	return err
+}
+
+func validateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "validate",
+		Usage:  "Validate the configuration file, reporting every problem found",
+		Flags:  _validateConfigFlags,
+		Action: _validateConfigAction,
+	}
+}
+
+var _showConfigFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "[optional] Specifies the path of the configuration",
+		Value: defaults.ConfigPath,
+	},
+	&cli.BoolFlag{
+		Name:  "effective",
+		Usage: "[optional] Show the configuration merged from the drop-in directory and environment variables, with the source of each value",
+	},
+}
+
+func _showConfigAction(cliCtx *cli.Context) error {
+	defer panics.Log()
+
+	cfgPath := cliCtx.String("config")
+	cfg := config.DefaultConfig()
+	if !cliCtx.Bool("effective") {
+		if err := config.LoadConfig(cfgPath, cfg); err != nil {
+			return errors.Wrapf(err, "failed to load config from %s", cfgPath)
+		}
+		yml, err := yaml.Marshal(cfg)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal config")
+		}
+		_, err = cliCtx.App.Writer.Write(yml)
+		return err
+	}
+
+	sources, err := config.LoadEffectiveConfig(cfgPath, cfg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load effective config from %s", cfgPath)
+	}
+	fields, err := config.EffectiveFields(cfg, sources)
+	if err != nil {
+		return errors.Wrap(err, "failed to flatten effective config")
+	}
+
+	w := tabwriter.NewWriter(cliCtx.App.Writer, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "FIELD\tVALUE\tSOURCE"); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", field.Path, field.Value, field.Source); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func showConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "show",
+		Usage:  "Show the configuration",
+		Flags:  _showConfigFlags,
+		Action: _showConfigAction,
+	}
+}
+
+var _migrateConfigFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "config",
+		Usage: "[optional] Specifies the path of the configuration",
+		Value: defaults.ConfigPath,
+	},
+}
+
+func _migrateConfigAction(cliCtx *cli.Context) error {
+	defer panics.Log()
+
+	cfgPath := cliCtx.String("config")
+	migrated, err := config.MigrateConfigFile(cfgPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate config %s", cfgPath)
+	}
+	if !migrated {
+		_, err = fmt.Fprintf(cliCtx.App.Writer, "%s: already version %d\n", cfgPath, config.CurrentVersion)
+		return err
+	}
+	_, err = fmt.Fprintf(cliCtx.App.Writer, "%s: migrated to version %d, the original file is kept as %s.bak\n", cfgPath, config.CurrentVersion, cfgPath)
+	return err
+}
+
+func migrateConfigCommand() *cli.Command {
+	return &cli.Command{
+		Name:   "migrate",
+		Usage:  "Rewrite the configuration file in the current layout, keeping a copy of the original file",
+		Flags:  _migrateConfigFlags,
+		Action: _migrateConfigAction,
+	}
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"path/filepath"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,7 +12,9 @@
 	"github.com/rancher/wins/pkg/defaults"
 	"github.com/rancher/wins/pkg/panics"
 	"github.com/rancher/wins/pkg/profilings"
+	"github.com/rancher/wins/pkg/services"
 	"github.com/rancher/wins/pkg/systemagent"
+	"github.com/rancher/wins/pkg/winservice"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
@@ -88,7 +91,11 @@ func _runAction(cliCtx *cli.Context) error {
 	// parse config
 	cfg := config.DefaultConfig()
 	cfgPath := cliCtx.String("config")
-	err := config.LoadConfig(cfgPath, cfg)
+	if version, err := config.FileVersion(cfgPath); err == nil && version < config.CurrentVersion {
+		// the config is migrated in memory, the file is only rewritten by the config migrate command
+		logrus.Warnf("Config %s is written in version %d, run 'wins.exe srv app config migrate' to migrate it to version %d", cfgPath, version, config.CurrentVersion)
+	}
+	sources, err := config.LoadEffectiveConfig(cfgPath, cfg)
 	if err != nil {
 		return errors.Wrapf(err, "failed to load config from %s", cfgPath)
 	}
@@ -97,7 +104,10 @@ func _runAction(cliCtx *cli.Context) error {
 		grpc.ConnectionTimeout(5 * time.Second),
 	}
 
-	serverOptions, err = setupGRPCServerOptions(serverOptions, cfg)
+	// debug level and whitelists can be changed by reloading the config
+	r := newReloader(ctx, cfgPath, cfg, sources)
+
+	serverOptions, err = setupGRPCServerOptions(serverOptions, r.debug.Load, r.processPathWhiteList)
 	if err != nil {
 		return errors.Wrap(err, "failed to setup grpc middlewares")
 	}
@@ -107,12 +117,12 @@ func _runAction(cliCtx *cli.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to create server")
 	}
+	r.setServer(server)
+	server.SetConfigReloader(r)
 
-	// adding system agent
-	agent := systemagent.New(cfg.SystemAgent)
-
-	// Determine if the agent should use strict verification
-	agent.StrictTLSMode = cfg.AgentStrictTLSMode
+	// adding system agent, it is restarted when its config or the strict verification mode are reloaded
+	agent := systemagent.New(cfg.SystemAgent, cfg.AgentStrictTLSMode)
+	r.setAgent(agent)
 
 	//checking if CSI Proxy has config, if so enables it.
 	if cfg.CSIProxy != nil {
@@ -124,6 +134,26 @@ func _runAction(cliCtx *cli.Context) error {
 		if err := csi.Enable(); err != nil {
 			return err
 		}
+		r.setCSIProxy(csi)
+	}
+
+	// installing the managed services, a failing service does not prevent the server from running
+	manager := services.NewManager(winservice.New(), filepath.Join(filepath.Dir(cfgPath), "services"), cfg.TLSConfig)
+	if err := manager.Reconcile(cfg.Services); err != nil {
+		logrus.Errorf("Failed to reconcile managed services: %v", err)
+	}
+	r.setServiceManager(manager)
+	server.SetServiceManager(manager)
+
+	reporter := &statusReporter{
+		reloader: r,
+		agent:    agent,
+	}
+	server.SetStatusReporter(reporter)
+	server.SetAgentReporter(reporter)
+
+	if err := watchConfig(ctx, cfgPath, r); err != nil {
+		logrus.Warnf("Changes to %s will require a restart to take effect: %v", cfgPath, err)
 	}
 
 	err = runService(ctx, server, agent)
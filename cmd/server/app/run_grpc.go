@@ -3,45 +3,28 @@
 import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/rancher/wins/cmd/grpcs"
-	"github.com/rancher/wins/cmd/server/config"
-	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 )
 
-func setupGRPCServerOptions(serverOptions []grpc.ServerOption, cfg *config.Config) ([]grpc.ServerOption, error) {
-	ui := make([]grpc.UnaryServerInterceptor, 0)
-	si := make([]grpc.StreamServerInterceptor, 0)
-
-	// add logging middleware
-	debug := cfg.Debug
-	if debug {
-		logrus.SetLevel(logrus.DebugLevel)
-		ui = append(ui,
-			grpcs.LogrusUnaryServerInterceptor(),
-		)
-		si = append(si,
-			grpcs.LogrusStreamServerInterceptor(),
-		)
+// setupGRPCServerOptions chains the server interceptors. The interceptors read their settings at request time,
+// so that the debug setting and process path whitelist can be changed by a configuration reload.
+func setupGRPCServerOptions(serverOptions []grpc.ServerOption, debug func() bool, processPathWhiteList *grpcs.ProcessPathWhiteList) ([]grpc.ServerOption, error) {
+	// add logging middleware, requests are only logged while debug is set in the config
+	ui := []grpc.UnaryServerInterceptor{
+		grpcs.LogrusUnaryServerInterceptor(debug),
+	}
+	si := []grpc.StreamServerInterceptor{
+		grpcs.LogrusStreamServerInterceptor(debug),
 	}
 
 	// add process path whitelist middleware
-	processPathWhiteList := cfg.WhiteList.ProcessPaths
-	if len(processPathWhiteList) != 0 {
-		logrus.Debugf("Process path whitelist: %v", processPathWhiteList)
-		ui = append(ui,
-			grpcs.ProcessPathUnaryServerInterceptor(processPathWhiteList),
-		)
-	}
+	ui = append(ui,
+		processPathWhiteList.UnaryServerInterceptor(),
+	)
 
-	if len(ui) != 0 {
-		serverOptions = append(serverOptions,
-			grpc_middleware.WithUnaryServerChain(ui...),
-		)
-	}
-	if len(si) != 0 {
-		serverOptions = append(serverOptions,
-			grpc_middleware.WithStreamServerChain(si...),
-		)
-	}
+	serverOptions = append(serverOptions,
+		grpc_middleware.WithUnaryServerChain(ui...),
+		grpc_middleware.WithStreamServerChain(si...),
+	)
 	return serverOptions, nil
 }
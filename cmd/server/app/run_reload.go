@@ -0,0 +1,283 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/grpcs"
+	"github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/apis"
+	"github.com/rancher/wins/pkg/csiproxy"
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/services"
+	"github.com/rancher/wins/pkg/systemagent"
+	winstls "github.com/rancher/wins/pkg/tls"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// reloader applies changes of the configuration file to the running server. Fields that cannot be
+// changed at runtime are reported as requiring a restart and are not applied.
+type reloader struct {
+	// applyMu serializes reloads, mu guards the fields below and is only held briefly so that status requests
+	// and the setters are not blocked while a reload downloads the CSI Proxy or restarts services
+	applyMu sync.Mutex
+	mu      sync.Mutex
+	path    string
+	// cfg is the configuration currently in effect and sources where its values came from
+	cfg     *config.Config
+	sources config.Sources
+	// restartRequired lists the fields changed since the start that are waiting for a restart
+	restartRequired []string
+	// logLevel is the level set via the command line, used when debug is turned off
+	logLevel logrus.Level
+	// debug is the debug setting of the config in effect, gRPC requests are logged while it is set
+	debug                atomic.Bool
+	server               *apis.Server
+	processPathWhiteList *grpcs.ProcessPathWhiteList
+	services             *services.Manager
+	agent                *systemagent.Agent
+	csiProxy             *csiproxy.Proxy
+	csiMonitor           *csiproxy.Monitor
+	// ctx bounds the health monitor of the CSI Proxy, stopCSIMonitor stops it and waits for it to return
+	ctx            context.Context
+	stopCSIMonitor func()
+}
+
+func newReloader(ctx context.Context, path string, cfg *config.Config, sources config.Sources) *reloader {
+	r := &reloader{
+		ctx:                  ctx,
+		path:                 path,
+		cfg:                  cfg,
+		sources:              sources,
+		logLevel:             logrus.GetLevel(),
+		processPathWhiteList: grpcs.NewProcessPathWhiteList(cfg.WhiteList.ProcessPaths),
+	}
+	r.applyDebug(cfg.Debug)
+	logrus.Debugf("Process path whitelist: %v", cfg.WhiteList.ProcessPaths)
+	return r
+}
+
+// setServer sets the server the proxy port whitelist is applied to
+func (r *reloader) setServer(server *apis.Server) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.server = server
+}
+
+// setServiceManager sets the manager the managed services are reconciled with
+func (r *reloader) setServiceManager(manager *services.Manager) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.services = manager
+}
+
+// setAgent sets the system agent restarted on changes of its config
+func (r *reloader) setAgent(agent *systemagent.Agent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.agent = agent
+}
+
+// setCSIProxy sets the CSI Proxy enabled at startup and starts monitoring its health
+func (r *reloader) setCSIProxy(csiProxy *csiproxy.Proxy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.useCSIProxy(csiProxy)
+}
+
+// Reload loads and validates the effective configuration, then applies the changed fields that can be changed at runtime.
+// An invalid configuration is rejected as a whole and leaves the running configuration untouched, as does a missing config file.
+func (r *reloader) Reload() (resp *types.ApplicationReloadResponse, err error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		err = errors.Errorf("panic while reloading configuration: %v", recoverObj)
+	})
+
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	cfg := config.DefaultConfig()
+	sources, err := config.ReloadEffectiveConfig(r.path, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load config from %s", r.path)
+	}
+
+	resp = &types.ApplicationReloadResponse{
+		ConfigPath: r.path,
+	}
+	r.mu.Lock()
+	current, server, manager, agent := r.cfg, r.server, r.services, r.agent
+	effectiveSources := config.Sources{}
+	for p, source := range r.sources {
+		effectiveSources[p] = source
+	}
+	r.mu.Unlock()
+
+	effective := *current
+	for _, field := range config.ChangedFields(current, cfg) {
+		switch field {
+		case "debug":
+			r.applyDebug(cfg.Debug)
+			effective.Debug = cfg.Debug
+		case "white_list.processPaths":
+			logrus.Infof("Updating process path whitelist to: %v", cfg.WhiteList.ProcessPaths)
+			r.processPathWhiteList.Set(cfg.WhiteList.ProcessPaths)
+			effective.WhiteList.ProcessPaths = cfg.WhiteList.ProcessPaths
+		case "white_list.proxyPorts":
+			if server == nil {
+				resp.RestartRequired = append(resp.RestartRequired, field)
+				continue
+			}
+			server.SetProxyPorts(cfg.WhiteList.ProxyPorts)
+			effective.WhiteList.ProxyPorts = cfg.WhiteList.ProxyPorts
+		case "csi-proxy":
+			if err := r.applyCSIProxy(cfg.CSIProxy, effective.TLSConfig); err != nil {
+				logrus.Errorf("Failed to apply CSI Proxy configuration: %v", err)
+				resp.Failed = append(resp.Failed, field+": "+err.Error())
+				continue
+			}
+			effective.CSIProxy = cfg.CSIProxy
+		case "systemagent", "agentStrictTLSMode":
+			if agent == nil {
+				resp.RestartRequired = append(resp.RestartRequired, field)
+				continue
+			}
+			agentCfg, strictTLSMode := effective.SystemAgent, effective.AgentStrictTLSMode
+			if field == "systemagent" {
+				agentCfg = cfg.SystemAgent
+			} else {
+				strictTLSMode = cfg.AgentStrictTLSMode
+			}
+			if err := agent.Reconfigure(agentCfg, strictTLSMode); err != nil {
+				logrus.Errorf("Failed to apply system agent configuration: %v", err)
+				resp.Failed = append(resp.Failed, field+": "+err.Error())
+				continue
+			}
+			effective.SystemAgent, effective.AgentStrictTLSMode = agentCfg, strictTLSMode
+		case "services":
+			if manager == nil {
+				resp.RestartRequired = append(resp.RestartRequired, field)
+				continue
+			}
+			if err := manager.Reconcile(cfg.Services); err != nil {
+				logrus.Errorf("Failed to reconcile managed services: %v", err)
+				resp.Failed = append(resp.Failed, field+": "+err.Error())
+				continue
+			}
+			effective.Services = cfg.Services
+		default:
+			resp.RestartRequired = append(resp.RestartRequired, field)
+			continue
+		}
+		resp.Applied = append(resp.Applied, field)
+		for p := range effectiveSources {
+			if p == field || strings.HasPrefix(p, field+".") {
+				delete(effectiveSources, p)
+			}
+		}
+		for p, source := range sources {
+			if p == field || strings.HasPrefix(p, field+".") {
+				effectiveSources[p] = source
+			}
+		}
+	}
+	r.mu.Lock()
+	r.cfg = &effective
+	r.sources = effectiveSources
+	r.restartRequired = resp.RestartRequired
+	r.mu.Unlock()
+
+	if len(resp.Applied) != 0 {
+		logrus.Infof("Applied configuration changes from %s: %v", r.path, resp.Applied)
+	}
+	if len(resp.Failed) != 0 {
+		logrus.Errorf("Configuration changes from %s failed to apply and will be retried on the next reload: %v", r.path, resp.Failed)
+	}
+	if len(resp.RestartRequired) != 0 {
+		logrus.Warnf("Configuration changes from %s require a restart of %s to take effect: %v", r.path, defaults.WindowsServiceName, resp.RestartRequired)
+	}
+	return resp, nil
+}
+
+// applyCSIProxy installs, upgrades or reconfigures the CSI Proxy to match cfg. A CSI Proxy that is no longer
+// configured is left as is, just like it would be after a restart. The health monitor is paused meanwhile, mu is
+// only held while the CSI Proxy in use is replaced and not while the CSI Proxy is downloaded and installed.
+func (r *reloader) applyCSIProxy(cfg *csiproxy.Config, tlsConfig *winstls.Config) error {
+	r.mu.Lock()
+	previous := r.csiProxy
+	r.useCSIProxy(nil)
+	r.mu.Unlock()
+	if cfg == nil {
+		logrus.Warnf("CSI Proxy is no longer configured, leaving the CSI Proxy service as is")
+		return nil
+	}
+
+	csiProxy, err := csiproxy.New(cfg, tlsConfig)
+	if err == nil {
+		err = csiProxy.Enable()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.useCSIProxy(previous)
+		return err
+	}
+	r.useCSIProxy(csiProxy)
+	return nil
+}
+
+// useCSIProxy replaces the CSI Proxy in use and restarts the health monitor for it, must be called with mu held
+func (r *reloader) useCSIProxy(csiProxy *csiproxy.Proxy) {
+	if r.stopCSIMonitor != nil {
+		r.stopCSIMonitor()
+		r.stopCSIMonitor = nil
+	}
+	r.csiProxy, r.csiMonitor = csiProxy, nil
+	if csiProxy == nil {
+		return
+	}
+
+	monitor := csiproxy.NewMonitor(csiProxy)
+	ctx, cancel := context.WithCancel(r.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		monitor.Run(ctx)
+	}()
+	r.csiMonitor = monitor
+	r.stopCSIMonitor = func() {
+		cancel()
+		<-done
+	}
+}
+
+// currentCSIProxy returns the CSI Proxy in use and its health monitor, if any
+func (r *reloader) currentCSIProxy() (*csiproxy.Proxy, *csiproxy.Monitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.csiProxy, r.csiMonitor
+}
+
+// current returns the configuration currently in effect, where its values came from and the changed fields
+// waiting for a restart. The returned values must not be modified.
+func (r *reloader) current() (*config.Config, config.Sources, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cfg, r.sources, r.restartRequired
+}
+
+func (r *reloader) applyDebug(debug bool) {
+	r.debug.Store(debug)
+	level := r.logLevel
+	if debug {
+		level = logrus.DebugLevel
+	}
+	if logrus.GetLevel() != level {
+		logrus.Infof("Setting log level to %s", level)
+		logrus.SetLevel(level)
+	}
+}
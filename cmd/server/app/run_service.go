@@ -6,7 +6,6 @@
 	"os"
 	"strings"
 	"time"
-	"unsafe"
 
 	"github.com/pkg/errors"
 	"github.com/rancher/wins/pkg/apis"
@@ -15,14 +14,25 @@
 	"github.com/rancher/wins/pkg/paths"
 	"github.com/rancher/wins/pkg/profilings"
 	"github.com/rancher/wins/pkg/systemagent"
+	"github.com/rancher/wins/pkg/winservice"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/debug"
 	"golang.org/x/sys/windows/svc/eventlog"
-	"golang.org/x/sys/windows/svc/mgr"
 )
 
+// serviceStateTimeout is how long the registration waits for an existing service to stop
+const serviceStateTimeout = 60 * time.Second
+
+// recoveryActions try to restart the service after 5s, 10s, and 15s. If it still fails, the service is not restarted anymore.
+// The failure actions are used to control the restart after upgrading.
+var recoveryActions = []winservice.RecoveryAction{
+	{Type: winservice.RecoveryRestart, Delay: 5 * time.Second},
+	{Type: winservice.RecoveryRestart, Delay: 10 * time.Second},
+	{Type: winservice.RecoveryRestart, Delay: 15 * time.Second},
+	{Type: winservice.RecoveryNone},
+}
+
 func registerService(delayedStart bool) error {
 	// confirm wins binary path
 	binaryPath, err := paths.GetBinaryPath(os.Args[0])
@@ -30,37 +40,23 @@ func registerService(delayedStart bool) error {
 		return errors.Wrap(err, "could not get binary")
 	}
 
-	// open SCM
-	m, err := mgr.Connect()
+	ctl := winservice.New()
+
+	// if the service exists that means it was registered
+	ok, err := ctl.Exists(defaults.WindowsServiceName)
 	if err != nil {
-		return errors.Wrap(err, "could not open SCM")
+		return errors.Wrap(err, "could not query service")
 	}
-	defer m.Disconnect()
-
-	// if the service can be opened that means it was registered
-	w, err := m.OpenService(defaults.WindowsServiceName)
-	if err == nil {
-		status, err := w.Query()
-		for err == nil {
-			if status.State == svc.Stopped {
-				break
-			}
-
-			logrus.Warnf("Service is not stopped, going to stop it")
-			status, err = w.Control(svc.Stop)
-			time.Sleep(3 * time.Second)
-		}
-		if err != nil {
-			return errors.Wrap(err, "could not query status")
+	if ok {
+		logrus.Warnf("Service is registered already, going to stop and delete it")
+		if err := winservice.Stop(ctl, defaults.WindowsServiceName, serviceStateTimeout); err != nil {
+			return errors.Wrap(err, "could not stop")
 		}
 
 		// drop this service
-		err = w.Delete()
-		if err != nil {
-			w.Close()
+		if err := ctl.Delete(defaults.WindowsServiceName); err != nil {
 			return errors.Wrap(err, "could not delete")
 		}
-		w.Close()
 
 		// wait a while
 		time.Sleep(3 * time.Second)
@@ -77,58 +73,18 @@ func registerService(delayedStart bool) error {
 	}
 
 	// create a new service inst
-	w, err = m.CreateService(
-		defaults.WindowsServiceName,
-		binaryPath,
-		mgr.Config{
-			ServiceType:      windows.SERVICE_WIN32_OWN_PROCESS,
-			StartType:        mgr.StartAutomatic,
-			ErrorControl:     mgr.ErrorNormal,
-			DisplayName:      defaults.WindowsServiceDisplayName,
-			DelayedAutoStart: delayedStart,
-			BinaryPathName:   binaryPath,
-		},
-		args...,
-	)
+	err = ctl.Create(defaults.WindowsServiceName, &winservice.Config{
+		BinaryPath:          binaryPath,
+		Args:                args,
+		DisplayName:         defaults.WindowsServiceDisplayName,
+		StartType:           winservice.StartAutomatic,
+		DelayedAutoStart:    delayedStart,
+		RecoveryActions:     recoveryActions,
+		RecoveryResetPeriod: 5 * time.Minute,
+	})
 	if err != nil {
 		return errors.Wrap(err, "could not create")
 	}
-	defer w.Close()
-
-	// see http://stackoverflow.com/questions/35151052/how-do-i-configure-failure-actions-of-a-windows-service-written-in-go
-	// using failure action to control the restart after upgrading
-	const (
-		scActionNone    = 0
-		scActionRestart = 1
-
-		serviceConfigFailureActions = 2
-	)
-	// serviceFailureActions correspond to https://docs.microsoft.com/en-us/windows/win32/api/winsvc/ns-winsvc-service_failure_actionsa
-	type serviceFailureActions struct {
-		ResetPeriod  uint32
-		RebootMsg    *uint16
-		Command      *uint16
-		ActionsCount uint32
-		Actions      uintptr
-	}
-	// scAction corresponds to https://docs.microsoft.com/en-us/windows/win32/api/winsvc/ns-winsvc-sc_action
-	type scAction struct {
-		Type  uint32
-		Delay uint32
-	}
-	// Defines that wins should try to restart the service after 5s, 10s, and 15s. If it still fails, wins does not try to restart the service anymore
-	t := []scAction{
-		{Type: scActionRestart, Delay: uint32(5 * time.Second / time.Millisecond)},
-		{Type: scActionRestart, Delay: uint32(10 * time.Second / time.Millisecond)},
-		{Type: scActionRestart, Delay: uint32(15 * time.Second / time.Millisecond)},
-		{Type: scActionNone},
-	}
-	lpInfo := serviceFailureActions{ResetPeriod: uint32(5 * time.Minute / time.Second), ActionsCount: uint32(len(t)), Actions: uintptr(unsafe.Pointer(&t[0]))}
-	// Arguments provided adhere to https://docs.microsoft.com/en-us/windows/win32/api/winsvc/nf-winsvc-changeserviceconfig2w
-	err = windows.ChangeServiceConfig2(w.Handle, serviceConfigFailureActions, (*byte)(unsafe.Pointer(&lpInfo)))
-	if err != nil {
-		return errors.Wrap(err, "could not add failure action")
-	}
 
 	// create event log
 	err = eventlog.InstallAsEventCreate(defaults.WindowsServiceName, eventlog.Info|eventlog.Warning|eventlog.Error)
@@ -143,23 +99,20 @@ type scAction struct {
 }
 
 func unregisterService() error {
-	// open SCM
-	m, err := mgr.Connect()
-	if err != nil {
-		return errors.Wrap(err, "could not open SCM")
-	}
-	defer m.Disconnect()
+	ctl := winservice.New()
 
-	w, err := m.OpenService(defaults.WindowsServiceName)
+	ok, err := ctl.Exists(defaults.WindowsServiceName)
 	if err != nil {
-		return errors.Wrap(err, "service hasn't been registered")
+		return errors.Wrap(err, "could not query service")
+	}
+	if !ok {
+		return errors.New("service hasn't been registered")
 	}
-	defer w.Close()
 
-	// if the service can be opened that means it was registered
+	// if the service exists that means it was registered
 	eventlog.Remove(defaults.WindowsServiceName)
 
-	err = w.Delete()
+	err = ctl.Delete(defaults.WindowsServiceName)
 	if err != nil {
 		return errors.Wrap(err, "could not delete")
 	}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/systemagent"
+	"github.com/rancher/wins/pkg/types"
+)
+
+// statusReporter reports the configuration in effect and the status of the system agent and the CSI Proxy.
+// Secrets in the configuration are redacted.
+type statusReporter struct {
+	reloader *reloader
+	agent    *systemagent.Agent
+}
+
+func (s *statusReporter) Status() (*types.ApplicationStatus, error) {
+	cfg, sources, restartRequired := s.reloader.current()
+
+	fields, err := config.EffectiveFields(cfg.Redacted(), sources)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list effective config fields")
+	}
+
+	st := &types.ApplicationStatus{
+		ConfigPath:           s.reloader.path,
+		RestartRequired:      restartRequired,
+		ProcessPathWhiteList: cfg.WhiteList.ProcessPaths,
+		SystemAgent:          s.agentStatus(),
+		CSIProxy:             s.csiProxyStatus(),
+	}
+	for _, field := range fields {
+		st.Config = append(st.Config, &types.ApplicationConfigField{
+			Path:   field.Path,
+			Value:  field.Value,
+			Source: field.Source,
+		})
+	}
+	return st, nil
+}
+
+func (s *statusReporter) agentStatus() *types.ApplicationComponentStatus {
+	state, err := s.agent.Status()
+	st := &types.ApplicationComponentStatus{
+		Enabled: s.agent.Enabled(),
+		State:   state,
+	}
+	if err != nil {
+		st.Error = err.Error()
+	}
+	return st
+}
+
+// AgentStatus reports what the system agent is watching and the last plan it applied.
+func (s *statusReporter) AgentStatus() *types.AgentStatus {
+	report := s.agent.Inspect()
+	st := &types.AgentStatus{
+		Enabled:              report.Enabled,
+		State:                report.State,
+		Error:                report.Error,
+		RemoteWatching:       report.RemoteWatching,
+		LocalWatching:        report.LocalWatching,
+		LocalPlanDirectory:   report.LocalPlanDir,
+		LastAppliedPlanError: report.LastPlanError,
+	}
+	if c := report.Connection; c != nil {
+		st.Connection = &types.AgentConnection{
+			Server:     c.Server,
+			Namespace:  c.Namespace,
+			SecretName: c.SecretName,
+			Error:      c.Error,
+		}
+	}
+	if plan := report.LastPlan; plan != nil {
+		st.LastAppliedPlan = &types.AgentAppliedPlan{
+			File:         plan.File,
+			Checksum:     plan.Checksum,
+			Attempts:     int32(plan.Attempts),
+			Outcome:      plan.Outcome,
+			OutcomeError: plan.OutcomeError,
+			FailureCount: int32(plan.FailureCount),
+		}
+		if !plan.AppliedAt.IsZero() {
+			st.LastAppliedPlan.AppliedAt = plan.AppliedAt.Format(time.RFC3339)
+		}
+		for _, instruction := range plan.Instructions {
+			st.LastAppliedPlan.Instructions = append(st.LastAppliedPlan.Instructions, &types.AgentInstruction{
+				Name:            instruction.Name,
+				Type:            instruction.Type,
+				Image:           instruction.Image,
+				Command:         instruction.Command,
+				Args:            instruction.Args,
+				Outcome:         instruction.Outcome,
+				Output:          instruction.Output,
+				Stderr:          instruction.Stderr,
+				ExitCode:        int32(instruction.ExitCode),
+				Failures:        int32(instruction.Failures),
+				LastSucceededAt: instruction.LastSucceededAt,
+				LastFailedAt:    instruction.LastFailedAt,
+			})
+		}
+	}
+	return st
+}
+
+// CheckAgentConnection checks the connection of the system agent to the server it watches remote plans from.
+func (s *statusReporter) CheckAgentConnection(ctx context.Context) (*types.AgentConnectionCheckResponse, error) {
+	result, err := s.agent.CheckConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.AgentConnectionCheckResponse{
+		Server:  result.Server,
+		Success: result.Err() == nil,
+	}
+	for _, step := range result.Steps {
+		resp.Steps = append(resp.Steps, &types.AgentConnectionCheckStep{
+			Name:    step.Name,
+			Message: step.Message,
+			Error:   step.Error,
+		})
+	}
+	return resp, nil
+}
+
+func (s *statusReporter) csiProxyStatus() *types.ApplicationComponentStatus {
+	csiProxy, monitor := s.reloader.currentCSIProxy()
+	if csiProxy == nil {
+		return &types.ApplicationComponentStatus{
+			State: "Disabled",
+		}
+	}
+
+	st := &types.ApplicationComponentStatus{
+		Enabled: true,
+	}
+	if monitor != nil {
+		if health := monitor.Health(); !health.LastCheck.IsZero() {
+			st.Health = &types.ApplicationHealth{
+				Healthy:   health.Healthy,
+				Error:     health.Error,
+				LastCheck: health.LastCheck.Format(time.RFC3339),
+				Restarts:  int32(health.Restarts),
+			}
+			if !health.LastRestart.IsZero() {
+				st.Health.LastRestart = health.LastRestart.Format(time.RFC3339)
+			}
+		}
+	}
+	state, err := csiProxy.State()
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.State = state
+	return st
+}
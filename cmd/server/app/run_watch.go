@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/paths"
+	"github.com/sirupsen/logrus"
+)
+
+// watchConfig watches the configuration file at cfgPath and its drop-in directory, reloading the configuration
+// on every change. The drop-in directory is watched as well if it is only created after the server started. The directory of the file is watched rather than the file itself, as editors and the SUC
+// replace the file instead of writing to it in place.
+func watchConfig(ctx context.Context, cfgPath string, r *reloader) error {
+	cfgPath, err := filepath.Abs(cfgPath)
+	if err != nil {
+		return errors.Wrapf(err, "could not resolve config path %s", cfgPath)
+	}
+
+	reload := func(watchErr error, watchEvent fsnotify.Event, relevant func(name string) bool) {
+		if watchErr != nil {
+			logrus.Warnf("Error watching config %s: %v", cfgPath, watchErr)
+			return
+		}
+		if !relevant(filepath.Clean(watchEvent.Name)) {
+			return
+		}
+		if !watchEvent.Has(fsnotify.Write) && !watchEvent.Has(fsnotify.Create) &&
+			!watchEvent.Has(fsnotify.Remove) && !watchEvent.Has(fsnotify.Rename) {
+			return
+		}
+
+		if _, err := r.Reload(); err != nil {
+			logrus.Errorf("Ignoring change to config %s: %v", cfgPath, err)
+		}
+	}
+
+	// the drop-in directory may be created or replaced after the server started, its watch is restarted whenever
+	// it is created in the directory of the config file
+	dropInDir := config.DropInDir(cfgPath)
+	var dropInMu sync.Mutex
+	var stopDropIn context.CancelFunc
+	watchDropIn := func(watch bool) error {
+		dropInMu.Lock()
+		defer dropInMu.Unlock()
+		if stopDropIn != nil {
+			stopDropIn()
+			stopDropIn = nil
+		}
+		if !watch {
+			return nil
+		}
+		dropInCtx, cancel := context.WithCancel(ctx)
+		err := paths.Watch(dropInCtx, dropInDir, func(watchErr error, watchEvent fsnotify.Event) {
+			reload(watchErr, watchEvent, func(name string) bool {
+				ext := strings.ToLower(filepath.Ext(name))
+				return ext == ".yaml" || ext == ".yml"
+			})
+		})
+		if err != nil {
+			cancel()
+			return err
+		}
+		stopDropIn = cancel
+		return nil
+	}
+
+	err = paths.Watch(ctx, filepath.Dir(cfgPath), func(watchErr error, watchEvent fsnotify.Event) {
+		if watchErr == nil && strings.EqualFold(filepath.Clean(watchEvent.Name), dropInDir) {
+			created := watchEvent.Has(fsnotify.Create)
+			if !created && !watchEvent.Has(fsnotify.Remove) && !watchEvent.Has(fsnotify.Rename) {
+				return
+			}
+			if err := watchDropIn(created); err != nil {
+				logrus.Warnf("Could not watch config drop-in directory %s: %v", dropInDir, err)
+			}
+			// fragments moved in or out along with the directory change the configuration
+			reload(nil, watchEvent, func(string) bool { return true })
+			return
+		}
+		reload(watchErr, watchEvent, func(name string) bool {
+			// removing the config file keeps the running configuration
+			return strings.EqualFold(name, cfgPath) && !watchEvent.Has(fsnotify.Remove) && !watchEvent.Has(fsnotify.Rename)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dropInDir); err != nil {
+		logrus.Debugf("Not watching config drop-in directory %s until it is created: %v", dropInDir, err)
+		return nil
+	}
+	return watchDropIn(true)
+}
@@ -4,20 +4,24 @@
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/rancher/system-agent/pkg/config"
 	"github.com/rancher/wins/pkg/csiproxy"
 	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/services"
 	wintls "github.com/rancher/wins/pkg/tls"
 )
 
 func DefaultConfig() *Config {
 	return &Config{
-		Listen: defaults.NamedPipeName,
-		Proxy:  defaults.ProxyPipeName,
+		Version: CurrentVersion,
+		Listen:  defaults.NamedPipeName,
+		Proxy:   defaults.ProxyPipeName,
 		WhiteList: WhiteListConfig{
 			ProcessPaths: []string{},
 			ProxyPorts:   []int{},
@@ -27,6 +31,7 @@ func DefaultConfig() *Config {
 }
 
 type Config struct {
+	Version            int                 `yaml:"version" json:"version"`
 	Debug              bool                `yaml:"debug" json:"debug"`
 	Listen             string              `yaml:"listen" json:"listen"`
 	Proxy              string              `yaml:"proxy" json:"proxy"`
@@ -35,19 +40,30 @@ type Config struct {
 	AgentStrictTLSMode bool                `yaml:"agentStrictTLSMode" json:"agentStrictTLSMode"`
 	CSIProxy           *csiproxy.Config    `yaml:"csi-proxy" json:"csi-proxy,omitempty"`
 	TLSConfig          *wintls.Config      `yaml:"tls-config" json:"tls-config,omitempty"`
+	Services           []services.Config   `yaml:"services" json:"services,omitempty"`
 }
 
 func (c *Config) Validate() error {
+	var errs error
 	if strings.TrimSpace(c.Listen) == "" {
-		return errors.New("[Validate] listen cannot be blank")
+		errs = multierror.Append(errs, errors.New("[Validate] listen cannot be blank"))
 	}
 
 	// validate white list field
-	if err := c.WhiteList.Validate(); err != nil {
-		return errors.Wrap(err, "[Validate] failed to validate white list field")
+	if merr, ok := c.WhiteList.Validate().(*multierror.Error); ok {
+		for _, err := range merr.Errors {
+			errs = multierror.Append(errs, errors.Wrap(err, "[Validate] failed to validate white list field"))
+		}
+	}
+
+	// validate managed services
+	if merr, ok := services.ValidateAll(c.Services).(*multierror.Error); ok {
+		for _, err := range merr.Errors {
+			errs = multierror.Append(errs, errors.Wrap(err, "[Validate] failed to validate services field"))
+		}
 	}
 
-	return nil
+	return errs
 }
 
 type WhiteListConfig struct {
@@ -56,18 +72,19 @@ type WhiteListConfig struct {
 }
 
 func (c *WhiteListConfig) Validate() error {
+	var errs error
 	// process path
 	for _, processPath := range c.ProcessPaths {
 		if strings.TrimSpace(processPath) == "" {
-			return errors.New("could not accept blank path as process white list")
+			errs = multierror.Append(errs, errors.New("could not accept blank path as process white list"))
 		}
 	}
 	for _, proxyPort := range c.ProxyPorts {
 		if proxyPort < 0 || proxyPort > 0xFFFF {
-			return errors.New("could not accept invalid port number in proxy ports")
+			errs = multierror.Append(errs, errors.Errorf("could not accept invalid port number %d in proxy ports", proxyPort))
 		}
 	}
-	return nil
+	return errs
 }
 
 func LoadConfig(path string, v *Config) error {
@@ -105,10 +122,116 @@ func SaveConfig(path string, v *Config) error {
 	return os.WriteFile(path, yml, os.ModePerm)
 }
 
+// DecodeConfig strictly decodes the config file at path into v, migrating it to the CurrentVersion in memory.
 func DecodeConfig(path string, v *Config) error {
 	bs, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
-	return yaml.Unmarshal(bs, v)
+	_, err = decode(bs, v)
+	return err
+}
+
+// CheckConfig reports every problem found in the config file at path, both decoding and validation errors.
+// The version the file was written in is returned, a file that does not exist is reported as an error.
+func CheckConfig(path string) (int, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read config")
+	}
+
+	v := DefaultConfig()
+	version, err := decode(bs, v)
+	if err != nil {
+		return version, err
+	}
+	return version, v.Validate()
+}
+
+// FileVersion returns the version of the config layout the file at path is written in, without decoding the config.
+func FileVersion(path string) (int, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not read config")
+	}
+	raw, err := unmarshalDocument(bs)
+	if err != nil {
+		return 0, err
+	}
+	return documentVersion(raw)
+}
+
+// MigrateConfigFile rewrites the config file at path in the CurrentVersion layout if it was written in an older one.
+// Only the fields set in the file are written, comments are not kept. A copy of the original file is kept with
+// a ".bak" suffix, both files keep the mode of the original file. It returns whether the file was migrated.
+func MigrateConfigFile(path string) (bool, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false, errors.Wrap(err, "could not stat config")
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, errors.Wrap(err, "could not read config")
+	}
+
+	raw, version, err := parseDocument(bs)
+	if err != nil {
+		return false, errors.Wrap(err, "could not decode config")
+	}
+	if err := decodeDocument(raw, DefaultConfig()); err != nil {
+		return false, errors.Wrap(err, "could not decode config")
+	}
+	if version == CurrentVersion {
+		return false, nil
+	}
+
+	yml, err := yaml.Marshal(raw)
+	if err != nil {
+		return false, errors.Wrap(err, "could not marshal migrated config")
+	}
+	if err := os.WriteFile(path+".bak", bs, stat.Mode().Perm()); err != nil {
+		return false, errors.Wrap(err, "could not back up config")
+	}
+	if err := os.WriteFile(path, yml, stat.Mode().Perm()); err != nil {
+		return false, errors.Wrap(err, "could not save migrated config")
+	}
+	return true, nil
+}
+
+// ChangedFields returns the paths of the fields that differ between the old and new config, named as they
+// appear in the config file. Nested structs are compared field by field, e.g. "white_list.proxyPorts".
+func ChangedFields(old, new *Config) []string {
+	return changedFields("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem())
+}
+
+func changedFields(prefix string, old, new reflect.Value) []string {
+	var changed []string
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		// the config file is decoded via its json tags
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			changed = append(changed, changedFields(name, old.Field(i), new.Field(i))...)
+			continue
+		}
+		if !fieldEqual(old.Field(i), new.Field(i)) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// fieldEqual treats nil and empty slices as equal, as both decode from a blank yaml value
+func fieldEqual(old, new reflect.Value) bool {
+	if old.Kind() == reflect.Slice && old.Len() == 0 && new.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(old.Interface(), new.Interface())
 }
@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// decode migrates the yaml document bs to the CurrentVersion and strictly decodes it into v.
+// Every unknown field and every value that does not match the type of its field is reported,
+// prefixed with its path in the document. The version the document was written in is returned.
+func decode(bs []byte, v *Config) (int, error) {
+	raw, version, err := parseDocument(bs)
+	if err != nil {
+		return version, err
+	}
+	return version, decodeDocument(raw, v)
+}
+
+// parseDocument parses the yaml document bs and migrates it to the CurrentVersion,
+// the version the document was written in is returned.
+func parseDocument(bs []byte) (map[string]interface{}, int, error) {
+	raw, err := unmarshalDocument(bs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version, err := documentVersion(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := migrate(raw, version); err != nil {
+		return nil, version, err
+	}
+	return raw, version, nil
+}
+
+// unmarshalDocument parses the yaml document bs as it is written, without migrating it
+func unmarshalDocument(bs []byte) (map[string]interface{}, error) {
+	js, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse yaml")
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(js, &raw); err != nil {
+		return nil, errors.New("config must be a mapping of fields")
+	}
+	if raw == nil {
+		// an empty document keeps all defaults
+		raw = map[string]interface{}{}
+	}
+	return raw, nil
+}
+
+// decodeDocument strictly decodes a migrated document into v
+func decodeDocument(raw map[string]interface{}, v *Config) error {
+	if err := checkFields("", raw, reflect.TypeOf(Config{})); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(raw)
+	if err != nil {
+		return errors.Wrap(err, "could not encode migrated config")
+	}
+	return json.Unmarshal(js, v)
+}
+
+// checkFields walks the decoded document alongside the type it is decoded into, descending into the fields of
+// structs, the elements of lists and the values of maps
+func checkFields(path string, raw interface{}, t reflect.Type) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if raw == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		// elements are checked against the element type, so that unknown fields of list entries are reported as well
+		if list, ok := raw.([]interface{}); ok && t.Elem().Kind() != reflect.Uint8 {
+			var errs error
+			for i, item := range list {
+				if err := checkFields(fmt.Sprintf("%s[%d]", path, i), item, t.Elem()); err != nil {
+					errs = multierror.Append(errs, err)
+				}
+			}
+			return sortErrors(errs)
+		}
+	case reflect.Map:
+		if m, ok := raw.(map[string]interface{}); ok && t.Key().Kind() == reflect.String {
+			var errs error
+			for key, value := range m {
+				if err := checkFields(join(path, key), value, t.Elem()); err != nil {
+					errs = multierror.Append(errs, err)
+				}
+			}
+			return sortErrors(errs)
+		}
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fieldError(path, errors.Errorf("expected a mapping, got %s", describe(raw)))
+		}
+		fields := jsonFields(t)
+
+		var errs error
+		for key, value := range m {
+			field, ok := fields[key]
+			if !ok {
+				errs = multierror.Append(errs, fieldError(join(path, key), unknownField(key, fields)))
+				continue
+			}
+			if err := checkFields(join(path, key), value, field.Type); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		return sortErrors(errs)
+	}
+
+	// decode leaf values on their own so that every mismatch is reported rather than the first one
+	js, err := json.Marshal(raw)
+	if err != nil {
+		return fieldError(path, err)
+	}
+	if err := json.Unmarshal(js, reflect.New(t).Interface()); err != nil {
+		return fieldError(path, errors.Errorf("expected %s, got %s", t, describe(raw)))
+	}
+	return nil
+}
+
+// jsonFields indexes the fields of t by the name they are decoded from
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			for k, f := range jsonFields(field.Type) {
+				fields[k] = f
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func unknownField(key string, fields map[string]reflect.StructField) error {
+	for name := range fields {
+		if normalizeKey(name) == normalizeKey(key) {
+			return errors.Errorf("unknown field, did you mean %q?", name)
+		}
+	}
+	return errors.New("unknown field")
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+func describe(raw interface{}) string {
+	switch raw.(type) {
+	case map[string]interface{}:
+		return "a mapping"
+	case []interface{}:
+		return "a list"
+	case string:
+		return fmt.Sprintf("string %q", raw)
+	default:
+		return fmt.Sprintf("%T %v", raw, raw)
+	}
+}
+
+func fieldError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return errors.Wrap(err, path)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortErrors orders aggregated errors by message, maps are walked in random order
+func sortErrors(err error) error {
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		return err
+	}
+	sort.Slice(merr.Errors, func(i, j int) bool {
+		return merr.Errors[i].Error() < merr.Errors[j].Error()
+	})
+	return merr
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DropInDirName is the name of the directory next to the config file holding config fragments
+	DropInDirName = "config.d"
+	// EnvPrefix is the prefix of the environment variables overriding config fields, nested fields are
+	// separated by a double underscore, e.g. WINS_WHITE_LIST__PROXYPORTS overrides white_list.proxyPorts
+	EnvPrefix = "WINS_"
+	// SourceDefault is the source of the values that are not set by any file or environment variable
+	SourceDefault = "default"
+)
+
+// IgnoredEnvVars are environment variables starting with EnvPrefix that are not config overrides, such as the
+// variables used by the install scripts and the documentation to locate wins.exe
+var IgnoredEnvVars = []string{
+	"WINS_PATH",
+	"WINS_BIN_PATH",
+}
+
+// Sources maps the path of every field set while loading the effective config to where its value came from
+type Sources map[string]string
+
+// EffectiveField is a single value of the effective config and its source
+type EffectiveField struct {
+	Path   string `json:"path"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// DropInDir returns the drop-in directory of the config file at path
+func DropInDir(path string) string {
+	return filepath.Join(filepath.Dir(path), DropInDirName)
+}
+
+// LoadEffectiveConfig loads the config file at path into v, then merges the yaml fragments in its drop-in directory
+// in lexical order of their names and finally applies the EnvPrefix environment variable overrides. Mappings are merged
+// field by field, any other value replaces the previous one. The source of every value set is returned.
+func LoadEffectiveConfig(path string, v *Config) (Sources, error) {
+	if v == nil {
+		return nil, errors.New("config cannot be nil")
+	}
+
+	documents, err := effectiveDocuments(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs error
+	merged := map[string]interface{}{}
+	sources := Sources{}
+	for _, document := range documents {
+		bs, err := ioutil.ReadFile(document)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not read %s", document))
+			continue
+		}
+		raw, _, err := parseDocument(bs)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrap(err, document))
+			continue
+		}
+		mergeDocument("", merged, raw, reflect.TypeOf(Config{}), document, sources)
+	}
+
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if len(name) <= len(EnvPrefix) || !strings.EqualFold(name[:len(EnvPrefix)], EnvPrefix) || ignoredEnvVar(name) {
+			continue
+		}
+		raw, err := envDocument(name[len(EnvPrefix):], value)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "environment variable %s", name))
+			continue
+		}
+		mergeDocument("", merged, raw, reflect.TypeOf(Config{}), "env "+name, sources)
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	if err := decodeDocument(merged, v); err != nil {
+		return nil, err
+	}
+	return sources, v.Validate()
+}
+
+// ignoredEnvVar returns whether name is one of the IgnoredEnvVars, names are matched ignoring case like on Windows
+func ignoredEnvVar(name string) bool {
+	for _, ignored := range IgnoredEnvVars {
+		if strings.EqualFold(name, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadEffectiveConfig loads the effective config like LoadEffectiveConfig for a reload of the running server.
+// Unlike at startup, the config file at path has to exist, a deleted or renamed config file would otherwise revert
+// the server to the defaults, e.g. an empty process path whitelist allowing any process to be started.
+func ReloadEffectiveConfig(path string, v *Config) (Sources, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("config file %s does not exist, keeping the running configuration", path)
+		}
+		return nil, errors.Wrap(err, "could not load config")
+	}
+	return LoadEffectiveConfig(path, v)
+}
+
+// EffectiveFields flattens v into its fields, attributing each to the source it was set by
+func EffectiveFields(v *Config, sources Sources) ([]EffectiveField, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(js, &raw); err != nil {
+		return nil, err
+	}
+
+	var fields []EffectiveField
+	var flatten func(path string, value interface{}, t reflect.Type) error
+	flatten = func(path string, value interface{}, t reflect.Type) error {
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if m, ok := value.(map[string]interface{}); ok && t != nil && t.Kind() == reflect.Struct {
+			structFields := jsonFields(t)
+			for key, v := range m {
+				fieldType := reflect.TypeOf(v)
+				if field, ok := structFields[key]; ok {
+					fieldType = field.Type
+				}
+				if err := flatten(join(path, key), v, fieldType); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		js, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		source, ok := sources[path]
+		if !ok {
+			source = SourceDefault
+		}
+		fields = append(fields, EffectiveField{Path: path, Value: string(js), Source: source})
+		return nil
+	}
+	if err := flatten("", raw, reflect.TypeOf(Config{})); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Path < fields[j].Path
+	})
+	return fields, nil
+}
+
+// effectiveDocuments lists the config file at path, if it exists, followed by the fragments in its drop-in directory
+func effectiveDocuments(path string) ([]string, error) {
+	var documents []string
+	if stat, err := os.Stat(path); err == nil {
+		if stat.IsDir() {
+			return nil, errors.New("could not load config from directory")
+		}
+		documents = append(documents, path)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "could not load config")
+	}
+
+	entries, err := os.ReadDir(DropInDir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return documents, nil
+		}
+		return nil, errors.Wrap(err, "could not read config drop-in directory")
+	}
+	// entries are sorted by filename
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		documents = append(documents, filepath.Join(DropInDir(path), entry.Name()))
+	}
+	return documents, nil
+}
+
+// mergeDocument merges src into dst, recording the source of every field that is set.
+// Fields holding structs are merged field by field, any other value replaces the previous one.
+func mergeDocument(path string, dst, src map[string]interface{}, t reflect.Type, source string, sources Sources) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := jsonFields(t)
+
+	for key, value := range src {
+		fieldPath := join(path, key)
+		if key == "version" && path == "" {
+			// every document is migrated to the current version
+			dst[key] = value
+			continue
+		}
+
+		field, known := fields[key]
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if known && srcIsMap && isStruct(field.Type) {
+			if !dstIsMap {
+				dstMap = map[string]interface{}{}
+				dst[key] = dstMap
+			}
+			mergeDocument(fieldPath, dstMap, srcMap, field.Type, source, sources)
+			continue
+		}
+
+		dst[key] = value
+		for p := range sources {
+			if strings.HasPrefix(p, fieldPath+".") {
+				delete(sources, p)
+			}
+		}
+		sources[fieldPath] = source
+	}
+}
+
+// envDocument converts an environment variable override into a document setting a single field.
+// The name is matched against the field names ignoring case, dashes and underscores.
+func envDocument(name, value string) (map[string]interface{}, error) {
+	segments := strings.Split(name, "__")
+	t := reflect.TypeOf(Config{})
+
+	root := map[string]interface{}{}
+	current := root
+	for i, segment := range segments {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, errors.Errorf("%s does not have fields", strings.Join(segments[:i], "__"))
+		}
+
+		var key string
+		var field reflect.StructField
+		for k, f := range jsonFields(t) {
+			if normalizeKey(k) == normalizeKey(segment) {
+				key, field = k, f
+				break
+			}
+		}
+		if key == "" {
+			return nil, errors.Errorf("unknown field %s", segment)
+		}
+
+		if i == len(segments)-1 {
+			v, err := envValue(value, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			current[key] = v
+			break
+		}
+		next := map[string]interface{}{}
+		current[key] = next
+		current = next
+		t = field.Type
+	}
+	return root, nil
+}
+
+// envValue parses the value of an environment variable as yaml, strings are taken verbatim
+// and lists can also be given as comma separated values
+func envValue(value string, t reflect.Type) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		return value, nil
+	case t.Kind() == reflect.Slice && !strings.HasPrefix(strings.TrimSpace(value), "["):
+		var list []interface{}
+		if strings.TrimSpace(value) == "" {
+			return list, nil
+		}
+		for _, item := range strings.Split(value, ",") {
+			v, err := envValue(strings.TrimSpace(item), t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+
+	js, err := yaml.YAMLToJSON([]byte(value))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse value")
+	}
+	var v interface{}
+	if err := json.Unmarshal(js, &v); err != nil {
+		return nil, errors.Wrap(err, "could not parse value")
+	}
+	return v, nil
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CurrentVersion is the version of the config layout understood by this release.
+// Bump it along with a new entry in migrations whenever the layout changes incompatibly.
+const CurrentVersion = 1
+
+// migration upgrades a decoded config document from version 'from' to 'from + 1'
+type migration struct {
+	from        int
+	description string
+	migrate     func(raw map[string]interface{}) error
+}
+
+var migrations = []migration{
+	{
+		from:        0,
+		description: "drop the white_list fields process_paths and proxy_ports, which were never read",
+		migrate: func(raw map[string]interface{}) error {
+			whiteList, ok := raw["white_list"].(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			// process_paths and proxy_ports were never decoded before version 1, so the whitelist they
+			// describe was not enforced, dropping them keeps it that way instead of enforcing it on upgrade
+			for _, field := range [][2]string{{"process_paths", "processPaths"}, {"proxy_ports", "proxyPorts"}} {
+				from, to := field[0], field[1]
+				v, ok := whiteList[from]
+				if !ok {
+					continue
+				}
+				if l, ok := v.([]interface{}); ok && len(l) > 0 {
+					logrus.Warnf("white_list.%s was never read and is dropped, set white_list.%s to enforce %v", from, to, l)
+				}
+				delete(whiteList, from)
+			}
+			return nil
+		},
+	},
+}
+
+// migrate upgrades the decoded config document from the given version to the CurrentVersion in place
+func migrate(raw map[string]interface{}, version int) error {
+	if version > CurrentVersion {
+		return errors.Errorf("version: config version %d is newer than the supported version %d", version, CurrentVersion)
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		logrus.Debugf("Migrating config from version %d: %s", m.from, m.description)
+		if err := m.migrate(raw); err != nil {
+			return errors.Wrapf(err, "failed to migrate config from version %d", m.from)
+		}
+	}
+
+	raw["version"] = CurrentVersion
+	return nil
+}
+
+// documentVersion returns the version of the decoded document, documents without a version predate versioning
+func documentVersion(raw map[string]interface{}) (int, error) {
+	v, ok := raw["version"]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	f, ok := v.(float64)
+	if !ok || f != float64(int(f)) || f < 0 {
+		return 0, errors.Errorf("version: expected a positive integer, got %s", describe(v))
+	}
+	return int(f), nil
+}
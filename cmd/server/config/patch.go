@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// Patch is a document setting fields of the config, fields holding structs are merged field by field
+type Patch struct {
+	// Source names where the patch came from in errors and logs
+	Source   string
+	document map[string]interface{}
+}
+
+// ParsePatch parses a yaml or json document setting fields of the config, unknown fields are rejected
+func ParsePatch(source string, bs []byte) (Patch, error) {
+	js, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return Patch{}, errors.Wrapf(err, "%s: could not parse patch", source)
+	}
+
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(js, &document); err != nil {
+		return Patch{}, errors.Errorf("%s: patch must be a mapping of fields", source)
+	}
+	if err := checkFields("", document, reflect.TypeOf(Config{})); err != nil {
+		return Patch{}, errors.Wrap(err, source)
+	}
+	return Patch{Source: source, document: document}, nil
+}
+
+// FieldPatch sets a single field of the config. Nested fields are separated by a double underscore and
+// matched ignoring case, dashes and underscores, the value is parsed the same way as an EnvPrefix environment variable.
+func FieldPatch(source, name, value string) (Patch, error) {
+	document, err := envDocument(name, value)
+	if err != nil {
+		return Patch{}, errors.Wrap(err, source)
+	}
+	if err := checkFields("", document, reflect.TypeOf(Config{})); err != nil {
+		return Patch{}, errors.Wrap(err, source)
+	}
+	return Patch{Source: source, document: document}, nil
+}
+
+// Paths returns the paths of the fields set by the patch, e.g. "white_list.proxyPorts"
+func (p Patch) Paths() []string {
+	sources := Sources{}
+	mergeDocument("", map[string]interface{}{}, p.document, reflect.TypeOf(Config{}), p.Source, sources)
+
+	paths := make([]string, 0, len(sources))
+	for path := range sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ApplyPatches merges the patches into v in order and validates the result. The paths of the fields
+// whose value changed are returned, v is left untouched if an error is returned.
+func ApplyPatches(v *Config, patches ...Patch) ([]string, error) {
+	if v == nil {
+		return nil, errors.New("config cannot be nil")
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode config")
+	}
+	merged := map[string]interface{}{}
+	if err := json.Unmarshal(js, &merged); err != nil {
+		return nil, errors.Wrap(err, "could not encode config")
+	}
+	for _, patch := range patches {
+		mergeDocument("", merged, patch.document, reflect.TypeOf(Config{}), patch.Source, Sources{})
+	}
+
+	patched := &Config{}
+	if err := decodeDocument(merged, patched); err != nil {
+		return nil, err
+	}
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+
+	changed := ChangedFields(v, patched)
+	*v = *patched
+	return changed, nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/rancher/wins/pkg/services"
+)
+
+// RedactedValue replaces secrets in a redacted config
+const RedactedValue = "REDACTED"
+
+// Redacted returns a copy of c that is safe to expose over the API, credentials and query parameters embedded
+// into download URLs and the values of environment variables are replaced by RedactedValue.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if c.CSIProxy != nil {
+		csiProxy := *c.CSIProxy
+		csiProxy.URL = redactURL(csiProxy.URL)
+		csiProxy.EnvVars = redactValues(csiProxy.EnvVars)
+		redacted.CSIProxy = &csiProxy
+	}
+	if c.Services != nil {
+		redacted.Services = make([]services.Config, len(c.Services))
+		for i, service := range c.Services {
+			service.URL = redactURL(service.URL)
+			service.EnvVars = redactValues(service.EnvVars)
+			redacted.Services[i] = service
+		}
+	}
+	return &redacted
+}
+
+// redactURL replaces the user info and the query parameter values of rawURL. The URL is not parsed with net/url,
+// as the CSI Proxy URL is a format string that is not necessarily a valid URL.
+func redactURL(rawURL string) string {
+	u := rawURL
+	var fragment string
+	if i := strings.Index(u, "#"); i >= 0 {
+		u, fragment = u[:i], u[i:]
+	}
+
+	var query string
+	if i := strings.Index(u, "?"); i >= 0 {
+		u, query = u[:i], u[i+1:]
+	}
+
+	if i := strings.Index(u, "://"); i >= 0 {
+		authority := u[i+3:]
+		if j := strings.Index(authority, "/"); j >= 0 {
+			authority = authority[:j]
+		}
+		if j := strings.LastIndex(authority, "@"); j >= 0 {
+			u = u[:i+3] + RedactedValue + u[i+3+j:]
+		}
+	}
+
+	if query != "" {
+		params := strings.Split(query, "&")
+		for i, param := range params {
+			if key, _, ok := strings.Cut(param, "="); ok {
+				params[i] = key + "=" + RedactedValue
+			}
+		}
+		u += "?" + strings.Join(params, "&")
+	}
+	return u + fragment
+}
+
+func redactValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(m))
+	for k := range m {
+		redacted[k] = RedactedValue
+	}
+	return redacted
+}
@@ -613,6 +613,64 @@ csi-proxy:
         }
     }
 
+    function Lock-WinsHost() {
+        # The rancher-wins SUC holds the same lock while it updates the host, see suc/pkg/lock
+        $lockPath = Join-Path -Path $env:CATTLE_AGENT_CONFIG_DIR -ChildPath "suc.lock"
+        $owner = @{
+            pid        = $PID
+            hostname   = [System.Net.Dns]::GetHostName()
+            command    = "install.ps1"
+            acquiredAt = (Get-Date).ToUniversalTime().ToString("o")
+        } | ConvertTo-Json -Compress
+
+        for ($attempt = 0; $attempt -lt 2; $attempt++) {
+            try {
+                $stream = [System.IO.File]::Open($lockPath, [System.IO.FileMode]::CreateNew, [System.IO.FileAccess]::Write)
+                $writer = [System.IO.StreamWriter]::new($stream)
+                $writer.Write($owner)
+                $writer.Close()
+                $script:winsHostLock = $owner
+                Write-LogInfo "Acquired lock $lockPath"
+                return
+            }
+            catch [System.IO.IOException] {
+                if (-Not (Test-Path -Path $lockPath)) {
+                    continue
+                }
+            }
+
+            $held = Get-Content -Path $lockPath -Raw -ErrorAction SilentlyContinue | ConvertFrom-Json -ErrorAction SilentlyContinue
+            if ($held.acquiredAt) {
+                $acquiredAt = [DateTime]$held.acquiredAt
+            } else {
+                $acquiredAt = (Get-Item -Path $lockPath).LastWriteTime
+            }
+            $stale = ((Get-Date) - $acquiredAt).TotalHours -gt 1
+            if ($held.pid -and ($held.hostname -eq [System.Net.Dns]::GetHostName()) -and -Not (Get-Process -Id $held.pid -ErrorAction SilentlyContinue)) {
+                $stale = $true
+            }
+            if (-Not $stale) {
+                Write-LogFatal "Another update of rancher-wins is in progress, $lockPath is held by $($held.command) (pid $($held.pid)) since $acquiredAt"
+            }
+            Write-LogWarn "Removing stale lock $lockPath held by $($held.command) (pid $($held.pid)) since $acquiredAt"
+            Remove-Item -Path $lockPath -Force -ErrorAction SilentlyContinue
+        }
+        Write-LogFatal "Another update of rancher-wins is in progress, could not acquire $lockPath"
+    }
+
+    function Unlock-WinsHost() {
+        if (-Not $script:winsHostLock) {
+            return
+        }
+        $lockPath = Join-Path -Path $env:CATTLE_AGENT_CONFIG_DIR -ChildPath "suc.lock"
+        # the lock is left in place if it has been taken over in the meantime
+        if ((Get-Content -Path $lockPath -Raw -ErrorAction SilentlyContinue) -eq $script:winsHostLock) {
+            Remove-Item -Path $lockPath -Force
+            Write-LogInfo "Released lock $lockPath"
+        }
+        $script:winsHostLock = $null
+    }
+
     function New-CattleId() {
         if (-Not $env:CATTLE_ID) {
             Write-LogInfo "Generating Cattle ID"
@@ -784,6 +842,7 @@ csi-proxy:
         }
 
         Test-RancherConnection
+        Lock-WinsHost
         Stop-Agent -ServiceName $serviceName
         Invoke-WinsAgentDownload
         Copy-WinsForCharts
@@ -848,5 +907,10 @@ csi-proxy:
     }
 
     Confirm-WindowsFeatures -RequiredFeatures @("Containers")
-    Invoke-WinsAgentInstall
+    try {
+        Invoke-WinsAgentInstall
+    }
+    finally {
+        Unlock-WinsHost
+    }
 }
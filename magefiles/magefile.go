@@ -181,6 +181,9 @@ func BuildSUC() error {
 		log.Printf("failed to copy wins.exe to suc/pkg/host")
 		return err
 	}
+	if err := embedCSIProxy(); err != nil {
+		return err
+	}
 	winsSucOutput := filepath.Join("bin", "wins-suc.exe")
 	if err := g.Build(flags, "./suc/", winsSucOutput); err != nil {
 		return err
@@ -191,6 +194,23 @@ func BuildSUC() error {
 	return nil
 }
 
+// embedCSIProxy moves the CSI Proxy binary set by CSI_PROXY_EMBED_BINARY into the suc package so that it can
+// be embedded, along with its version set by CSI_PROXY_EMBED_VERSION. Without them, no CSI Proxy is embedded.
+func embedCSIProxy() error {
+	binary, csiProxyVersion := os.Getenv("CSI_PROXY_EMBED_BINARY"), os.Getenv("CSI_PROXY_EMBED_VERSION")
+	if binary == "" && csiProxyVersion == "" {
+		return nil
+	}
+	if binary == "" || csiProxyVersion == "" {
+		return fmt.Errorf("CSI_PROXY_EMBED_BINARY and CSI_PROXY_EMBED_VERSION must be set together")
+	}
+	log.Printf("[Build] Embedding CSI Proxy %s from %s \n", csiProxyVersion, binary)
+	if err := sh.Copy(filepath.Join("suc/pkg/host/artifacts/csi-proxy.exe"), binary); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join("suc/pkg/host/artifacts/csi-proxy.version"), []byte(csiProxyVersion), 0644)
+}
+
 func Test() error {
 	mg.Deps(BuildAll)
 	log.Printf("[Test] Testing wins version %s \n", version)
@@ -0,0 +1,44 @@
+package apis
+
+import (
+	"context"
+
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type agentService struct {
+	server *Server
+}
+
+func (s *agentService) Status(_ context.Context, _ *types.Void) (resp *types.AgentStatusResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.agent == nil {
+		return nil, status.Errorf(codes.Unimplemented, "system agent status reporting is not enabled")
+	}
+
+	return &types.AgentStatusResponse{
+		Status: s.server.agent.AgentStatus(),
+	}, nil
+}
+
+func (s *agentService) CheckConnection(ctx context.Context, _ *types.Void) (resp *types.AgentConnectionCheckResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.agent == nil {
+		return nil, status.Errorf(codes.Unimplemented, "system agent status reporting is not enabled")
+	}
+
+	resp, err := s.server.agent.CheckAgentConnection(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "could not check connection: %v", err)
+	}
+	return resp, nil
+}
@@ -8,9 +8,9 @@
 	"github.com/Microsoft/go-winio"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
-	"github.com/rancher/remotedialer"
 	"github.com/rancher/wins/pkg/npipes"
 	"github.com/rancher/wins/pkg/proxy"
+	"github.com/rancher/wins/pkg/services"
 	"github.com/rancher/wins/pkg/types"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -21,11 +21,40 @@ type Server struct {
 	listener net.Listener
 	proxy    proxyServer
 	server   *grpc.Server
+	reloader ConfigReloader
+	reporter StatusReporter
+	services ServiceManager
+	agent    AgentReporter
+}
+
+// ConfigReloader reloads the configuration of the running server on request of the ApplicationService.
+type ConfigReloader interface {
+	Reload() (*types.ApplicationReloadResponse, error)
+}
+
+// StatusReporter reports the effective configuration and the status of the components run alongside the server
+// on request of the ApplicationService. The listeners and the proxy port whitelist are filled in by the server.
+type StatusReporter interface {
+	Status() (*types.ApplicationStatus, error)
+}
+
+// ServiceManager manages the services configured in the server config on request of the ManagedServiceService.
+type ServiceManager interface {
+	List() []services.Status
+	Status(name string) (services.Status, error)
+	Restart(name string) error
+}
+
+// AgentReporter reports the status of the system agent and the last plan it applied, and checks its connection
+// to Rancher on request of the AgentService.
+type AgentReporter interface {
+	AgentStatus() *types.AgentStatus
+	CheckAgentConnection(ctx context.Context) (*types.AgentConnectionCheckResponse, error)
 }
 
 type proxyServer struct {
 	listener net.Listener
-	ports    []int
+	handler  *proxy.Handler
 }
 
 func (s *Server) Close() error {
@@ -42,7 +71,9 @@ func (s *Server) Serve(ctx context.Context) error {
 	types.RegisterHnsServiceServer(srv, &hnsService{})
 	types.RegisterRouteServiceServer(srv, &routeService{})
 	types.RegisterProcessServiceServer(srv, &processService{})
-	types.RegisterApplicationServiceServer(srv, &applicationService{})
+	types.RegisterApplicationServiceServer(srv, &applicationService{server: s})
+	types.RegisterManagedServiceServiceServer(srv, &managedServiceService{server: s})
+	types.RegisterAgentServiceServer(srv, &agentService{server: s})
 
 	errg, _ := errgroup.WithContext(ctx)
 
@@ -53,22 +84,59 @@ func (s *Server) Serve(ctx context.Context) error {
 
 	errg.Go(func() error {
 		logrus.Infof("Listening on %v", s.proxy.listener.Addr())
-		handler := remotedialer.New(proxy.GetServerAuthorizer(), remotedialer.DefaultErrorWriter)
-		handler.ClientConnectAuthorizer = proxy.GetClientConnectAuthorizer(s.proxy.ports)
-		return http.Serve(s.proxy.listener, handler)
+		return http.Serve(s.proxy.listener, s.proxy.handler)
 	})
 
 	return errg.Wait()
 }
 
-func NewServer(listen string, serverOptions []grpc.ServerOption, proxy string, proxyPorts []int) (*Server, error) {
+// SetConfigReloader sets the reloader invoked by the ApplicationService Reload call, it must be set before Serve.
+func (s *Server) SetConfigReloader(reloader ConfigReloader) {
+	s.reloader = reloader
+}
+
+// SetStatusReporter sets the reporter invoked by the ApplicationService Status call, it must be set before Serve.
+func (s *Server) SetStatusReporter(reporter StatusReporter) {
+	s.reporter = reporter
+}
+
+// SetServiceManager sets the manager invoked by the ManagedServiceService calls, it must be set before Serve.
+func (s *Server) SetServiceManager(manager ServiceManager) {
+	s.services = manager
+}
+
+// SetAgentReporter sets the reporter invoked by the AgentService Status call, it must be set before Serve.
+func (s *Server) SetAgentReporter(reporter AgentReporter) {
+	s.agent = reporter
+}
+
+// Listeners returns the addresses the server is listening on.
+func (s *Server) Listeners() []*types.ApplicationListener {
+	return []*types.ApplicationListener{
+		{Name: "grpc", Address: s.listener.Addr().String()},
+		{Name: "proxy", Address: s.proxy.listener.Addr().String()},
+	}
+}
+
+// SetProxyPorts replaces the proxy port whitelist, closing any proxy sessions connected to a removed port.
+func (s *Server) SetProxyPorts(proxyPorts []int) {
+	logrus.Infof("Updating proxy port whitelist to: %v", proxyPorts)
+	s.proxy.handler.SetPorts(proxyPorts)
+}
+
+// ProxyPorts returns the proxy port whitelist currently in effect.
+func (s *Server) ProxyPorts() []int {
+	return s.proxy.handler.Ports()
+}
+
+func NewServer(listen string, serverOptions []grpc.ServerOption, proxyName string, proxyPorts []int) (*Server, error) {
 	listenPath := npipes.GetFullPath(listen)
 	listener, err := npipes.New(listenPath, "", 0)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not listen %s", listenPath)
 	}
 
-	proxyPath := npipes.GetFullPath(proxy)
+	proxyPath := npipes.GetFullPath(proxyName)
 	path, err := npipes.ParsePath(proxyPath)
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not parse path %s", proxyPath)
@@ -77,13 +145,13 @@ func NewServer(listen string, serverOptions []grpc.ServerOption, proxy string, p
 	if err != nil {
 		return nil, errors.Wrapf(err, "could not listen %s", proxyPath)
 	}
-	logrus.Infof("listening for tcp requests on %s destined for: %v", proxy, proxyPorts)
+	logrus.Infof("listening for tcp requests on %s destined for: %v", proxyName, proxyPorts)
 
 	return &Server{
 		listener: listener,
 		proxy: proxyServer{
 			listener: proxyListener,
-			ports:    proxyPorts,
+			handler:  proxy.NewHandler(proxyPorts),
 		},
 		server: grpc.NewServer(serverOptions...),
 	}, nil
@@ -14,6 +14,7 @@
 )
 
 type applicationService struct {
+	server *Server
 }
 
 func (s *applicationService) Info(_ context.Context, _ *types.Void) (resp *types.ApplicationInfoResponse, respErr error) {
@@ -31,6 +32,48 @@ func (s *applicationService) Info(_ context.Context, _ *types.Void) (resp *types
 	}, nil
 }
 
+func (s *applicationService) Reload(_ context.Context, _ *types.Void) (resp *types.ApplicationReloadResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.reloader == nil {
+		return nil, status.Errorf(codes.Unimplemented, "configuration reload is not enabled")
+	}
+
+	resp, err := s.server.reloader.Reload()
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "could not reload configuration: %v", err)
+	}
+
+	return resp, nil
+}
+
+func (s *applicationService) Status(_ context.Context, _ *types.Void) (resp *types.ApplicationStatusResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.reporter == nil {
+		return nil, status.Errorf(codes.Unimplemented, "status reporting is not enabled")
+	}
+
+	st, err := s.server.reporter.Status()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not get status: %v", err)
+	}
+
+	st.Listeners = s.server.Listeners()
+	st.ProxyPortWhiteList = nil
+	for _, port := range s.server.ProxyPorts() {
+		st.ProxyPortWhiteList = append(st.ProxyPortWhiteList, int32(port))
+	}
+
+	return &types.ApplicationStatusResponse{
+		Status: st,
+	}, nil
+}
+
 func getActualInfo() (*types.ApplicationInfo, error) {
 	serverChecksum, err := paths.GetBinarySHA1Hash(os.Args[0])
 	if err != nil {
@@ -0,0 +1,85 @@
+package apis
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/pkg/panics"
+	"github.com/rancher/wins/pkg/services"
+	"github.com/rancher/wins/pkg/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type managedServiceService struct {
+	server *Server
+}
+
+func (s *managedServiceService) List(_ context.Context, _ *types.Void) (resp *types.ManagedServiceListResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.services == nil {
+		return nil, status.Errorf(codes.Unimplemented, "managed services are not enabled")
+	}
+
+	resp = &types.ManagedServiceListResponse{}
+	for _, st := range s.server.services.List() {
+		resp.Services = append(resp.Services, toManagedServiceStatus(st))
+	}
+	return resp, nil
+}
+
+func (s *managedServiceService) Status(_ context.Context, req *types.ManagedServiceRequest) (resp *types.ManagedServiceStatusResponse, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.services == nil {
+		return nil, status.Errorf(codes.Unimplemented, "managed services are not enabled")
+	}
+
+	st, err := s.server.services.Status(req.Name)
+	if err != nil {
+		return nil, managedServiceError(err)
+	}
+
+	return &types.ManagedServiceStatusResponse{
+		Status: toManagedServiceStatus(st),
+	}, nil
+}
+
+func (s *managedServiceService) Restart(_ context.Context, req *types.ManagedServiceRequest) (resp *types.Void, respErr error) {
+	defer panics.DealWith(func(recoverObj interface{}) {
+		respErr = status.Errorf(codes.Unknown, "panic %v", recoverObj)
+	})
+
+	if s.server.services == nil {
+		return nil, status.Errorf(codes.Unimplemented, "managed services are not enabled")
+	}
+
+	if err := s.server.services.Restart(req.Name); err != nil {
+		return nil, managedServiceError(err)
+	}
+
+	return &types.Void{}, nil
+}
+
+func managedServiceError(err error) error {
+	if errors.Is(err, services.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "%v", err)
+}
+
+func toManagedServiceStatus(st services.Status) *types.ManagedServiceStatus {
+	return &types.ManagedServiceStatus{
+		Name:        st.Name,
+		DisplayName: st.DisplayName,
+		BinaryPath:  st.BinaryPath,
+		StartType:   st.StartType,
+		State:       st.State,
+		Error:       st.Error,
+	}
+}
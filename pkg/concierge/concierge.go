@@ -1,28 +1,35 @@
 package concierge
 
 import (
-	"fmt"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rancher/wins/pkg/winservice"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sys/windows"
-	"golang.org/x/sys/windows/registry"
 	"golang.org/x/sys/windows/svc"
-	"golang.org/x/sys/windows/svc/mgr"
 )
 
+// recoveryActions restart a failed service after 10 seconds
+var recoveryActions = []winservice.RecoveryAction{
+	{
+		Type:  winservice.RecoveryRestart,
+		Delay: 10 * time.Second,
+	},
+}
+
 type Config struct {
 	Args        []string
 	Description string
 	DisplayName string
 	EnvVars     []string
-	registryKey string
 }
 
 type Concierge struct {
 	name string
 	path string
 	cfg  *Config
+	ctl  winservice.Controller
 }
 
 // New creates a new Concierge for managing a Windows Service.
@@ -38,18 +45,16 @@ func New(name, path string, cfg *Config) (*Concierge, error) {
 		return nil, errors.New("cfg is nil, please provide at least an empty config")
 	}
 
-	cfg.registryKey = fmt.Sprintf(`SYSTEM\CurrentControlSet\Services\%s`, name)
-
 	return &Concierge{
 		name: name,
 		path: path,
 		cfg:  cfg,
+		ctl:  winservice.New(),
 	}, nil
 }
 
 // Enable will start the Windows Service. If the service doesn't exist it will create it.
 func (c *Concierge) Enable() error {
-	var service *mgr.Service
 	ok, err := c.ServiceExists()
 	if err != nil {
 		return errors.Wrap(err, "error checking if the service exists")
@@ -60,17 +65,11 @@ func (c *Concierge) Enable() error {
 		}
 	}
 
-	if service, err = c.fetchService(); err != nil {
-		return errors.Wrap(err, "error fetching the service")
-	}
-	defer service.Close()
-
-	return service.Start()
+	return c.ctl.Start(c.name)
 }
 
 // Disable will stop the Windows Service.
 func (c *Concierge) Disable() error {
-	var service *mgr.Service
 	ok, err := c.ServiceExists()
 	if err != nil {
 		return errors.Wrap(err, "error checking if the service exists")
@@ -79,12 +78,7 @@ func (c *Concierge) Disable() error {
 		return errors.Errorf("service %s not found", c.path)
 	}
 
-	if service, err = c.fetchService(); err != nil {
-		return errors.Wrap(err, "error fetching the service")
-	}
-	defer service.Close()
-
-	if _, err := service.Control(svc.Stop); err != nil {
+	if err := c.ctl.Stop(c.name); err != nil {
 		return errors.Wrap(err, "error stopping the service")
 	}
 	return nil
@@ -92,43 +86,54 @@ func (c *Concierge) Disable() error {
 
 // CreateService configures the Windows service correctly, returning the service.
 func (c *Concierge) CreateService() error {
-	m, err := mgr.Connect()
-	if err != nil {
-		return errors.Wrap(err, "could not open SCM")
-	}
-	defer m.Disconnect()
-
-	service, err := m.CreateService(c.name, c.path, mgr.Config{
-		ServiceType:    windows.SERVICE_WIN32_OWN_PROCESS,
-		StartType:      mgr.StartAutomatic,
-		ErrorControl:   mgr.ErrorNormal,
-		BinaryPathName: c.path,
-		Description:    c.cfg.Description,
-		DisplayName:    c.cfg.DisplayName,
-	}, c.cfg.Args...)
+	return c.ctl.Create(c.name, c.serviceConfig(c.cfg))
+}
 
-	defer service.Close()
+// Update diffs cfg against the live config of the service and applies only the changes to the command line,
+// description, display name, recovery actions and environment variables. It returns whether anything changed,
+// the service has to be restarted to pick up the changes.
+func (c *Concierge) Update(cfg *Config) (bool, error) {
+	if cfg == nil {
+		return false, errors.New("cfg is nil, please provide at least an empty config")
+	}
 
+	live, err := c.ctl.Config(c.name)
 	if err != nil {
-		return errors.Wrap(err, "error creating the service")
+		return false, errors.Wrap(err, "error querying the service config")
 	}
+	desired := c.serviceConfig(cfg)
+	// settings that are not managed by the concierge are left as they are
+	desired.StartType, desired.DelayedAutoStart, desired.Dependencies = live.StartType, live.DelayedAutoStart, live.Dependencies
 
-	recoveryActions := []mgr.RecoveryAction{
-		{
-			Type:  mgr.ServiceRestart,
-			Delay: 10000,
-		},
+	c.cfg = cfg
+	changed := winservice.Diff(live, desired)
+	if len(changed) == 0 {
+		return false, nil
 	}
-	if err := c.registerEnvVars(); err != nil {
-		return err
+	if err := c.ctl.Update(c.name, desired); err != nil {
+		return false, errors.Wrap(err, "error updating the service config")
 	}
+	logrus.Infof("Updated %s of service %s", strings.Join(changed, ", "), c.name)
+	return true, nil
+}
+
+// Start starts the service if it is not running already and waits for it to be running within timeout.
+func (c *Concierge) Start(timeout time.Duration) error {
+	return winservice.Start(c.ctl, c.name, timeout)
+}
 
-	return service.SetRecoveryActions(recoveryActions, 0)
+// Stop stops the service if it is not stopped already and waits for it to be stopped within timeout.
+func (c *Concierge) Stop(timeout time.Duration) error {
+	return winservice.Stop(c.ctl, c.name, timeout)
+}
+
+// WaitForState polls the state of the service until it reaches the desired state, failing once timeout passed.
+func (c *Concierge) WaitForState(desired svc.State, timeout time.Duration) error {
+	return winservice.WaitForState(c.ctl, c.name, winservice.State(desired), timeout)
 }
 
 // Delete removes the service and any registry keys.
 func (c *Concierge) Delete() error {
-	var service *mgr.Service
 	ok, err := c.ServiceExists()
 	if err != nil {
 		return errors.Wrap(err, "error checking if the service exists")
@@ -137,81 +142,37 @@ func (c *Concierge) Delete() error {
 		return errors.Errorf("service %s not found", c.path)
 	}
 
-	if service, err = c.fetchService(); err != nil {
-		return errors.Wrap(err, "error fetching the service")
-	}
-	defer service.Close()
-
-	return service.Delete()
+	return c.ctl.Delete(c.name)
 }
 
 // ServiceExists retrieves the Windows service if exists.
 func (c *Concierge) ServiceExists() (bool, error) {
-	m, err := mgr.Connect()
-	if err != nil {
-		return false, errors.Wrap(err, "could not open SCM")
-	}
-	defer func(m *mgr.Mgr) {
-		_ = m.Disconnect()
-	}(m)
-
-	services, err := m.ListServices()
-	if err != nil {
-		return false, errors.Wrap(err, "could not list services")
-	}
-
-	for _, service := range services {
-		if service == c.name {
-			return true, nil
-		}
-	}
-
-	return false, nil
+	return c.ctl.Exists(c.name)
 }
 
 // State gets the state of the service. Examples are stopped, running, etc.
 func (c *Concierge) State() (svc.State, error) {
-	service, err := c.fetchService()
-	if err != nil {
-		return svc.State(windows.SERVICE_NO_CHANGE), errors.Wrap(err, "error opening the service")
-	}
-	defer service.Close()
-
-	status, err := service.Query()
+	state, err := c.ctl.State(c.name)
 	if err != nil {
-		return svc.State(windows.SERVICE_NO_CHANGE), errors.Wrap(err, "error querying the service")
+		return 0, errors.Wrap(err, "error querying the service")
 	}
-	return status.State, nil
+	return svc.State(state), nil
 }
 
-// fetchService retrieves the Windows service.
-func (c *Concierge) fetchService() (*mgr.Service, error) {
-	m, err := mgr.Connect()
-	if err != nil {
-		return nil, errors.Wrap(err, "could not open SCM")
-	}
-	defer m.Disconnect()
-
-	service, err := m.OpenService(c.name)
-	if err != nil {
-		return nil, errors.Wrap(err, "could not open service")
-	}
-
-	return service, nil
+// StateString translates a svc.State to its string representation.
+func StateString(state svc.State) string {
+	return winservice.State(state).String()
 }
 
-// registerEnvVars creates a registry key for the service to set environment variables.
-func (c *Concierge) registerEnvVars() error {
-	if len(c.cfg.EnvVars) == 0 {
-		logrus.Infof("skipping environment variable configuration for %s, none are provided", c.name)
-		return nil
+// serviceConfig returns the config of the service running the binary at the path of the concierge
+func (c *Concierge) serviceConfig(cfg *Config) *winservice.Config {
+	return &winservice.Config{
+		BinaryPath:      c.path,
+		Args:            cfg.Args,
+		DisplayName:     cfg.DisplayName,
+		Description:     cfg.Description,
+		StartType:       winservice.StartAutomatic,
+		RecoveryActions: recoveryActions,
+		EnvVars:         cfg.EnvVars,
 	}
-
-	k, err := registry.OpenKey(registry.LOCAL_MACHINE, c.cfg.registryKey, registry.WRITE)
-	if err != nil {
-		return errors.Wrap(err, "error opening registry key")
-	}
-	defer k.Close()
-
-	return k.SetStringsValue("Environment", c.cfg.EnvVars)
 }
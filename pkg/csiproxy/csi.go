@@ -3,31 +3,65 @@
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	"github.com/rancher/wins/pkg/concierge"
 	winstls "github.com/rancher/wins/pkg/tls"
+	"golang.org/x/sys/windows/svc"
 )
 
 const (
-	exeName     = "csi-proxy.exe"
-	serviceName = "csiproxy"
+	exeName        = "csi-proxy.exe"
+	stateName      = "csi-proxy.json"
+	defaultLogPath = "\\etc\\rancher\\wins\\csi-proxy.log"
+	// defaultKubeletPath is the kubelet directory CSI Proxy uses if it is not passed -kubelet-path
+	defaultKubeletPath = "C:\\var\\lib\\kubelet"
+
+	// ServiceName is the name of the Windows service CSI Proxy is installed as
+	ServiceName = "csiproxy"
 )
 
+// apiGroups are the API groups served by CSI Proxy
+var apiGroups = []string{"disk", "filesystem", "iscsi", "smb", "system", "volume"}
+
+var apiVersionRegexp = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]+)?$`)
+
 // Config is the CSI Proxy config settings
 type Config struct {
 	URL         string `yaml:"url" json:"url"`
 	Version     string `yaml:"version" json:"version"`
 	KubeletPath string `yaml:"kubeletPath" json:"kubeletPath"`
+	// SHA256 is the expected checksum of the downloaded archive, it is not verified if empty
+	SHA256 string `yaml:"sha256" json:"sha256,omitempty"`
+	// LogPath is the file CSI Proxy logs to
+	LogPath string `yaml:"logPath" json:"logPath,omitempty"`
+	// APIGroups are the API group versions the health monitor probes, formatted as <group>/<version>. They are not
+	// passed to CSI Proxy, which serves every group it supports, as not every release accepts an -api-groups flag.
+	APIGroups []string `yaml:"apiGroups" json:"apiGroups,omitempty"`
+	// ExtraArgs are appended to the arguments of the service
+	ExtraArgs []string `yaml:"extraArgs" json:"extraArgs,omitempty"`
+	// EnvVars are set in the environment of the service
+	EnvVars map[string]string `yaml:"envVars" json:"envVars,omitempty"`
+	// Monitor configures the health monitor of the service
+	Monitor MonitorConfig `yaml:"monitor" json:"monitor"`
 }
 
 // Validate ensures that the configuration for CSI Proxy is correct if provided.
@@ -43,7 +77,78 @@ func (c *Config) validate() error {
 	if strings.TrimSpace(c.KubeletPath) == "" {
 		return errors.New("kubelet path cannot be empty")
 	}
-	return nil
+
+	if c.SHA256 != "" {
+		if checksum, err := hex.DecodeString(c.SHA256); err != nil || len(checksum) != sha256.Size {
+			return errors.Errorf("CSI Proxy sha256 %q is not a hex encoded SHA256 checksum", c.SHA256)
+		}
+	}
+
+	for _, apiGroup := range c.APIGroups {
+		group, version, _ := strings.Cut(apiGroup, "/")
+		if !contains(apiGroups, group) || !apiVersionRegexp.MatchString(version) {
+			return errors.Errorf("CSI Proxy API group %q must be formatted as <group>/<version> with a group out of %v", apiGroup, apiGroups)
+		}
+	}
+
+	for name := range c.EnvVars {
+		if name == "" || strings.Contains(name, "=") {
+			return errors.Errorf("CSI Proxy environment variable name %q is invalid", name)
+		}
+	}
+	return c.Monitor.validate()
+}
+
+// args returns the command line arguments of the CSI Proxy service
+func (c *Config) args() []string {
+	logPath := c.LogPath
+	if logPath == "" {
+		logPath = defaultLogPath
+	}
+	args := []string{"-windows-service", "-log_file=" + logPath, "-logtostderr=false"}
+	// the default is left out so that services installed before the kubelet path was passed are not restarted
+	if !samePath(c.KubeletPath, defaultKubeletPath) {
+		args = append(args, "-kubelet-path="+c.KubeletPath)
+	}
+	return append(args, c.ExtraArgs...)
+}
+
+// samePath returns whether the Windows paths a and b are the same, ignoring case, the kind of separator and trailing separators
+func samePath(a, b string) bool {
+	normalize := func(p string) string {
+		return strings.TrimRight(strings.ReplaceAll(p, "/", "\\"), "\\")
+	}
+	return strings.EqualFold(normalize(a), normalize(b))
+}
+
+// envVars returns the environment variables of the CSI Proxy service as a sorted list of NAME=value
+func (c *Config) envVars() []string {
+	var envVars []string
+	for name, value := range c.EnvVars {
+		envVars = append(envVars, name+"="+value)
+	}
+	sort.Strings(envVars)
+	return envVars
+}
+
+// installation records what has been applied to the installed CSI Proxy
+type installation struct {
+	Version string   `json:"version"`
+	Args    []string `json:"args"`
+	EnvVars []string `json:"envVars,omitempty"`
+}
+
+// legacyArgs are the arguments of services installed before the installation was recorded
+var legacyArgs = []string{"-windows-service", "-log_file=" + defaultLogPath, "-logtostderr=false"}
+
+// service controls the CSI Proxy Windows service
+type service interface {
+	ServiceExists() (bool, error)
+	Enable() error
+	Update(cfg *concierge.Config) (bool, error)
+	Start(timeout time.Duration) error
+	Stop(timeout time.Duration) error
+	State() (svc.State, error)
 }
 
 // Proxy is for creating and retrieving the Windows Service
@@ -53,7 +158,12 @@ type Proxy struct {
 	serviceName string
 	binaryName  string
 	binaryPath  string
-	concierge   *concierge.Concierge
+	statePath   string
+	concierge   service
+	// stateTimeout bounds the wait for the service to start or stop, and
+	// settleTime is how long a started service has to keep running
+	stateTimeout time.Duration
+	settleTime   time.Duration
 }
 
 // New creates a new Proxy struct
@@ -67,102 +177,359 @@ func New(cfg *Config, tlsCfg *winstls.Config) (*Proxy, error) {
 		return nil, err
 	}
 
-	config := concierge.Config{
-		Args:        []string{"-windows-service", "-log_file=\\etc\\rancher\\wins\\csi-proxy.log", "-logtostderr=false"},
-		Description: "Manages the Kubernetes CSI Proxy application.",
-		DisplayName: "CSI Proxy",
-		EnvVars:     nil,
-	}
-
-	service, err := concierge.New(serviceName, filepath.Join(cwd, exeName), &config)
+	service, err := concierge.New(ServiceName, filepath.Join(cwd, exeName), serviceConfig(desired(cfg)))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Proxy{
-		cfg:         cfg,
-		tlsCfg:      tlsCfg,
-		serviceName: serviceName,
-		binaryName:  exeName,
-		binaryPath:  filepath.Join(cwd, exeName),
-		concierge:   service,
+		cfg:          cfg,
+		tlsCfg:       tlsCfg,
+		serviceName:  ServiceName,
+		binaryName:   exeName,
+		binaryPath:   filepath.Join(cwd, exeName),
+		statePath:    filepath.Join(cwd, stateName),
+		concierge:    service,
+		stateTimeout: 60 * time.Second,
+		settleTime:   5 * time.Second,
 	}, nil
 }
 
+// Enable downloads CSI Proxy, creates the Windows service and starts it. If the service exists already
+// but the installed version or the service config differs from the configured one, the binary is
+// upgraded and the service is reconfigured in place.
 func (p *Proxy) Enable() error {
+	desired := desired(p.cfg)
 	ok, err := p.concierge.ServiceExists()
 	if err != nil {
 		return err
 	}
 	if !ok {
-		if p.tlsCfg != nil && p.tlsCfg.CertFilePath != "" {
-			// CSI Proxy does not need the certpool that is returned
-			_, err := p.tlsCfg.SetupGenericTLSConfigFromFile()
-			if err != nil {
-
-				return err
-			}
+		logrus.Infof("CSI Proxy %s is being downloaded.", p.cfg.Version)
+		downloaded, err := p.download()
+		if err != nil {
+			return err
 		}
-		logrus.Infof("CSI Proxy is being downloaded.")
-		if err := p.download(); err != nil {
+		if err := os.Rename(downloaded, p.binaryPath); err != nil {
+			_ = os.Remove(downloaded)
+			return errors.Wrap(err, "could not install CSI Proxy binary")
+		}
+		if err := p.writeInstallation(desired); err != nil {
 			return err
 		}
 		logrus.Infof("CSI Proxy is being started.")
-		if err := p.concierge.Enable(); err != nil {
-			return err
+		return p.concierge.Enable()
+	}
+
+	installed, err := p.installed()
+	if err != nil {
+		return err
+	}
+	if installed == nil {
+		// installed before the installation was recorded, the version of the binary is unknown, so it is
+		// replaced by the configured version once. The installation is only recorded once the replaced binary runs.
+		installed = &installation{Args: legacyArgs}
+	}
+
+	switch {
+	case installed.Version != desired.Version:
+		return p.upgrade(installed, desired)
+	case !reflect.DeepEqual(installed.Args, desired.Args) || !reflect.DeepEqual(installed.EnvVars, desired.EnvVars):
+		logrus.Infof("CSI Proxy service is being reconfigured.")
+		if err := p.apply(installed, desired, ""); err != nil {
+			return errors.Wrap(err, "could not reconfigure CSI Proxy")
 		}
+		logrus.Infof("CSI Proxy service has been reconfigured.")
 	}
 	return nil
 }
 
-// download retrieves the CSI Proxy executable from the config settings.
-func (p *Proxy) download() error {
-	file, err := os.Create(p.binaryPath)
+// State returns the state of the CSI Proxy service.
+func (p *Proxy) State() (string, error) {
+	state, err := p.concierge.State()
+	if err != nil {
+		return "", err
+	}
+	return concierge.StateString(state), nil
+}
+
+// upgrade replaces the installed CSI Proxy binary with the configured version. The new binary is downloaded
+// before the service is stopped.
+func (p *Proxy) upgrade(installed, desired *installation) error {
+	if installed.Version == "" {
+		logrus.Infof("CSI Proxy of an unknown version is being replaced by %s.", desired.Version)
+	} else {
+		logrus.Infof("CSI Proxy is being upgraded from %s to %s.", installed.Version, desired.Version)
+	}
+	downloaded, err := p.download()
+	if err != nil {
+		return errors.Wrapf(err, "could not download CSI Proxy %s", desired.Version)
+	}
+	defer func() {
+		_ = os.Remove(downloaded)
+	}()
+
+	if err := p.apply(installed, desired, downloaded); err != nil {
+		return errors.Wrapf(err, "could not upgrade CSI Proxy to %s", desired.Version)
+	}
+	logrus.Infof("CSI Proxy has been upgraded to %s.", desired.Version)
+	return nil
+}
+
+// apply stops the service, replaces the binary with the downloaded one if given, reconfigures the service if its
+// config changed and starts it again. The previous binary and service config are restored if the service does not
+// keep running.
+func (p *Proxy) apply(installed, desired *installation, downloaded string) error {
+	if err := p.stop(); err != nil {
+		return errors.Wrap(err, "could not stop CSI Proxy")
+	}
+
+	var backupPath string
+	if downloaded != "" {
+		backupPath = p.binaryPath + ".bak"
+		if err := os.Rename(p.binaryPath, backupPath); err != nil {
+			return multierror.Append(errors.Wrap(err, "could not back up CSI Proxy binary"), p.start())
+		}
+		if err := os.Rename(downloaded, p.binaryPath); err != nil {
+			return p.rollback(installed, desired, backupPath, errors.Wrap(err, "could not replace CSI Proxy binary"))
+		}
+	}
+	if err := p.reconfigure(installed, desired); err != nil {
+		return p.rollback(installed, desired, backupPath, err)
+	}
+	if err := p.start(); err != nil {
+		return p.rollback(installed, desired, backupPath, errors.Wrap(err, "could not start CSI Proxy"))
+	}
+
+	if backupPath != "" {
+		if err := os.Remove(backupPath); err != nil {
+			logrus.Warnf("Could not remove CSI Proxy backup %s: %v", backupPath, err)
+		}
+	}
+	return p.writeInstallation(desired)
+}
+
+// rollback restores the backed up binary, if any, and the previous service config after a failed change
+// and starts the service again.
+func (p *Proxy) rollback(installed, desired *installation, backupPath string, cause error) error {
+	logrus.Errorf("Rolling back CSI Proxy changes: %v", cause)
+	errs := multierror.Append(nil, cause)
+	if err := p.stop(); err != nil {
+		errs = multierror.Append(errs, errors.Wrap(err, "could not stop CSI Proxy for rollback"))
+		return errs
+	}
+	if backupPath != "" {
+		if err := os.Rename(backupPath, p.binaryPath); err != nil {
+			errs = multierror.Append(errs, errors.Wrap(err, "could not restore CSI Proxy binary"))
+			return errs
+		}
+	}
+	if err := p.reconfigure(desired, installed); err != nil {
+		errs = multierror.Append(errs, errors.Wrap(err, "could not restore CSI Proxy service config"))
+		return errs
+	}
+	if err := p.start(); err != nil {
+		errs = multierror.Append(errs, errors.Wrap(err, "could not start restored CSI Proxy"))
+	}
+	return errs
+}
+
+// reconfigure updates the service config if it differs between from and to.
+func (p *Proxy) reconfigure(from, to *installation) error {
+	if reflect.DeepEqual(from.Args, to.Args) && reflect.DeepEqual(from.EnvVars, to.EnvVars) {
+		return nil
+	}
+	if _, err := p.concierge.Update(serviceConfig(to)); err != nil {
+		return errors.Wrap(err, "could not reconfigure CSI Proxy service")
+	}
+	return nil
+}
+
+// start starts the service and ensures it keeps running past the settle time.
+func (p *Proxy) start() error {
+	if err := p.concierge.Start(p.stateTimeout); err != nil {
+		return err
+	}
+	time.Sleep(p.settleTime)
+	state, err := p.concierge.State()
 	if err != nil {
 		return err
 	}
+	if state != svc.Running {
+		return errors.Errorf("service stopped after starting, state is %s", concierge.StateString(state))
+	}
+	return nil
+}
 
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
+// stop stops the service if it is not stopped already.
+func (p *Proxy) stop() error {
+	return p.concierge.Stop(p.stateTimeout)
+}
 
-	client := http.Client{
-		CheckRedirect: func(r *http.Request, _ []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
+// installed returns what has been applied to the installed CSI Proxy, if it has been recorded.
+func (p *Proxy) installed() (*installation, error) {
+	bs, err := os.ReadFile(p.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "could not read installed CSI Proxy state")
+	}
+	installed := &installation{}
+	if err := json.Unmarshal(bs, installed); err != nil {
+		return nil, errors.Wrap(err, "could not parse installed CSI Proxy state")
 	}
+	return installed, nil
+}
 
-	// default to insecure which matches system-agent functionality
-	// if a proxy is set with the proper envvars, we will use it
-	// as long as the req does not match an entry in no_proxy env var
-	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, Proxy: http.ProxyFromEnvironment}
+func (p *Proxy) writeInstallation(installed *installation) error {
+	bs, err := json.Marshal(installed)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.statePath, bs, 0644); err != nil {
+		return errors.Wrap(err, "could not record installed CSI Proxy state")
+	}
+	return nil
+}
+
+// StatePath returns the path of the file recording what has been applied to the CSI Proxy binary at binaryPath
+func StatePath(binaryPath string) string {
+	return filepath.Join(filepath.Dir(binaryPath), stateName)
+}
 
-	if p.tlsCfg != nil && p.tlsCfg.Insecure != nil && !*p.tlsCfg.Insecure && p.tlsCfg.CertFilePath != "" {
-		transport.TLSClientConfig.InsecureSkipVerify = false
+// RecordedVersion returns the version recorded for the CSI Proxy binary at binaryPath, it is empty if
+// the installation has not been recorded.
+func RecordedVersion(binaryPath string) (string, error) {
+	p := &Proxy{statePath: StatePath(binaryPath)}
+	installed, err := p.installed()
+	if err != nil || installed == nil {
+		return "", err
 	}
+	return installed.Version, nil
+}
 
-	client.Transport = transport
+// RecordVersion records that the CSI Proxy binary at binaryPath has been replaced by version outside of Enable,
+// so that Enable does not download it again once the config requests that version. The recorded service config
+// is kept as it is.
+func RecordVersion(binaryPath, version string) error {
+	p := &Proxy{statePath: StatePath(binaryPath)}
+	installed, err := p.installed()
+	if err != nil {
+		return err
+	}
+	if installed == nil {
+		installed = &installation{Args: legacyArgs}
+	}
+	installed.Version = version
+	return p.writeInstallation(installed)
+}
+
+// desired returns the installation configured by cfg
+func desired(cfg *Config) *installation {
+	return &installation{
+		Version: cfg.Version,
+		Args:    cfg.args(),
+		EnvVars: cfg.envVars(),
+	}
+}
 
+// serviceConfig returns the config of the CSI Proxy service for an installation
+func serviceConfig(i *installation) *concierge.Config {
+	return &concierge.Config{
+		Args:        i.Args,
+		Description: "Manages the Kubernetes CSI Proxy application.",
+		DisplayName: "CSI Proxy",
+		EnvVars:     i.EnvVars,
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// httpClient returns a client verifying the server certificate against the system certificates and the configured
+// certificate, unless the TLS config is explicitly set to be insecure.
+func (p *Proxy) httpClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if p.tlsCfg != nil {
+		if p.tlsCfg.Insecure != nil && *p.tlsCfg.Insecure {
+			logrus.Warnf("Skipping TLS verification for CSI Proxy download")
+			tlsConfig.InsecureSkipVerify = true
+		}
+		if p.tlsCfg.CertFilePath != "" {
+			certPool, err := p.tlsCfg.SetupGenericTLSConfigFromFile()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = certPool
+		}
+	}
+
+	return &http.Client{
+		CheckRedirect: func(r *http.Request, _ []*http.Request) error {
+			r.URL.Opaque = r.URL.Path
+			return nil
+		},
+		// if a proxy is set with the proper envvars, we will use it
+		// as long as the req does not match an entry in no_proxy env var
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}, nil
+}
+
+// download retrieves the CSI Proxy executable from the config settings into a temporary file next to the binary,
+// verifying the checksum of the archive if configured. The path of the temporary file is returned.
+func (p *Proxy) download() (downloaded string, err error) {
+	client, err := p.httpClient()
+	if err != nil {
+		return "", err
+	}
 	defer client.CloseIdleConnections()
 
-	resp, err := client.Get(fmt.Sprintf(p.cfg.URL, p.cfg.Version))
+	url := fmt.Sprintf(p.cfg.URL, p.cfg.Version)
+	resp, err := client.Get(url)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
 
-	gz, err := gzip.NewReader(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("could not download %s: %s", url, resp.Status)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(p.binaryPath), p.binaryName+".*.tmp")
 	if err != nil {
-		return err
+		return "", err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	hash := sha256.New()
+	body := io.TeeReader(resp.Body, hash)
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return "", err
 	}
 	defer func(gz *gzip.Reader) {
 		_ = gz.Close()
 	}(gz)
 
+	found := false
 	tr := tar.NewReader(gz)
 	for {
 		hdr, err := tr.Next()
@@ -171,14 +538,30 @@ func (p *Proxy) download() error {
 			break
 		}
 		if err != nil {
-			return err
+			return "", err
 		}
 
-		if strings.Contains(hdr.Name, p.binaryName) {
+		// archives list the binary under a directory, e.g. bin/csi-proxy.exe
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == p.binaryName {
 			if _, err := io.Copy(file, tr); err != nil {
-				return err
+				return "", err
 			}
+			found = true
+			break
 		}
 	}
-	return nil
+	if !found {
+		return "", errors.Errorf("could not find %s in %s", p.binaryName, url)
+	}
+
+	// drain the trailing bytes of the archive so the checksum covers all of it
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return "", err
+	}
+	if p.cfg.SHA256 != "" {
+		if checksum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(checksum, p.cfg.SHA256) {
+			return "", errors.Errorf("checksum of %s is %s, expected %s", url, checksum, p.cfg.SHA256)
+		}
+	}
+	return file.Name(), nil
 }
@@ -0,0 +1,456 @@
+package csiproxy
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rancher/wins/pkg/concierge"
+	winstls "github.com/rancher/wins/pkg/tls"
+	"golang.org/x/sys/windows/svc"
+)
+
+// fakeService runs the binary at path, a binary with the content "broken" or
+// a service config with the argument "-broken" stops right after it is started
+type fakeService struct {
+	path   string
+	cfg    *concierge.Config
+	exists bool
+	state  svc.State
+}
+
+func (f *fakeService) ServiceExists() (bool, error) {
+	return f.exists, nil
+}
+
+func (f *fakeService) Enable() error {
+	f.exists = true
+	return f.Start(0)
+}
+
+func (f *fakeService) Start(_ time.Duration) error {
+	bs, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	f.state = svc.Running
+	if string(bs) == "broken" || contains(f.cfg.Args, "-broken") {
+		f.state = svc.Stopped
+	}
+	return nil
+}
+
+func (f *fakeService) Update(cfg *concierge.Config) (bool, error) {
+	changed := !reflect.DeepEqual(f.cfg, cfg)
+	f.cfg = cfg
+	return changed, nil
+}
+
+func (f *fakeService) Stop(_ time.Duration) error {
+	f.state = svc.Stopped
+	return nil
+}
+
+func (f *fakeService) State() (svc.State, error) {
+	return f.state, nil
+}
+
+func archive(t *testing.T, name, content string) []byte {
+	t.Helper()
+	return archiveEntries(t, [2]string{name, content})
+}
+
+// archiveEntries returns a tar.gz archive of the entries, each given as name and content
+func archiveEntries(t *testing.T, entries ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: entry[0], Mode: 0755, Size: int64(len(entry[1]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checksum(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// newTestProxy serves an archive per version from a local TLS server, the archive for a version contains a binary
+// with the content of the version
+func newTestProxy(t *testing.T, versions map[string]string) (*Proxy, *fakeService, *httptest.Server) {
+	t.Helper()
+	archives := map[string][]byte{}
+	for version, content := range versions {
+		archives["/"+version+"/csi-proxy.tar.gz"] = archive(t, "bin/"+exeName, content)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bs, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(bs)
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(certPath, cert, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		URL:         server.URL + "/%s/csi-proxy.tar.gz",
+		Version:     "v1",
+		KubeletPath: "c:/var/lib/kubelet",
+	}
+	service := &fakeService{path: filepath.Join(dir, exeName), cfg: serviceConfig(desired(cfg))}
+	return &Proxy{
+		cfg:         cfg,
+		tlsCfg:      &winstls.Config{CertFilePath: certPath},
+		serviceName: ServiceName,
+		binaryName:  exeName,
+		binaryPath:  service.path,
+		statePath:   filepath.Join(dir, stateName),
+		concierge:   service,
+	}, service, server
+}
+
+func TestDownload(t *testing.T) {
+	insecure := true
+	tests := []struct {
+		name        string
+		modify      func(p *Proxy)
+		expectedErr bool
+	}{
+		{
+			name:   "trusted certificate",
+			modify: func(_ *Proxy) {},
+		},
+		{
+			name: "matching checksum",
+			modify: func(p *Proxy) {
+				p.cfg.SHA256 = checksum(archive(t, "bin/"+exeName, "v1"))
+			},
+		},
+		{
+			name: "checksum mismatch",
+			modify: func(p *Proxy) {
+				p.cfg.SHA256 = checksum([]byte("v1"))
+			},
+			expectedErr: true,
+		},
+		{
+			name: "certificate is verified by default",
+			modify: func(p *Proxy) {
+				p.tlsCfg = nil
+			},
+			expectedErr: true,
+		},
+		{
+			name: "insecure skips verification",
+			modify: func(p *Proxy) {
+				p.tlsCfg = &winstls.Config{Insecure: &insecure}
+			},
+		},
+		{
+			name: "missing version",
+			modify: func(p *Proxy) {
+				p.cfg.Version = "v2"
+			},
+			expectedErr: true,
+		},
+		{
+			name: "missing binary",
+			modify: func(p *Proxy) {
+				p.binaryName = "other.exe"
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _, _ := newTestProxy(t, map[string]string{"v1": "v1"})
+			tt.modify(p)
+
+			downloaded, err := p.download()
+			entries, _ := os.ReadDir(filepath.Dir(p.binaryPath))
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				for _, entry := range entries {
+					if filepath.Ext(entry.Name()) == ".tmp" {
+						t.Errorf("expected temporary file %s to be removed", entry.Name())
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			bs, err := os.ReadFile(downloaded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bs) != "v1" {
+				t.Errorf("expected downloaded binary v1, got %s", bs)
+			}
+		})
+	}
+}
+
+func TestDownloadArchiveEntries(t *testing.T) {
+	bs := archiveEntries(t,
+		[2]string{"bin/" + exeName + ".sha256", "checksum"},
+		[2]string{"bin/" + exeName, "v1"},
+		[2]string{"bin/amd64/" + exeName, "v2"},
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(bs)
+	}))
+	t.Cleanup(server.Close)
+
+	p := &Proxy{
+		cfg:        &Config{URL: server.URL + "/%s/csi-proxy.tar.gz", Version: "v1", SHA256: checksum(bs)},
+		binaryName: exeName,
+		binaryPath: filepath.Join(t.TempDir(), exeName),
+	}
+	downloaded, err := p.download()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// only the first entry named exactly like the binary is extracted, the checksum still covers the whole archive
+	if bs, err := os.ReadFile(downloaded); err != nil || string(bs) != "v1" {
+		t.Errorf("expected downloaded binary v1, got %s: %v", bs, err)
+	}
+}
+
+func TestEnableUnrecordedInstallation(t *testing.T) {
+	p, service, _ := newTestProxy(t, map[string]string{"v1": "v1", "v2": "broken"})
+
+	// installed before the installation was recorded, the binary is not the configured version
+	service.exists = true
+	service.cfg = serviceConfig(&installation{Args: legacyArgs})
+	if err := os.WriteFile(p.binaryPath, []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p.cfg.Version = "v2"
+	if err := p.Enable(); err == nil {
+		t.Fatal("expected replacing the binary by one that fails to start to fail")
+	}
+	if bs, _ := os.ReadFile(p.binaryPath); string(bs) != "v0" {
+		t.Errorf("expected binary v0 to be restored, got %s", bs)
+	}
+	if installed, err := p.installed(); err != nil || installed != nil {
+		t.Errorf("expected no installation to be recorded for an unverified binary, got %+v: %v", installed, err)
+	}
+
+	p.cfg.Version = "v1"
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error replacing the binary: %v", err)
+	}
+	if bs, _ := os.ReadFile(p.binaryPath); string(bs) != "v1" {
+		t.Errorf("expected binary v1, got %s", bs)
+	}
+	installed, err := p.installed()
+	if err != nil || installed == nil || installed.Version != "v1" {
+		t.Errorf("expected installation of v1 to be recorded, got %+v: %v", installed, err)
+	}
+	if service.state != svc.Running {
+		t.Errorf("expected service to be running, got %s", concierge.StateString(service.state))
+	}
+}
+
+func TestEnable(t *testing.T) {
+	p, service, _ := newTestProxy(t, map[string]string{"v1": "v1", "v2": "v2", "v3": "broken"})
+
+	assert := func(version, binary string, args []string, state svc.State) {
+		t.Helper()
+		installed, err := p.installed()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if installed.Version != version {
+			t.Errorf("expected installed version %s, got %s", version, installed.Version)
+		}
+		if bs, _ := os.ReadFile(p.binaryPath); string(bs) != binary {
+			t.Errorf("expected binary %s, got %s", binary, bs)
+		}
+		if !reflect.DeepEqual(service.cfg.Args, args) || !reflect.DeepEqual(installed.Args, args) {
+			t.Errorf("expected args %v, got %v and recorded %v", args, service.cfg.Args, installed.Args)
+		}
+		if service.state != state {
+			t.Errorf("expected state %s, got %s", concierge.StateString(state), concierge.StateString(service.state))
+		}
+		if _, err := os.Stat(p.binaryPath + ".bak"); !os.IsNotExist(err) {
+			t.Errorf("expected backup to be removed")
+		}
+	}
+
+	// the default kubelet path is not passed, the args match those of services installed by older releases
+	args := legacyArgs
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error installing: %v", err)
+	}
+	assert("v1", "v1", args, svc.Running)
+
+	p.cfg.Version = "v2"
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error upgrading: %v", err)
+	}
+	assert("v2", "v2", args, svc.Running)
+
+	p.cfg.Version = "v3"
+	if err := p.Enable(); err == nil {
+		t.Fatal("expected upgrading to a binary that fails to start to fail")
+	}
+	assert("v2", "v2", args, svc.Running)
+
+	p.cfg.Version = "v4"
+	if err := p.Enable(); err == nil {
+		t.Fatal("expected upgrading to a missing version to fail")
+	}
+	assert("v2", "v2", args, svc.Running)
+
+	p.cfg.Version = "v2"
+	p.cfg.LogPath = "c:/var/log/csi-proxy.log"
+	p.cfg.KubeletPath = "d:/kubelet"
+	p.cfg.APIGroups = []string{"disk/v1", "filesystem/v1"}
+	p.cfg.EnvVars = map[string]string{"B": "2", "A": "1"}
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error reconfiguring: %v", err)
+	}
+	reconfiguredArgs := []string{"-windows-service", "-log_file=c:/var/log/csi-proxy.log", "-logtostderr=false", "-kubelet-path=d:/kubelet"}
+	assert("v2", "v2", reconfiguredArgs, svc.Running)
+	if !reflect.DeepEqual(service.cfg.EnvVars, []string{"A=1", "B=2"}) {
+		t.Errorf("expected env vars to be sorted, got %v", service.cfg.EnvVars)
+	}
+
+	p.cfg.ExtraArgs = []string{"-broken"}
+	if err := p.Enable(); err == nil {
+		t.Fatal("expected reconfiguring a service that fails to start to fail")
+	}
+	assert("v2", "v2", reconfiguredArgs, svc.Running)
+}
+
+func TestRecordVersion(t *testing.T) {
+	p, service, _ := newTestProxy(t, map[string]string{"v1": "v1"})
+
+	if version, err := RecordedVersion(p.binaryPath); err != nil || version != "" {
+		t.Fatalf("expected no recorded version before installing, got %q: %v", version, err)
+	}
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error installing: %v", err)
+	}
+	args := service.cfg.Args
+
+	// the binary is replaced by v2 outside of Enable, which must not download it again
+	if err := os.WriteFile(p.binaryPath, []byte("v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordVersion(p.binaryPath, "v2"); err != nil {
+		t.Fatalf("unexpected error recording version: %v", err)
+	}
+	if version, err := RecordedVersion(p.binaryPath); err != nil || version != "v2" {
+		t.Fatalf("expected recorded version v2, got %q: %v", version, err)
+	}
+
+	p.cfg.Version = "v2"
+	if err := p.Enable(); err != nil {
+		t.Fatalf("unexpected error enabling recorded version: %v", err)
+	}
+	installed, err := p.installed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(installed.Args, args) {
+		t.Errorf("expected recorded args %v to be kept, got %v", args, installed.Args)
+	}
+	if bs, _ := os.ReadFile(p.binaryPath); string(bs) != "v2" {
+		t.Errorf("expected binary v2, got %s", bs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(c *Config)
+		expectedErr bool
+	}{
+		{
+			name:   "valid",
+			modify: func(_ *Config) {},
+		},
+		{
+			name: "valid api groups",
+			modify: func(c *Config) {
+				c.APIGroups = []string{"disk/v1", "iscsi/v1alpha2", "system/v1alpha1"}
+			},
+		},
+		{
+			name: "unknown api group",
+			modify: func(c *Config) {
+				c.APIGroups = []string{"network/v1"}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "api group without version",
+			modify: func(c *Config) {
+				c.APIGroups = []string{"disk"}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid env var",
+			modify: func(c *Config) {
+				c.EnvVars = map[string]string{"A=B": "C"}
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid checksum",
+			modify: func(c *Config) {
+				c.SHA256 = "abc"
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{URL: "https://localhost/%s", Version: "v1", KubeletPath: "c:/var/lib/kubelet"}
+			tt.modify(cfg)
+			if err := cfg.validate(); (err != nil) != tt.expectedErr {
+				t.Errorf("expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package csiproxy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/pkg/concierge"
+	"github.com/rancher/wins/pkg/npipes"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+)
+
+const (
+	defaultMonitorInterval = 30 * time.Second
+	defaultMaxRestarts     = 3
+	defaultRestartWindow   = 10 * time.Minute
+	probeTimeout           = 5 * time.Second
+)
+
+// defaultProbedAPIGroups are probed if no API groups are configured, they are served by every CSI Proxy v1 release
+var defaultProbedAPIGroups = []string{"disk/v1", "filesystem/v1", "volume/v1"}
+
+// MonitorConfig configures the health monitor of the CSI Proxy service
+type MonitorConfig struct {
+	// Disabled turns off the health monitor
+	Disabled bool `yaml:"disabled" json:"disabled,omitempty"`
+	// IntervalSeconds is the time between health checks, defaults to 30 seconds
+	IntervalSeconds int `yaml:"intervalSeconds" json:"intervalSeconds,omitempty"`
+	// MaxRestarts is the number of restarts allowed within the restart window, defaults to 3
+	MaxRestarts int `yaml:"maxRestarts" json:"maxRestarts,omitempty"`
+	// RestartWindowSeconds is the window restarts are counted in, defaults to 10 minutes
+	RestartWindowSeconds int `yaml:"restartWindowSeconds" json:"restartWindowSeconds,omitempty"`
+}
+
+func (c *MonitorConfig) validate() error {
+	if c.IntervalSeconds < 0 || c.MaxRestarts < 0 || c.RestartWindowSeconds < 0 {
+		return errors.New("CSI Proxy monitor settings cannot be negative")
+	}
+	return nil
+}
+
+func (c *MonitorConfig) interval() time.Duration {
+	if c.IntervalSeconds == 0 {
+		return defaultMonitorInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+func (c *MonitorConfig) maxRestarts() int {
+	if c.MaxRestarts == 0 {
+		return defaultMaxRestarts
+	}
+	return c.MaxRestarts
+}
+
+func (c *MonitorConfig) restartWindow() time.Duration {
+	if c.RestartWindowSeconds == 0 {
+		return defaultRestartWindow
+	}
+	return time.Duration(c.RestartWindowSeconds) * time.Second
+}
+
+// Health is the result of the last health check of the CSI Proxy service
+type Health struct {
+	Healthy bool
+	State   string
+	Error   string
+	// LastCheck is the time of the last health check, it is zero until the first check
+	LastCheck time.Time
+	// Restarts is the number of restarts by the monitor within the restart window
+	Restarts    int
+	LastRestart time.Time
+}
+
+// Monitor periodically checks that the CSI Proxy service is running and serving its named pipes,
+// restarting the service if it is not, as long as the restart limit is not exceeded.
+type Monitor struct {
+	proxy *Proxy
+	cfg   MonitorConfig
+	// probe dials the named pipe of an API group version
+	probe func(pipe string) error
+	now   func() time.Time
+
+	mu       sync.RWMutex
+	health   Health
+	restarts []time.Time
+}
+
+// NewMonitor creates a health monitor for the CSI Proxy service
+func NewMonitor(p *Proxy) *Monitor {
+	return &Monitor{
+		proxy: p,
+		cfg:   p.cfg.Monitor,
+		probe: probePipe,
+		now:   time.Now,
+	}
+}
+
+// Run checks the health of the service every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context) {
+	if m.cfg.Disabled {
+		logrus.Infof("CSI Proxy health monitor is disabled")
+		return
+	}
+
+	logrus.Infof("Monitoring CSI Proxy health every %v", m.cfg.interval())
+	ticker := time.NewTicker(m.cfg.interval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Health returns the result of the last health check
+func (m *Monitor) Health() Health {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.health
+}
+
+// Check checks the health of the service once, restarting it if it is unhealthy
+func (m *Monitor) Check() Health {
+	health := Health{LastCheck: m.now()}
+	state, err := m.proxy.concierge.State()
+	if err == nil {
+		health.State = concierge.StateString(state)
+		if state == svc.Running {
+			err = m.probePipes()
+		} else {
+			err = errors.Errorf("service is %s", health.State)
+		}
+	}
+
+	m.mu.Lock()
+	previous := m.health
+	m.pruneRestarts(health.LastCheck)
+	health.Restarts, health.LastRestart = len(m.restarts), previous.LastRestart
+	m.mu.Unlock()
+
+	if err == nil {
+		health.Healthy = true
+		if !previous.Healthy && !previous.LastCheck.IsZero() {
+			logrus.Infof("CSI Proxy is healthy again")
+		}
+		m.setHealth(health)
+		return health
+	}
+
+	health.Error = err.Error()
+	if previous.Healthy || previous.LastCheck.IsZero() {
+		logrus.Errorf("CSI Proxy is unhealthy: %v", err)
+	}
+	if state == svc.StartPending || state == svc.StopPending || state == svc.ContinuePending {
+		// transitioning, possibly restarted by the recovery actions of the service
+		m.setHealth(health)
+		return health
+	}
+
+	if health.Restarts >= m.cfg.maxRestarts() {
+		if previous.Restarts < m.cfg.maxRestarts() || previous.Healthy {
+			logrus.Errorf("CSI Proxy has been restarted %d times within %v, not restarting it until the window passes",
+				health.Restarts, m.cfg.restartWindow())
+		}
+		m.setHealth(health)
+		return health
+	}
+
+	logrus.Warnf("Restarting CSI Proxy: %v", err)
+	m.mu.Lock()
+	m.restarts = append(m.restarts, health.LastCheck)
+	health.Restarts, health.LastRestart = len(m.restarts), health.LastCheck
+	m.mu.Unlock()
+	if restartErr := m.restart(); restartErr != nil {
+		logrus.Errorf("Failed to restart CSI Proxy: %v", restartErr)
+		health.Error = errors.Wrap(restartErr, "could not restart service").Error()
+	} else {
+		logrus.Infof("CSI Proxy has been restarted")
+	}
+	m.setHealth(health)
+	return health
+}
+
+func (m *Monitor) restart() error {
+	if err := m.proxy.stop(); err != nil {
+		return err
+	}
+	return m.proxy.start()
+}
+
+// probePipes dials the named pipes of the configured API groups, or the default ones if none are configured
+func (m *Monitor) probePipes() error {
+	groups := m.proxy.cfg.APIGroups
+	if len(groups) == 0 {
+		groups = defaultProbedAPIGroups
+	}
+	for _, group := range groups {
+		pipe := "csi-proxy-" + strings.Replace(group, "/", "-", 1)
+		if err := m.probe(pipe); err != nil {
+			return errors.Wrapf(err, "could not connect to %s", pipe)
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) pruneRestarts(now time.Time) {
+	var restarts []time.Time
+	for _, restart := range m.restarts {
+		if now.Sub(restart) < m.cfg.restartWindow() {
+			restarts = append(restarts, restart)
+		}
+	}
+	m.restarts = restarts
+}
+
+func (m *Monitor) setHealth(health Health) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = health
+}
+
+func probePipe(pipe string) error {
+	dial, err := npipes.NewDialer(npipes.GetFullPath(pipe), probeTimeout)
+	if err != nil {
+		return err
+	}
+	conn, err := dial(context.Background(), "")
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
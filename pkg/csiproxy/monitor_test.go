@@ -0,0 +1,71 @@
+package csiproxy
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestMonitorCheck(t *testing.T) {
+	p, service, _ := newTestProxy(t, map[string]string{"v1": "v1"})
+	if err := p.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	p.cfg.APIGroups = []string{"disk/v1", "iscsi/v1alpha2"}
+	p.cfg.Monitor = MonitorConfig{MaxRestarts: 2, RestartWindowSeconds: 60}
+
+	now := time.Unix(0, 0)
+	probed := map[string]bool{}
+	var probeErr error
+	m := NewMonitor(p)
+	m.now = func() time.Time { return now }
+	m.probe = func(pipe string) error {
+		probed[pipe] = true
+		return probeErr
+	}
+
+	assert := func(healthy bool, restarts int, state svc.State) {
+		t.Helper()
+		health := m.Check()
+		if health.Healthy != healthy || health.Restarts != restarts {
+			t.Errorf("expected healthy %t with %d restarts, got %+v", healthy, restarts, health)
+		}
+		if m.Health() != health {
+			t.Errorf("expected the last check to be reported, got %+v", m.Health())
+		}
+		if service.state != state {
+			t.Errorf("expected service to be %d, got %d", state, service.state)
+		}
+	}
+
+	assert(true, 0, svc.Running)
+	if !probed["csi-proxy-disk-v1"] || !probed["csi-proxy-iscsi-v1alpha2"] {
+		t.Errorf("expected the pipes of the configured API groups to be probed, got %v", probed)
+	}
+
+	// a crashed service is restarted
+	service.state = svc.Stopped
+	assert(false, 1, svc.Running)
+	assert(true, 1, svc.Running)
+
+	// a service that does not serve its pipes is restarted
+	probeErr = errors.New("pipe not found")
+	assert(false, 2, svc.Running)
+
+	// restarts are limited within the window
+	service.state = svc.Stopped
+	assert(false, 2, svc.Stopped)
+
+	// a transitioning service is left alone
+	service.state = svc.StartPending
+	now = now.Add(2 * time.Minute)
+	assert(false, 0, svc.StartPending)
+
+	// restarts are allowed again once the window passed
+	service.state = svc.Stopped
+	probeErr = nil
+	assert(false, 1, svc.Running)
+	assert(true, 1, svc.Running)
+}
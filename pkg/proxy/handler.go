@@ -0,0 +1,190 @@
+package proxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/remotedialer"
+	"github.com/sirupsen/logrus"
+)
+
+// Handler serves the remotedialer sessions of clients publishing ports through the proxy.
+// The ports that clients are allowed to connect to can be replaced at runtime via SetPorts.
+type Handler struct {
+	mu       sync.RWMutex
+	ports    map[int]bool
+	sessions map[*session]struct{}
+}
+
+// session tracks the hijacked connection of a single client and the ports it has connected to
+type session struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	ports  map[int]bool
+	closed bool
+}
+
+// NewHandler returns a Handler that authorizes client connect requests against the provided ports
+func NewHandler(ports []int) *Handler {
+	h := &Handler{
+		sessions: make(map[*session]struct{}),
+	}
+	h.ports = toPortSet(ports)
+	return h
+}
+
+// Ports returns the ports that are currently allowed
+func (h *Handler) Ports() []int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ports := make([]int, 0, len(h.ports))
+	for p := range h.ports {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// SetPorts atomically replaces the allowed ports. Sessions that have connected to a port
+// that is no longer allowed are closed, the clients are expected to reconnect.
+func (h *Handler) SetPorts(ports []int) {
+	allowed := toPortSet(ports)
+
+	h.mu.Lock()
+	h.ports = allowed
+	var revoked []*session
+	for s := range h.sessions {
+		if s.usesAnyExcept(allowed) {
+			revoked = append(revoked, s)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, s := range revoked {
+		if err := s.close(); err != nil {
+			logrus.Warnf("Failed to close proxy session using a removed port: %v", err)
+		}
+	}
+	if len(revoked) != 0 {
+		logrus.Infof("Closed %d proxy session(s) using removed ports", len(revoked))
+	}
+}
+
+// ServeHTTP upgrades the request to a remotedialer session whose connect requests are authorized by the Handler
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	s := &session{
+		ports: make(map[int]bool),
+	}
+
+	server := remotedialer.New(GetServerAuthorizer(), remotedialer.DefaultErrorWriter)
+	server.ClientConnectAuthorizer = func(proto, address string) bool {
+		port, ok := parseLocalPort(address)
+		if proto != "tcp" || !ok || !h.allowed(port) {
+			return false
+		}
+		s.use(port)
+		return true
+	}
+
+	defer h.remove(s)
+	server.ServeHTTP(&hijackResponseWriter{
+		ResponseWriter: rw,
+		onHijack: func(conn net.Conn) {
+			s.mu.Lock()
+			s.conn = conn
+			s.mu.Unlock()
+			h.add(s)
+		},
+	}, req)
+}
+
+func (h *Handler) allowed(port int) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ports[port]
+}
+
+func (h *Handler) add(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sessions[s] = struct{}{}
+}
+
+func (h *Handler) remove(s *session) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, s)
+}
+
+func (s *session) use(port int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ports[port] = true
+}
+
+func (s *session) usesAnyExcept(allowed map[int]bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.ports {
+		if !allowed[p] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *session) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.conn == nil {
+		return nil
+	}
+	s.closed = true
+	return s.conn.Close()
+}
+
+// hijackResponseWriter reports the connection hijacked by the websocket upgrade
+type hijackResponseWriter struct {
+	http.ResponseWriter
+	onHijack func(net.Conn)
+}
+
+func (w *hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.onHijack(conn)
+	return conn, rw, nil
+}
+
+// parseLocalPort returns the port of a localhost address, as dialed by the clients created via GetClientOnConnect
+func parseLocalPort(address string) (int, bool) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || host != "localhost" {
+		return 0, false
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, false
+	}
+	return p, true
+}
+
+func toPortSet(ports []int) map[int]bool {
+	set := make(map[int]bool, len(ports))
+	for _, p := range ports {
+		set[p] = true
+	}
+	return set
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestHandlerSetPorts(t *testing.T) {
+	h := NewHandler([]int{9796, 443})
+	if !h.allowed(9796) || !h.allowed(443) {
+		t.Fatalf("expected initial ports to be allowed, got %v", h.Ports())
+	}
+
+	kept, keptPeer := net.Pipe()
+	defer keptPeer.Close()
+	revoked, revokedPeer := net.Pipe()
+	defer revokedPeer.Close()
+
+	keptSession := &session{conn: kept, ports: map[int]bool{443: true}}
+	revokedSession := &session{conn: revoked, ports: map[int]bool{443: true, 9796: true}}
+	h.add(keptSession)
+	h.add(revokedSession)
+
+	h.SetPorts([]int{443, 8080})
+
+	if want := []int{443, 8080}; !reflect.DeepEqual(h.Ports(), want) {
+		t.Errorf("expected ports %v, got %v", want, h.Ports())
+	}
+	if h.allowed(9796) {
+		t.Error("expected removed port 9796 to no longer be allowed")
+	}
+	if keptSession.closed {
+		t.Error("expected session only using allowed ports to stay open")
+	}
+	if !revokedSession.closed {
+		t.Error("expected session using a removed port to be closed")
+	}
+}
+
+func TestParseLocalPort(t *testing.T) {
+	tests := []struct {
+		address string
+		port    int
+		ok      bool
+	}{
+		{address: "localhost:9796", port: 9796, ok: true},
+		{address: "127.0.0.1:9796", ok: false},
+		{address: "localhost", ok: false},
+		{address: "localhost:http", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			port, ok := parseLocalPort(tt.address)
+			if ok != tt.ok || port != tt.port {
+				t.Errorf("parseLocalPort(%q) = %d, %t, want %d, %t", tt.address, port, ok, tt.port, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/winservice"
+)
+
+// Start types of a managed service
+const (
+	StartAutomatic = "automatic"
+	StartDelayed   = "delayed"
+	StartManual    = "manual"
+	StartDisabled  = "disabled"
+)
+
+// Recovery action types of a managed service
+const (
+	RecoveryRestart = "restart"
+	RecoveryNone    = "none"
+)
+
+// reservedNames are the services managed by wins on its own
+var reservedNames = []string{defaults.WindowsServiceName, defaults.WindowsSUCName, "csiproxy", "rke2"}
+
+// defaultRecoveryActions restart a failed service after 10 seconds, like the CSI Proxy service
+var defaultRecoveryActions = []RecoveryAction{{Type: RecoveryRestart, DelaySeconds: 10}}
+
+// Config describes a Windows service installed and managed by wins
+type Config struct {
+	Name        string `yaml:"name" json:"name"`
+	DisplayName string `yaml:"displayName" json:"displayName,omitempty"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	// URL is downloaded as the binary of the service, it is mutually exclusive with Path
+	URL string `yaml:"url" json:"url,omitempty"`
+	// SHA256 is the expected checksum of the binary downloaded from URL, it is not verified if empty
+	SHA256 string `yaml:"sha256" json:"sha256,omitempty"`
+	// Path is the binary of the service on the host, it is mutually exclusive with URL
+	Path    string            `yaml:"path" json:"path,omitempty"`
+	Args    []string          `yaml:"args" json:"args,omitempty"`
+	EnvVars map[string]string `yaml:"envVars" json:"envVars,omitempty"`
+	// StartType is one of automatic, delayed, manual or disabled, defaults to automatic
+	StartType string `yaml:"startType" json:"startType,omitempty"`
+	// RecoveryActions are taken by the SCM in order on subsequent failures of the service,
+	// defaults to restarting the service after 10 seconds
+	RecoveryActions []RecoveryAction `yaml:"recoveryActions" json:"recoveryActions,omitempty"`
+}
+
+// RecoveryAction is taken by the SCM when a service fails
+type RecoveryAction struct {
+	// Type is one of restart or none
+	Type         string `yaml:"type" json:"type"`
+	DelaySeconds int    `yaml:"delaySeconds" json:"delaySeconds,omitempty"`
+}
+
+// Validate ensures that the configuration of a managed service is correct
+func (c *Config) Validate() error {
+	var errs error
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("name cannot be blank")
+	}
+	if strings.ContainsAny(c.Name, `/\`) {
+		errs = multierror.Append(errs, errors.Errorf("name %q cannot contain slashes", c.Name))
+	}
+	for _, name := range reservedNames {
+		if strings.EqualFold(c.Name, name) {
+			errs = multierror.Append(errs, errors.Errorf("name %q is reserved", c.Name))
+		}
+	}
+
+	switch {
+	case c.URL == "" && c.Path == "":
+		errs = multierror.Append(errs, errors.New("either url or path must be set"))
+	case c.URL != "" && c.Path != "":
+		errs = multierror.Append(errs, errors.New("url and path cannot be set together"))
+	case c.Path != "" && c.SHA256 != "":
+		errs = multierror.Append(errs, errors.New("sha256 can only be verified for a binary downloaded from url"))
+	}
+	if c.SHA256 != "" {
+		if checksum, err := hex.DecodeString(c.SHA256); err != nil || len(checksum) != sha256.Size {
+			errs = multierror.Append(errs, errors.Errorf("sha256 %q is not a hex encoded SHA256 checksum", c.SHA256))
+		}
+	}
+
+	for name := range c.EnvVars {
+		if name == "" || strings.Contains(name, "=") {
+			errs = multierror.Append(errs, errors.Errorf("environment variable name %q is invalid", name))
+		}
+	}
+
+	switch c.StartType {
+	case "", StartAutomatic, StartDelayed, StartManual, StartDisabled:
+	default:
+		errs = multierror.Append(errs, errors.Errorf("start type %q must be one of %s, %s, %s or %s",
+			c.StartType, StartAutomatic, StartDelayed, StartManual, StartDisabled))
+	}
+
+	for _, action := range c.RecoveryActions {
+		if action.Type != RecoveryRestart && action.Type != RecoveryNone {
+			errs = multierror.Append(errs, errors.Errorf("recovery action %q must be one of %s or %s",
+				action.Type, RecoveryRestart, RecoveryNone))
+		}
+		if action.DelaySeconds < 0 {
+			errs = multierror.Append(errs, errors.New("recovery action delay cannot be negative"))
+		}
+	}
+
+	if errs != nil {
+		return errors.Wrapf(errs, "service %s", c.Name)
+	}
+	return nil
+}
+
+// ValidateAll validates every managed service and ensures that their names are unique
+func ValidateAll(configs []Config) error {
+	var errs error
+	names := map[string]bool{}
+	for i := range configs {
+		if err := configs[i].Validate(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		name := strings.ToLower(configs[i].Name)
+		if names[name] {
+			errs = multierror.Append(errs, errors.Errorf("service %s is configured more than once", configs[i].Name))
+		}
+		names[name] = true
+	}
+	return errs
+}
+
+func (c *Config) startType() string {
+	if c.StartType == "" {
+		return StartAutomatic
+	}
+	return c.StartType
+}
+
+// autoStart returns whether the service is started by wins
+func (c *Config) autoStart() bool {
+	startType := c.startType()
+	return startType == StartAutomatic || startType == StartDelayed
+}
+
+// binaryName returns the file name the binary downloaded from URL is installed as
+func (c *Config) binaryName() string {
+	name := path.Base(strings.SplitN(c.URL, "?", 2)[0])
+	if strings.EqualFold(path.Ext(name), ".exe") {
+		return name
+	}
+	return c.Name + ".exe"
+}
+
+// serviceConfig returns the configuration of the Windows service running binaryPath
+func (c *Config) serviceConfig(binaryPath string) *winservice.Config {
+	displayName := c.DisplayName
+	if displayName == "" {
+		displayName = c.Name
+	}
+	description := c.Description
+	if description == "" {
+		description = "Managed by " + defaults.WindowsServiceDisplayName + "."
+	}
+	actions := c.RecoveryActions
+	if len(actions) == 0 {
+		actions = defaultRecoveryActions
+	}
+
+	var envVars []string
+	for name, value := range c.EnvVars {
+		envVars = append(envVars, name+"="+value)
+	}
+	sort.Strings(envVars)
+
+	cfg := &winservice.Config{
+		BinaryPath:  binaryPath,
+		Args:        c.Args,
+		DisplayName: displayName,
+		Description: description,
+		EnvVars:     envVars,
+	}
+	switch c.startType() {
+	case StartDelayed:
+		cfg.StartType, cfg.DelayedAutoStart = winservice.StartAutomatic, true
+	case StartManual:
+		cfg.StartType = winservice.StartManual
+	case StartDisabled:
+		cfg.StartType = winservice.StartDisabled
+	default:
+		cfg.StartType = winservice.StartAutomatic
+	}
+	for _, action := range actions {
+		recoveryAction := winservice.RecoveryAction{
+			Type:  winservice.RecoveryNone,
+			Delay: time.Duration(action.DelaySeconds) * time.Second,
+		}
+		if action.Type == RecoveryRestart {
+			recoveryAction.Type = winservice.RecoveryRestart
+		}
+		cfg.RecoveryActions = append(cfg.RecoveryActions, recoveryAction)
+	}
+	return cfg
+}
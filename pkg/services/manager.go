@@ -0,0 +1,460 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	winstls "github.com/rancher/wins/pkg/tls"
+	"github.com/rancher/wins/pkg/winservice"
+	"github.com/sirupsen/logrus"
+)
+
+const stateName = "services.json"
+
+// StateNotInstalled is reported for a configured service that does not exist
+const StateNotInstalled = "Not Installed"
+
+// ErrNotFound is returned for a service that is not configured
+var ErrNotFound = errors.New("service is not configured")
+
+// installation records what has been applied to an installed service
+type installation struct {
+	URL     string            `json:"url,omitempty"`
+	SHA256  string            `json:"sha256,omitempty"`
+	Service winservice.Config `json:"service"`
+}
+
+// Status is the status of a configured service
+type Status struct {
+	Name        string
+	DisplayName string
+	BinaryPath  string
+	StartType   string
+	State       string
+	// Error is the error of the last reconciliation of the service, if any
+	Error string
+}
+
+// Manager installs, updates and uninstalls the configured services. Only services installed by the
+// manager are changed, they are recorded in a state file next to the downloaded binaries.
+type Manager struct {
+	ctl       winservice.Controller
+	dir       string
+	statePath string
+	tlsCfg    *winstls.Config
+	// stateTimeout bounds the wait for a service to start or stop
+	stateTimeout time.Duration
+
+	mu      sync.Mutex
+	configs []Config
+	errs    map[string]error
+}
+
+// NewManager creates a manager installing services with ctl, binaries downloaded for the services are stored in dir
+func NewManager(ctl winservice.Controller, dir string, tlsCfg *winstls.Config) *Manager {
+	return &Manager{
+		ctl:          ctl,
+		dir:          dir,
+		statePath:    filepath.Join(dir, stateName),
+		tlsCfg:       tlsCfg,
+		stateTimeout: 60 * time.Second,
+		errs:         map[string]error{},
+	}
+}
+
+// Reconcile installs or updates the configured services to match configs and uninstalls the services that
+// were installed by the manager but are no longer configured. A failing service does not prevent the others
+// from being reconciled, the failures are returned together.
+func (m *Manager) Reconcile(configs []Config) error {
+	if err := ValidateAll(configs); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	installed, err := m.installed()
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	m.configs, m.errs = configs, map[string]error{}
+	configured := map[string]bool{}
+	for i := range configs {
+		cfg := &configs[i]
+		configured[cfg.Name] = true
+		if err := m.reconcile(cfg, installed); err != nil {
+			err = errors.Wrapf(err, "service %s", cfg.Name)
+			m.errs[cfg.Name] = err
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	var removed []string
+	for name := range installed {
+		if !configured[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		logrus.Infof("Service %s is no longer configured and is being uninstalled.", name)
+		if err := m.uninstall(name, installed[name]); err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not uninstall service %s", name))
+			continue
+		}
+		delete(installed, name)
+		logrus.Infof("Service %s has been uninstalled.", name)
+	}
+
+	if err := m.writeInstalled(installed); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return errs
+}
+
+// List returns the status of the configured services
+func (m *Manager) List() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]Status, 0, len(m.configs))
+	for i := range m.configs {
+		statuses = append(statuses, m.status(&m.configs[i]))
+	}
+	return statuses
+}
+
+// Status returns the status of a configured service
+func (m *Manager) Status(name string) (Status, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := m.config(name)
+	if cfg == nil {
+		return Status{}, errors.Wrapf(ErrNotFound, "service %s", name)
+	}
+	return m.status(cfg), nil
+}
+
+// Restart stops a configured service and starts it again
+func (m *Manager) Restart(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := m.config(name)
+	if cfg == nil {
+		return errors.Wrapf(ErrNotFound, "service %s", name)
+	}
+	if err := m.stop(cfg.Name); err != nil {
+		return errors.Wrapf(err, "could not stop service %s", cfg.Name)
+	}
+	if err := m.start(cfg.Name); err != nil {
+		return errors.Wrapf(err, "could not start service %s", cfg.Name)
+	}
+	logrus.Infof("Service %s has been restarted.", cfg.Name)
+	return nil
+}
+
+func (m *Manager) config(name string) *Config {
+	for i := range m.configs {
+		if strings.EqualFold(m.configs[i].Name, name) {
+			return &m.configs[i]
+		}
+	}
+	return nil
+}
+
+func (m *Manager) status(cfg *Config) Status {
+	status := Status{
+		Name:        cfg.Name,
+		DisplayName: cfg.serviceConfig("").DisplayName,
+		BinaryPath:  m.binaryPath(cfg),
+		StartType:   cfg.startType(),
+	}
+	if err := m.errs[cfg.Name]; err != nil {
+		status.Error = err.Error()
+	}
+
+	ok, err := m.ctl.Exists(cfg.Name)
+	switch {
+	case err != nil:
+		status.Error = err.Error()
+	case !ok:
+		status.State = StateNotInstalled
+	default:
+		state, err := m.ctl.State(cfg.Name)
+		if err != nil {
+			status.Error = err.Error()
+			break
+		}
+		status.State = state.String()
+	}
+	return status
+}
+
+// reconcile installs the service of cfg, or updates it if it changed since it was installed.
+func (m *Manager) reconcile(cfg *Config, installed map[string]*installation) error {
+	ok, err := m.ctl.Exists(cfg.Name)
+	if err != nil {
+		return err
+	}
+	previous := installed[cfg.Name]
+	if ok && previous == nil {
+		return errors.New("a service with the same name exists and is not managed by wins")
+	}
+
+	desired := &installation{URL: cfg.URL, SHA256: cfg.SHA256, Service: *cfg.serviceConfig(m.binaryPath(cfg))}
+	var downloaded string
+	if cfg.URL != "" && (!ok || previous.URL != desired.URL || previous.SHA256 != desired.SHA256 || !exists(desired.Service.BinaryPath)) {
+		logrus.Infof("Service %s is being downloaded from %s.", cfg.Name, cfg.URL)
+		if downloaded, err = m.download(cfg); err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.Remove(downloaded)
+		}()
+	}
+
+	if !ok {
+		return m.install(cfg, desired, downloaded, installed)
+	}
+	if downloaded == "" && len(winservice.Diff(&previous.Service, &desired.Service)) == 0 {
+		if !cfg.autoStart() {
+			return nil
+		}
+		state, err := m.ctl.State(cfg.Name)
+		if err != nil || state != winservice.Stopped {
+			return err
+		}
+		logrus.Infof("Service %s is stopped and is being started.", cfg.Name)
+		return m.start(cfg.Name)
+	}
+	return m.update(cfg, previous, desired, downloaded, installed)
+}
+
+func (m *Manager) install(cfg *Config, desired *installation, downloaded string, installed map[string]*installation) error {
+	logrus.Infof("Service %s is being installed.", cfg.Name)
+	if downloaded != "" {
+		if err := os.Rename(downloaded, desired.Service.BinaryPath); err != nil {
+			return errors.Wrap(err, "could not install binary")
+		}
+	}
+	if err := m.ctl.Create(cfg.Name, &desired.Service); err != nil {
+		return errors.Wrap(err, "could not create service")
+	}
+	installed[cfg.Name] = desired
+	if cfg.autoStart() {
+		if err := m.start(cfg.Name); err != nil {
+			return errors.Wrap(err, "could not start service")
+		}
+	}
+	logrus.Infof("Service %s has been installed.", cfg.Name)
+	return nil
+}
+
+// update stops the service, replaces its binary with the downloaded one if given and updates its config.
+// The service is started again if it is started automatically, or if it was running and is not disabled.
+func (m *Manager) update(cfg *Config, previous, desired *installation, downloaded string, installed map[string]*installation) error {
+	logrus.Infof("Service %s is being updated.", cfg.Name)
+	state, err := m.ctl.State(cfg.Name)
+	if err != nil {
+		return err
+	}
+	if err := m.stop(cfg.Name); err != nil {
+		return errors.Wrap(err, "could not stop service")
+	}
+	if downloaded != "" {
+		if err := os.Rename(downloaded, desired.Service.BinaryPath); err != nil {
+			return errors.Wrap(err, "could not replace binary")
+		}
+	}
+	if err := m.ctl.Update(cfg.Name, &desired.Service); err != nil {
+		return errors.Wrap(err, "could not update service")
+	}
+	installed[cfg.Name] = desired
+
+	if previous.URL != "" && previous.Service.BinaryPath != desired.Service.BinaryPath {
+		if err := os.Remove(previous.Service.BinaryPath); err != nil && !os.IsNotExist(err) {
+			logrus.Warnf("Could not remove previous binary %s of service %s: %v", previous.Service.BinaryPath, cfg.Name, err)
+		}
+	}
+	if cfg.autoStart() || (state != winservice.Stopped && cfg.startType() != StartDisabled) {
+		if err := m.start(cfg.Name); err != nil {
+			return errors.Wrap(err, "could not start service")
+		}
+	}
+	logrus.Infof("Service %s has been updated.", cfg.Name)
+	return nil
+}
+
+// uninstall stops and deletes the service and removes its downloaded binary.
+func (m *Manager) uninstall(name string, i *installation) error {
+	ok, err := m.ctl.Exists(name)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := m.stop(name); err != nil {
+			return errors.Wrap(err, "could not stop service")
+		}
+		if err := m.ctl.Delete(name); err != nil {
+			return errors.Wrap(err, "could not delete service")
+		}
+	}
+	if i.URL != "" {
+		if err := os.RemoveAll(filepath.Join(m.dir, name)); err != nil {
+			logrus.Warnf("Could not remove binaries of service %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// start starts the service and waits for it to be running.
+func (m *Manager) start(name string) error {
+	return winservice.Start(m.ctl, name, m.stateTimeout)
+}
+
+// stop stops the service if it is not stopped already and waits for it to be stopped.
+func (m *Manager) stop(name string) error {
+	return winservice.Stop(m.ctl, name, m.stateTimeout)
+}
+
+// binaryPath returns the path of the binary the service of cfg runs
+func (m *Manager) binaryPath(cfg *Config) string {
+	if cfg.Path != "" {
+		return cfg.Path
+	}
+	return filepath.Join(m.dir, cfg.Name, cfg.binaryName())
+}
+
+// download retrieves the binary of the service into a temporary file next to the binary, verifying its checksum
+// if configured. The path of the temporary file is returned.
+func (m *Manager) download(cfg *Config) (path string, err error) {
+	binaryPath := m.binaryPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(binaryPath), 0755); err != nil {
+		return "", err
+	}
+
+	client, err := m.httpClient()
+	if err != nil {
+		return "", err
+	}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(cfg.URL)
+	if err != nil {
+		return "", err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("could not download %s: %s", cfg.URL, resp.Status)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(binaryPath), filepath.Base(binaryPath)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(file, io.TeeReader(resp.Body, hash)); err != nil {
+		return "", err
+	}
+	if cfg.SHA256 != "" {
+		if checksum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(checksum, cfg.SHA256) {
+			return "", errors.Errorf("checksum of %s is %s, expected %s", cfg.URL, checksum, cfg.SHA256)
+		}
+	}
+	return file.Name(), nil
+}
+
+// httpClient returns a client verifying the server certificate against the system certificates and the configured
+// certificate, unless the TLS config is explicitly set to be insecure.
+func (m *Manager) httpClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if m.tlsCfg != nil {
+		if m.tlsCfg.Insecure != nil && *m.tlsCfg.Insecure {
+			logrus.Warnf("Skipping TLS verification for service downloads")
+			tlsConfig.InsecureSkipVerify = true
+		}
+		if m.tlsCfg.CertFilePath != "" {
+			certPool, err := m.tlsCfg.SetupGenericTLSConfigFromFile()
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = certPool
+		}
+	}
+
+	return &http.Client{
+		// if a proxy is set with the proper envvars, we will use it
+		// as long as the req does not match an entry in no_proxy env var
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}, nil
+}
+
+// installed returns the services installed by the manager.
+func (m *Manager) installed() (map[string]*installation, error) {
+	installed := map[string]*installation{}
+	bs, err := os.ReadFile(m.statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return installed, nil
+		}
+		return nil, errors.Wrap(err, "could not read installed services state")
+	}
+	if err := json.Unmarshal(bs, &installed); err != nil {
+		return nil, errors.Wrap(err, "could not parse installed services state")
+	}
+	return installed, nil
+}
+
+func (m *Manager) writeInstalled(installed map[string]*installation) error {
+	if len(installed) == 0 {
+		if err := os.Remove(m.statePath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "could not remove installed services state")
+		}
+		return nil
+	}
+	bs, err := json.MarshalIndent(installed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(m.statePath, bs, 0644); err != nil {
+		return errors.Wrap(err, "could not record installed services state")
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/wins/pkg/winservice"
+)
+
+func assertCalls(t *testing.T, ctl *winservice.Fake, calls ...string) {
+	t.Helper()
+	if actual := ctl.TakeCalls(); !reflect.DeepEqual(actual, calls) {
+		t.Errorf("expected calls %v, got %v", calls, actual)
+	}
+}
+
+func assertState(t *testing.T, ctl *winservice.Fake, name string, expected winservice.State) {
+	t.Helper()
+	if state, err := ctl.State(name); err != nil || state != expected {
+		t.Errorf("expected service %s to be %s, got %s: %v", name, expected, state, err)
+	}
+}
+
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestReconcile(t *testing.T) {
+	binaries := map[string]string{"/v1/agent.exe": "v1", "/v2/agent.exe": "v2"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := binaries[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	ctl := winservice.NewFake()
+	m := NewManager(ctl, dir, nil)
+	m.stateTimeout = 60 * time.Millisecond
+
+	agent := Config{
+		Name:    "agent",
+		URL:     server.URL + "/v1/agent.exe",
+		SHA256:  checksum("v1"),
+		Args:    []string{"--debug"},
+		EnvVars: map[string]string{"B": "2", "A": "1"},
+	}
+	local := Config{Name: "local", Path: `c:\bin\local.exe`, StartType: StartManual}
+	binaryPath := filepath.Join(dir, "agent", "agent.exe")
+
+	assertBinary := func(content string) {
+		t.Helper()
+		bs, err := os.ReadFile(binaryPath)
+		if err != nil || string(bs) != content {
+			t.Errorf("expected binary %q, got %q: %v", content, bs, err)
+		}
+	}
+
+	// new services are installed, and started unless they are started manually
+	if err := m.Reconcile([]Config{agent, local}); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, ctl, "create agent", "start agent", "create local")
+	assertBinary("v1")
+	expected := &winservice.Config{
+		BinaryPath:      binaryPath,
+		Args:            []string{"--debug"},
+		DisplayName:     "agent",
+		Description:     "Managed by Rancher Wins.",
+		EnvVars:         []string{"A=1", "B=2"},
+		StartType:       winservice.StartAutomatic,
+		RecoveryActions: []winservice.RecoveryAction{{Type: winservice.RecoveryRestart, Delay: 10 * time.Second}},
+	}
+	if cfg, err := ctl.Config("agent"); err != nil || !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("expected config %+v, got %+v: %v", expected, cfg, err)
+	}
+	assertState(t, ctl, "local", winservice.Stopped)
+
+	// unchanged services are left alone, a stopped automatic service is started
+	ctl.SetState("agent", winservice.Stopped)
+	if err := NewManager(ctl, dir, nil).Reconcile([]Config{agent, local}); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, ctl, "start agent")
+
+	// a changed service is stopped, updated and started again
+	agent.URL, agent.SHA256 = server.URL+"/v2/agent.exe", checksum("v2")
+	if err := m.Reconcile([]Config{agent, local}); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, ctl, "stop agent", "update agent", "start agent")
+	assertBinary("v2")
+
+	// a binary with a mismatching checksum is not installed
+	agent.SHA256 = checksum("v1")
+	if err := m.Reconcile([]Config{agent, local}); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("expected checksum error, got %v", err)
+	}
+	assertCalls(t, ctl)
+	assertBinary("v2")
+	if status, _ := m.Status("agent"); status.State != "Running" || status.Error == "" {
+		t.Errorf("expected running service reporting the error, got %+v", status)
+	}
+
+	// services that are not installed by the manager are not touched
+	ctl.Add("other", &winservice.Config{BinaryPath: `c:\bin\other.exe`}, winservice.Running)
+	if err := m.Reconcile([]Config{{Name: "other", Path: `c:\bin\other.exe`}}); err == nil || !strings.Contains(err.Error(), "not managed") {
+		t.Errorf("expected unmanaged service error, got %v", err)
+	}
+	assertCalls(t, ctl, "stop agent", "delete agent", "delete local")
+	if _, err := os.Stat(filepath.Join(dir, "agent")); !os.IsNotExist(err) {
+		t.Errorf("expected binaries of removed service to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, stateName)); !os.IsNotExist(err) {
+		t.Errorf("expected state to be removed once no service is installed, got %v", err)
+	}
+
+	if statuses := m.List(); len(statuses) != 1 || statuses[0].State != "Running" || statuses[0].Error == "" {
+		t.Errorf("expected unmanaged service to be listed with its error, got %+v", statuses)
+	}
+	if _, err := m.Status("agent"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	ctl := winservice.NewFake()
+	m := NewManager(ctl, t.TempDir(), nil)
+	m.stateTimeout = 60 * time.Millisecond
+	if err := m.Reconcile([]Config{{Name: "local", Path: `c:\bin\local.exe`}}); err != nil {
+		t.Fatal(err)
+	}
+	ctl.TakeCalls()
+
+	if err := m.Restart("LOCAL"); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, ctl, "stop local", "start local")
+	assertState(t, ctl, "local", winservice.Running)
+
+	if err := m.Restart("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []Config
+		err     string
+	}{
+		{
+			name:    "valid",
+			configs: []Config{{Name: "a", URL: "https://example.com/a.exe", StartType: StartDelayed}, {Name: "b", Path: `c:\b.exe`}},
+		},
+		{
+			name:    "reserved",
+			configs: []Config{{Name: "CSIProxy", Path: `c:\csi-proxy.exe`}},
+			err:     "reserved",
+		},
+		{
+			name:    "no binary",
+			configs: []Config{{Name: "a"}},
+			err:     "either url or path",
+		},
+		{
+			name:    "duplicate",
+			configs: []Config{{Name: "a", Path: `c:\a.exe`}, {Name: "A", Path: `c:\a.exe`}},
+			err:     "more than once",
+		},
+		{
+			name:    "start type",
+			configs: []Config{{Name: "a", Path: `c:\a.exe`, StartType: "boot"}},
+			err:     "start type",
+		},
+		{
+			name:    "recovery action",
+			configs: []Config{{Name: "a", Path: `c:\a.exe`, RecoveryActions: []RecoveryAction{{Type: "reboot"}}}},
+			err:     "recovery action",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAll(tt.configs)
+			if tt.err == "" && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rancher/system-agent/pkg/applyinator"
@@ -11,55 +14,284 @@
 	"github.com/rancher/system-agent/pkg/k8splan"
 	"github.com/rancher/system-agent/pkg/localplan"
 	"github.com/rancher/system-agent/pkg/version"
+	"github.com/rancher/wins/pkg/systemagent/connection"
 	"github.com/sirupsen/logrus"
 )
 
+// States reported by Agent.Status
+const (
+	StateDisabled   = "Disabled"
+	StateNotStarted = "Not Started"
+	StateWatching   = "Watching"
+	StateFailed     = "Failed"
+	StateStopped    = "Stopped"
+)
+
+// preflightTimeout bounds the connection check run before watching remote plans
+const preflightTimeout = 10 * time.Second
+
+// defaultRetryInterval is how long the agent waits before it tries to start again after it failed to start
+const defaultRetryInterval = 30 * time.Second
+
+// applier watches plans and applies them with the applyinator until ctx is done, it is replaced by a fake in tests
+type applier interface {
+	watchRemote(ctx context.Context, cfg *config.AgentConfig, connInfo config.ConnectionInfo, strictTLSMode bool)
+	watchLocal(ctx context.Context, cfg *config.AgentConfig)
+}
+
+// Agent supervises the embedded system agent. It watches plans while Run is running and can be
+// reconfigured meanwhile, a failure to start is reported by Status and retried.
 type Agent struct {
+	applier         applier
+	checkConnection func(ctx context.Context, path string, strictTLSMode bool) *connection.Result
+	readPlanSecret  func(ctx context.Context, path string, strictTLSMode bool) (map[string][]byte, error)
+	retryInterval   time.Duration
+
+	mu            sync.RWMutex
 	cfg           *config.AgentConfig
-	StrictTLSMode bool
+	strictTLSMode bool
+	// ctx is set while Run is running, cancel stops the watches of the current configuration
+	ctx    context.Context
+	cancel context.CancelFunc
+	state  string
+	err    error
 }
 
+// Enabled returns whether the agent has been configured
+func (a *Agent) Enabled() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cfg != nil
+}
+
+// Status returns the state of the agent and the error it failed with, if any
+func (a *Agent) Status() (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.state, a.err
+}
+
+// config returns the configuration of the agent, the returned config must not be modified
+func (a *Agent) config() (*config.AgentConfig, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cfg, a.strictTLSMode
+}
+
+// Run watches plans until ctx is done. Failures do not stop Run, they are reported by Status instead.
 func (a *Agent) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.ctx != nil {
+		a.mu.Unlock()
+		return errors.New("system agent is running already")
+	}
+	a.ctx = ctx
+	a.start()
+	a.mu.Unlock()
+
+	<-ctx.Done()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stop()
+	a.ctx = nil
+	if a.cfg != nil {
+		a.state, a.err = StateStopped, nil
+	}
+	return nil
+}
 
+// Reconfigure restarts the agent with cfg and strictTLSMode if they changed. An invalid configuration
+// is rejected and the agent keeps running with its current configuration.
+func (a *Agent) Reconfigure(cfg *config.AgentConfig, strictTLSMode bool) error {
+	if cfg != nil {
+		if err := validate(cfg); err != nil {
+			return err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if reflect.DeepEqual(a.cfg, cfg) && a.strictTLSMode == strictTLSMode {
+		return nil
+	}
+	logrus.Infof("Rancher System Agent configuration changed, restarting system agent")
+	a.stop()
+	a.cfg, a.strictTLSMode = cfg, strictTLSMode
+	a.start()
+	return nil
+}
+
+// CheckConnection checks the connection to the server remote plans are watched from
+func (a *Agent) CheckConnection(ctx context.Context) (*connection.Result, error) {
+	cfg, strictTLSMode := a.config()
+	if cfg == nil || !cfg.RemoteEnabled {
+		return nil, errors.New("remote plans are not enabled")
+	}
+	return a.checkConnection(ctx, cfg.ConnectionInfoFile, strictTLSMode), nil
+}
+
+// Restart stops watching plans and starts again with the current configuration
+func (a *Agent) Restart() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.ctx == nil {
+		return errors.New("system agent is not running")
+	}
+	if a.cfg == nil {
+		return errors.New("system agent is not configured")
+	}
+	logrus.Infof("Restarting system agent")
+	a.stop()
+	a.start()
+	return nil
+}
+
+// start starts watching plans with the current configuration in the background, must be called with mu held
+func (a *Agent) start() {
+	a.err = nil
 	if a.cfg == nil {
 		logrus.Info("Rancher System Agent configuration not found, not starting system agent.")
-		return nil
+		a.state = StateDisabled
+		return
+	}
+	if a.ctx == nil {
+		a.state = StateNotStarted
+		return
 	}
 
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.cancel = cancel
+	a.state = StateNotStarted
+	go a.supervise(ctx, a.cfg, a.strictTLSMode)
+}
+
+// stop stops the watches of the current configuration, must be called with mu held
+func (a *Agent) stop() {
+	if a.cancel != nil {
+		a.cancel()
+		a.cancel = nil
+	}
+}
+
+// supervise starts watching plans with cfg, retrying until it succeeds or ctx is done
+func (a *Agent) supervise(ctx context.Context, cfg *config.AgentConfig, strictTLSMode bool) {
+	for {
+		err := a.watch(ctx, cfg, strictTLSMode)
+		if err == nil {
+			a.setStatus(ctx, StateWatching, nil)
+			return
+		}
+		logrus.Errorf("Rancher System Agent failed to start, retrying in %v: %v", a.retryInterval, err)
+		a.setStatus(ctx, StateFailed, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.retryInterval):
+		}
+	}
+}
+
+// setStatus records the state of the watches started with ctx, unless they have been stopped meanwhile
+func (a *Agent) setStatus(ctx context.Context, state string, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	a.state, a.err = state, err
+}
+
+func (a *Agent) watch(ctx context.Context, cfg *config.AgentConfig, strictTLSMode bool) error {
 	logrus.Infof("Rancher System Agent version %s is starting", version.FriendlyVersion())
 
-	if !a.cfg.LocalEnabled && !a.cfg.RemoteEnabled {
-		return errors.New("local and remote were both not enabled. exiting, as one must be enabled")
+	if err := validate(cfg); err != nil {
+		return err
 	}
 
-	logrus.Infof("Setting %s as the working directory", a.cfg.WorkDir)
+	logrus.Infof("Setting %s as the working directory", cfg.WorkDir)
 
-	imageUtil := image.NewUtility(a.cfg.ImagesDir, a.cfg.ImageCredentialProviderConfig, a.cfg.ImageCredentialProviderBinDir, a.cfg.AgentRegistriesFile)
-	// Currently we do not support the 'interlockDir' on Windows, as the system-agent install script does not yet utilize those files
-	applier := applyinator.NewApplyinator(a.cfg.WorkDir, a.cfg.PreserveWorkDir, a.cfg.AppliedPlanDir, "", imageUtil)
-	if a.cfg.RemoteEnabled {
+	if cfg.RemoteEnabled {
 		logrus.Infof("Starting remote watch of plans")
-		logrus.Debugf("Agent Strict TLS Mode is %t", a.StrictTLSMode)
+		logrus.Debugf("Agent Strict TLS Mode is %t", strictTLSMode)
 
 		var connInfo config.ConnectionInfo
 
-		if err := config.Parse(a.cfg.ConnectionInfoFile, &connInfo); err != nil {
+		if err := config.Parse(cfg.ConnectionInfoFile, &connInfo); err != nil {
 			return fmt.Errorf("unable to parse connection info file: %v", err)
 		}
 
-		k8splan.Watch(ctx, *applier, connInfo, a.StrictTLSMode)
+		a.preflight(ctx, cfg.ConnectionInfoFile, strictTLSMode)
+		a.applier.watchRemote(ctx, cfg, connInfo, strictTLSMode)
+	}
+
+	if cfg.LocalEnabled {
+		logrus.Infof("Starting local watch of plans in %s", cfg.LocalPlanDir)
+		a.applier.watchLocal(ctx, cfg)
 	}
 
-	if a.cfg.LocalEnabled {
-		logrus.Infof("Starting local watch of plans in %s", a.cfg.LocalPlanDir)
-		localplan.WatchFiles(ctx, *applier, a.cfg.LocalPlanDir)
+	return nil
+}
+
+// preflight checks the connection to the server remote plans are watched from. A failing check is logged,
+// the system agent keeps retrying to connect on its own.
+func (a *Agent) preflight(ctx context.Context, path string, strictTLSMode bool) {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	result := a.checkConnection(ctx, path, strictTLSMode)
+	if err := result.Err(); err != nil {
+		logrus.Errorf("Rancher System Agent cannot connect to %s, run 'wins cli agent check-connection' for details: %v", result.Server, err)
+		return
 	}
+	logrus.Infof("Rancher System Agent can connect to %s", result.Server)
+}
 
+// validate ensures that cfg enables watching plans and that the connection info file of remote plans can be parsed
+func validate(cfg *config.AgentConfig) error {
+	if !cfg.LocalEnabled && !cfg.RemoteEnabled {
+		return errors.New("local and remote were both not enabled. exiting, as one must be enabled")
+	}
+	if cfg.RemoteEnabled {
+		var connInfo config.ConnectionInfo
+		if err := config.Parse(cfg.ConnectionInfoFile, &connInfo); err != nil {
+			return fmt.Errorf("unable to parse connection info file: %v", err)
+		}
+	}
 	return nil
 }
 
-func New(cfg *config.AgentConfig) *Agent {
+// systemAgentApplier watches plans with the applyinator of the system agent
+type systemAgentApplier struct{}
+
+func (systemAgentApplier) watchRemote(ctx context.Context, cfg *config.AgentConfig, connInfo config.ConnectionInfo, strictTLSMode bool) {
+	k8splan.Watch(ctx, *newApplyinator(cfg), connInfo, strictTLSMode)
+}
+
+func (systemAgentApplier) watchLocal(ctx context.Context, cfg *config.AgentConfig) {
+	localplan.WatchFiles(ctx, *newApplyinator(cfg), cfg.LocalPlanDir)
+}
+
+func newApplyinator(cfg *config.AgentConfig) *applyinator.Applyinator {
+	imageUtil := image.NewUtility(cfg.ImagesDir, cfg.ImageCredentialProviderConfig, cfg.ImageCredentialProviderBinDir, cfg.AgentRegistriesFile)
+	// Currently we do not support the 'interlockDir' on Windows, as the system-agent install script does not yet utilize those files
+	return applyinator.NewApplyinator(cfg.WorkDir, cfg.PreserveWorkDir, cfg.AppliedPlanDir, "", imageUtil)
+}
+
+// New creates an agent watching plans configured by cfg once it runs, cfg can be nil if the agent is disabled
+func New(cfg *config.AgentConfig, strictTLSMode bool) *Agent {
+	state := StateNotStarted
+	if cfg == nil {
+		state = StateDisabled
+	}
 	return &Agent{
-		cfg: cfg,
+		applier:         systemAgentApplier{},
+		checkConnection: connection.Check,
+		readPlanSecret:  connection.ReadPlanSecret,
+		retryInterval:   defaultRetryInterval,
+		cfg:             cfg,
+		strictTLSMode:   strictTLSMode,
+		state:           state,
 	}
 }
@@ -0,0 +1,173 @@
+package systemagent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/system-agent/pkg/config"
+	"github.com/rancher/wins/pkg/systemagent/connection"
+)
+
+// fakeApplier records the watches started by the agent, a watch is active until its context is done
+type fakeApplier struct {
+	mu      sync.Mutex
+	watches []*fakeWatch
+}
+
+type fakeWatch struct {
+	ctx           context.Context
+	remote        bool
+	strictTLSMode bool
+}
+
+func (f *fakeApplier) watchRemote(ctx context.Context, _ *config.AgentConfig, _ config.ConnectionInfo, strictTLSMode bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.watches = append(f.watches, &fakeWatch{ctx: ctx, remote: true, strictTLSMode: strictTLSMode})
+}
+
+func (f *fakeApplier) watchLocal(ctx context.Context, _ *config.AgentConfig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.watches = append(f.watches, &fakeWatch{ctx: ctx})
+}
+
+// active returns the watches that have not been stopped
+func (f *fakeApplier) active() []*fakeWatch {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var active []*fakeWatch
+	for _, w := range f.watches {
+		if w.ctx.Err() == nil {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+func newTestAgent(cfg *config.AgentConfig, strictTLSMode bool) (*Agent, *fakeApplier) {
+	applier := &fakeApplier{}
+	a := New(cfg, strictTLSMode)
+	a.applier = applier
+	a.checkConnection = func(context.Context, string, bool) *connection.Result { return &connection.Result{} }
+	a.retryInterval = 10 * time.Millisecond
+	return a, applier
+}
+
+func waitForState(t *testing.T, a *Agent, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		state, err := a.Status()
+		if state == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected agent to be %s, got %s: %v", expected, state, err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func runAgent(t *testing.T, a *Agent) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	return func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("expected agent to stop cleanly, got %v", err)
+		}
+	}
+}
+
+func TestAgentReconfigure(t *testing.T) {
+	connectionInfoFile := filepath.Join(t.TempDir(), "connection-info.json")
+	writeJSON(t, connectionInfoFile, config.ConnectionInfo{Namespace: "fleet-default"})
+
+	local := &config.AgentConfig{LocalEnabled: true, LocalPlanDir: `c:\plans`}
+	a, applier := newTestAgent(local, false)
+	stop := runAgent(t, a)
+
+	waitForState(t, a, StateWatching)
+	if active := applier.active(); len(active) != 1 || active[0].remote {
+		t.Fatalf("expected a local watch, got %+v", active)
+	}
+
+	// an invalid config is rejected and the agent keeps running
+	if err := a.Reconfigure(&config.AgentConfig{}, false); err == nil {
+		t.Error("expected config without local and remote plans to be rejected")
+	}
+	if state, _ := a.Status(); state != StateWatching || len(applier.active()) != 1 {
+		t.Errorf("expected agent to keep watching, got %s", state)
+	}
+
+	// a new config replaces the watches
+	remote := &config.AgentConfig{RemoteEnabled: true, ConnectionInfoFile: connectionInfoFile}
+	if err := a.Reconfigure(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, a, StateWatching)
+	if active := applier.active(); len(active) != 1 || !active[0].remote || !active[0].strictTLSMode {
+		t.Fatalf("expected a strict remote watch, got %+v", active)
+	}
+
+	// an unchanged config does not restart the agent
+	if err := a.Reconfigure(&config.AgentConfig{RemoteEnabled: true, ConnectionInfoFile: connectionInfoFile}, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(applier.watches) != 2 {
+		t.Errorf("expected no restart, got %d watches", len(applier.watches))
+	}
+
+	if err := a.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, a, StateWatching)
+	if len(applier.watches) != 3 || len(applier.active()) != 1 {
+		t.Errorf("expected restart to replace the watch, got %d watches", len(applier.watches))
+	}
+
+	// removing the config disables the agent
+	if err := a.Reconfigure(nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := a.Status(); state != StateDisabled || len(applier.active()) != 0 {
+		t.Errorf("expected disabled agent without watches, got %s", state)
+	}
+
+	stop()
+	if err := a.Restart(); err == nil {
+		t.Error("expected restart of a stopped agent to fail")
+	}
+}
+
+func TestAgentRetry(t *testing.T) {
+	connectionInfoFile := filepath.Join(t.TempDir(), "connection-info.json")
+	a, applier := newTestAgent(&config.AgentConfig{RemoteEnabled: true, ConnectionInfoFile: connectionInfoFile}, false)
+	stop := runAgent(t, a)
+
+	// the connection info is written after the agent has been started
+	waitForState(t, a, StateFailed)
+	if _, err := a.Status(); err == nil {
+		t.Error("expected the failure to be reported")
+	}
+	if err := os.WriteFile(connectionInfoFile, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	waitForState(t, a, StateWatching)
+	if active := applier.active(); len(active) != 1 {
+		t.Errorf("expected a remote watch, got %+v", active)
+	}
+
+	stop()
+	if state, _ := a.Status(); state != StateStopped || len(applier.active()) != 0 {
+		t.Errorf("expected stopped agent without watches, got %s", state)
+	}
+}
@@ -0,0 +1,344 @@
+package connection
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/rancher/system-agent/pkg/config"
+)
+
+// Info is the target the system agent watches remote plans from, read from its connection info file
+type Info struct {
+	Server     string
+	Namespace  string
+	SecretName string
+	// CAData is the PEM encoded certificate authority of the server, the system trust store is used if empty
+	CAData                []byte
+	InsecureSkipTLSVerify bool
+	ClientCertData        []byte
+	ClientKeyData         []byte
+	// Token is the bearer token the system agent authenticates with
+	Token string
+}
+
+// kubeConfig holds the fields of the kubeconfig embedded into the connection info that are checked
+type kubeConfig struct {
+	CurrentContext string `json:"current-context"`
+	Contexts       []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster string `json:"cluster"`
+			User    string `json:"user"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Clusters []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthorityData []byte `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			ClientCertificateData []byte `json:"client-certificate-data"`
+			ClientKeyData         []byte `json:"client-key-data"`
+			Token                 string `json:"token"`
+		} `json:"user"`
+	} `json:"users"`
+}
+
+// Read parses the connection info file at path and the kubeconfig embedded into it
+func Read(path string) (*Info, error) {
+	var connInfo config.ConnectionInfo
+	if err := config.Parse(path, &connInfo); err != nil {
+		return nil, errors.Wrapf(err, "could not parse connection info file %s", path)
+	}
+
+	info := &Info{
+		Namespace:  connInfo.Namespace,
+		SecretName: connInfo.SecretName,
+	}
+	var kc kubeConfig
+	if err := yaml.Unmarshal([]byte(connInfo.KubeConfig), &kc); err != nil {
+		return info, errors.Wrap(err, "could not parse kubeconfig of connection info")
+	}
+
+	// the cluster and user of the current context are used, falling back to the first ones
+	clusterName, userName := "", ""
+	for _, c := range kc.Contexts {
+		if c.Name == kc.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+	found := false
+	for _, c := range kc.Clusters {
+		if c.Name == clusterName || (clusterName == "" && !found) {
+			info.Server = c.Cluster.Server
+			info.CAData = c.Cluster.CertificateAuthorityData
+			info.InsecureSkipTLSVerify = c.Cluster.InsecureSkipTLSVerify
+			found = true
+		}
+	}
+	if !found {
+		return info, errors.New("kubeconfig of connection info does not define a cluster")
+	}
+	if info.Server == "" {
+		return info, errors.New("kubeconfig of connection info does not define a server")
+	}
+	for i, u := range kc.Users {
+		if u.Name == userName || (userName == "" && i == 0) {
+			info.ClientCertData = u.User.ClientCertificateData
+			info.ClientKeyData = u.User.ClientKeyData
+			info.Token = u.User.Token
+		}
+	}
+	return info, nil
+}
+
+// Step is a single step of a connection check, it failed if Error is set
+type Step struct {
+	Name    string
+	Message string
+	Error   string
+}
+
+// Result lists the steps of a connection check in order, the check stops at the first failing step
+type Result struct {
+	Server string
+	Steps  []Step
+}
+
+// Err returns the error of the failing step, if any
+func (r *Result) Err() error {
+	for _, step := range r.Steps {
+		if step.Error != "" {
+			return errors.Errorf("%s: %s", step.Name, step.Error)
+		}
+	}
+	return nil
+}
+
+func (r *Result) pass(name, format string, args ...interface{}) {
+	r.Steps = append(r.Steps, Step{Name: name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *Result) fail(name string, err error) *Result {
+	r.Steps = append(r.Steps, Step{Name: name, Error: err.Error()})
+	return r
+}
+
+// Check parses the connection info file at path, validates the certificates it contains and connects to the
+// server it points to. Like the system agent, the system trust store is tried before the certificate authority
+// of the connection info unless strictTLSMode is set.
+func Check(ctx context.Context, path string, strictTLSMode bool) *Result {
+	r := &Result{}
+	info, err := Read(path)
+	if info != nil {
+		r.Server = info.Server
+	}
+	if err != nil {
+		return r.fail("parse connection info", err)
+	}
+	r.pass("parse connection info", "server %s, plan secret %s/%s", info.Server, info.Namespace, info.SecretName)
+
+	u, err := url.Parse(info.Server)
+	if err != nil || u.Host == "" || u.Scheme != "https" {
+		return r.fail("parse server", errors.Errorf("server %q is not an https URL", info.Server))
+	}
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	now := time.Now()
+	var caPool *x509.CertPool
+	if len(info.CAData) != 0 {
+		caPool, err = parseCertificateAuthority(info.CAData, now)
+		if err != nil {
+			return r.fail("validate certificate authority", err)
+		}
+		r.pass("validate certificate authority", "certificate authority is valid")
+	}
+
+	var clientCerts []tls.Certificate
+	if len(info.ClientCertData) != 0 {
+		cert, err := parseClientCertificate(info.ClientCertData, info.ClientKeyData, now)
+		if err != nil {
+			return r.fail("validate client certificate", err)
+		}
+		clientCerts = append(clientCerts, cert)
+		r.pass("validate client certificate", "client certificate is valid")
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		r.pass("resolve "+host, "server is addressed by IP")
+	} else {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return r.fail("resolve "+host, err)
+		}
+		r.pass("resolve "+host, "resolved to %s", strings.Join(addrs, ", "))
+	}
+
+	address := net.JoinHostPort(host, port)
+	handshake := func(roots *x509.CertPool, insecure bool) error {
+		dialer := &tls.Dialer{Config: &tls.Config{
+			ServerName:         host,
+			RootCAs:            roots,
+			Certificates:       clientCerts,
+			InsecureSkipVerify: insecure,
+		}}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	switch {
+	case info.InsecureSkipTLSVerify:
+		if err := handshake(nil, true); err != nil {
+			return r.fail("tls handshake", err)
+		}
+		r.pass("tls handshake", "connected to %s without verifying its certificate", address)
+	case caPool == nil:
+		if err := handshake(nil, false); err != nil {
+			return r.fail("tls handshake", errors.Wrap(err, "server certificate is not trusted by the system trust store"))
+		}
+		r.pass("tls handshake", "connected to %s, server certificate is trusted by the system trust store", address)
+	case !strictTLSMode && handshake(nil, false) == nil:
+		r.pass("tls handshake", "connected to %s, server certificate is trusted by the system trust store", address)
+	default:
+		if err := handshake(caPool, false); err != nil {
+			if strictTLSMode {
+				err = errors.Wrap(err, "server certificate is not trusted by the certificate authority of the connection info, strict TLS mode is enabled")
+			} else {
+				err = errors.Wrap(err, "server certificate is trusted by neither the system trust store nor the certificate authority of the connection info")
+			}
+			return r.fail("tls handshake", err)
+		}
+		r.pass("tls handshake", "connected to %s, server certificate is trusted by the certificate authority of the connection info", address)
+	}
+	return r
+}
+
+// ReadPlanSecret reads the data of the plan secret the system agent watches remote plans from, and records their
+// outcome in, with the credentials of the connection info file at path. The server certificate is verified like
+// the system agent does, see Check.
+func ReadPlanSecret(ctx context.Context, path string, strictTLSMode bool) (map[string][]byte, error) {
+	info, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: info.InsecureSkipTLSVerify}
+	if len(info.CAData) != 0 && !info.InsecureSkipTLSVerify {
+		// the system trust store is tried before the certificate authority unless strict TLS mode is enabled
+		pool := x509.NewCertPool()
+		if systemPool, err := x509.SystemCertPool(); err == nil && !strictTLSMode {
+			pool = systemPool
+		}
+		if !pool.AppendCertsFromPEM(info.CAData) {
+			return nil, errors.New("certificate-authority-data does not contain a PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(info.ClientCertData) != 0 {
+		cert, err := tls.X509KeyPair(info.ClientCertData, info.ClientKeyData)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse client certificate and key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	secretURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", strings.TrimSuffix(info.Server, "/"), url.PathEscape(info.Namespace), url.PathEscape(info.SecretName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if info.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+info.Token)
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment}}
+	defer client.CloseIdleConnections()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read plan secret %s/%s", info.Namespace, info.SecretName)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("could not read plan secret %s/%s: %s", info.Namespace, info.SecretName, resp.Status)
+	}
+
+	// the values of the data of a secret are base64 encoded, which decoding into []byte undoes
+	var secret struct {
+		Data map[string][]byte `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, errors.Wrapf(err, "could not parse plan secret %s/%s", info.Namespace, info.SecretName)
+	}
+	return secret.Data, nil
+}
+
+// parseCertificateAuthority parses the PEM encoded certificates in data and ensures that they are valid at now
+func parseCertificateAuthority(data []byte, now time.Time) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	count := 0
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not parse certificate")
+		}
+		if err := checkValidity(cert, now); err != nil {
+			return nil, err
+		}
+		pool.AddCert(cert)
+		count++
+	}
+	if count == 0 {
+		return nil, errors.New("certificate-authority-data does not contain a PEM encoded certificate")
+	}
+	return pool, nil
+}
+
+// parseClientCertificate parses the PEM encoded key pair and ensures that the certificate is valid at now
+func parseClientCertificate(certData, keyData []byte, now time.Time) (tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return cert, errors.Wrap(err, "could not parse client certificate and key")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return cert, errors.Wrap(err, "could not parse client certificate")
+	}
+	return cert, checkValidity(leaf, now)
+}
+
+func checkValidity(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return errors.Errorf("certificate %q is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return errors.Errorf("certificate %q expired at %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package connection
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/system-agent/pkg/config"
+)
+
+func certPEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func selfSignedPEM(t *testing.T, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certPEM(der)
+}
+
+func writeConnectionInfo(t *testing.T, server string, caData []byte) string {
+	t.Helper()
+	kubeConfig := fmt.Sprintf(`apiVersion: v1
+clusters:
+- name: local
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: default
+  context:
+    cluster: local
+    user: default
+current-context: default
+users:
+- name: default
+  user:
+    token: secret
+`, server, base64.StdEncoding.EncodeToString(caData))
+	bs, err := json.Marshal(config.ConnectionInfo{KubeConfig: kubeConfig, Namespace: "fleet-default", SecretName: "plan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "connection-info.json")
+	if err := os.WriteFile(path, bs, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheck(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	serverCA := certPEM(server.Certificate().Raw)
+
+	tests := []struct {
+		name          string
+		server        string
+		caData        []byte
+		strictTLSMode bool
+		failedStep    string
+		err           string
+	}{
+		{
+			name:          "trusted by the certificate authority",
+			server:        server.URL,
+			caData:        serverCA,
+			strictTLSMode: true,
+		},
+		{
+			name:       "not trusted",
+			server:     server.URL,
+			caData:     selfSignedPEM(t, time.Now().Add(time.Hour)),
+			failedStep: "tls handshake",
+			err:        "neither the system trust store nor the certificate authority",
+		},
+		{
+			name:          "not trusted in strict mode",
+			server:        server.URL,
+			caData:        selfSignedPEM(t, time.Now().Add(time.Hour)),
+			strictTLSMode: true,
+			failedStep:    "tls handshake",
+			err:           "strict TLS mode is enabled",
+		},
+		{
+			name:       "expired certificate authority",
+			server:     server.URL,
+			caData:     selfSignedPEM(t, time.Now().Add(-time.Hour)),
+			failedStep: "validate certificate authority",
+			err:        `certificate "test-ca" expired`,
+		},
+		{
+			name:       "malformed certificate authority",
+			server:     server.URL,
+			caData:     []byte("not a certificate"),
+			failedStep: "validate certificate authority",
+			err:        "does not contain a PEM encoded certificate",
+		},
+		{
+			name:       "plain http",
+			server:     strings.Replace(server.URL, "https", "http", 1),
+			caData:     serverCA,
+			failedStep: "parse server",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Check(context.Background(), writeConnectionInfo(t, tt.server, tt.caData), tt.strictTLSMode)
+			if result.Server != tt.server {
+				t.Errorf("expected server %s, got %s", tt.server, result.Server)
+			}
+			last := result.Steps[len(result.Steps)-1]
+			if tt.failedStep == "" {
+				if err := result.Err(); err != nil {
+					t.Errorf("expected check to succeed, got %v", err)
+				}
+				return
+			}
+			if last.Name != tt.failedStep || !strings.Contains(last.Error, tt.err) {
+				t.Errorf("expected step %s to fail with %q, got %+v", tt.failedStep, tt.err, last)
+			}
+		})
+	}
+}
+
+func TestCheckConnectionInfo(t *testing.T) {
+	missing := Check(context.Background(), filepath.Join(t.TempDir(), "missing.json"), false)
+	if err := missing.Err(); err == nil || !strings.Contains(err.Error(), "parse connection info") {
+		t.Errorf("expected missing connection info to fail, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "connection-info.json")
+	if err := os.WriteFile(path, []byte(`{"kubeConfig":"clusters: []"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(context.Background(), path, false).Err(); err == nil || !strings.Contains(err.Error(), "does not define a cluster") {
+		t.Errorf("expected kubeconfig without cluster to fail, got %v", err)
+	}
+}
+
+func TestReadPlanSecret(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/namespaces/fleet-default/secrets/plan" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"kind":"Secret","data":{"applied-checksum":"%s"}}`, base64.StdEncoding.EncodeToString([]byte("abc")))
+	}))
+	defer server.Close()
+
+	data, err := ReadPlanSecret(context.Background(), writeConnectionInfo(t, server.URL, certPEM(server.Certificate().Raw)), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data["applied-checksum"]) != "abc" {
+		t.Errorf("expected applied checksum abc, got %q", data["applied-checksum"])
+	}
+
+	_, err = ReadPlanSecret(context.Background(), writeConnectionInfo(t, server.URL, selfSignedPEM(t, time.Now().Add(time.Hour))), true)
+	if err == nil {
+		t.Error("expected a server certificate that is not trusted to be rejected")
+	}
+}
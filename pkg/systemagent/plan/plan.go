@@ -0,0 +1,246 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/system-agent/pkg/applyinator"
+)
+
+const (
+	// localPlanSuffix is the suffix of the files the system agent picks up from the local plan directory
+	localPlanSuffix = ".plan"
+	// defaultPeriodSeconds is the period of a periodic instruction that does not set one
+	defaultPeriodSeconds = 600
+)
+
+// Validation is the result of validating a plan file, the plan is valid if there are no errors
+type Validation struct {
+	File     string
+	Checksum string
+	Valid    bool
+	Errors   []string
+	Warnings []string
+}
+
+// DryRun describes what applying a plan would do
+type DryRun struct {
+	File         string
+	Checksum     string
+	Files        []FileChange
+	Instructions []InstructionRun
+	Images       []string
+	Probes       []ProbeCheck
+}
+
+// FileChange is a file of the plan, Action is one of write, create directory or delete
+type FileChange struct {
+	Path        string
+	Action      string
+	Permissions string `json:",omitempty"`
+	Size        int    `json:",omitempty"`
+}
+
+// InstructionRun is an instruction of the plan, one-time instructions run once in order
+// while periodic instructions run every PeriodSeconds
+type InstructionRun struct {
+	Name          string
+	Type          string
+	Image         string   `json:",omitempty"`
+	Command       string   `json:",omitempty"`
+	Args          []string `json:",omitempty"`
+	Env           []string `json:",omitempty"`
+	PeriodSeconds int      `json:",omitempty"`
+}
+
+// ProbeCheck is a probe of the plan
+type ProbeCheck struct {
+	Name string
+	URL  string
+}
+
+// Load reads the plan file at path, fields that are not part of a plan are rejected
+func Load(path string) (*applyinator.CalculatedPlan, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read plan %s", path)
+	}
+
+	var plan applyinator.Plan
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plan); err != nil {
+		return nil, errors.Wrapf(err, "could not parse plan %s", path)
+	}
+
+	// the checksum is calculated the way the system agent does it
+	calculated, err := applyinator.CalculatePlan(bs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not calculate checksum of plan %s", path)
+	}
+	calculated.Plan = plan
+	return &calculated, nil
+}
+
+// Validate loads the plan file at path and checks the plan for mistakes the applyinator would only report when applying it
+func Validate(path string) *Validation {
+	v := &Validation{File: path}
+	if !strings.HasSuffix(path, localPlanSuffix) {
+		v.Warnings = append(v.Warnings, fmt.Sprintf("the file name does not end with %s, the system agent does not pick it up from the local plan directory", localPlanSuffix))
+	}
+
+	calculated, err := Load(path)
+	if err != nil {
+		v.Errors = append(v.Errors, err.Error())
+		return v
+	}
+	v.Checksum = calculated.Checksum
+	errs, warnings := validatePlan(&calculated.Plan)
+	v.Errors, v.Warnings = errs, append(v.Warnings, warnings...)
+	v.Valid = len(v.Errors) == 0
+	return v
+}
+
+func validatePlan(plan *applyinator.Plan) (errs, warnings []string) {
+	paths := map[string]bool{}
+	for i, file := range plan.Files {
+		field := fmt.Sprintf("files[%d]", i)
+		switch {
+		case file.Path == "":
+			errs = append(errs, field+": path is required")
+		case !isAbs(file.Path):
+			errs = append(errs, fmt.Sprintf("%s: path %s is not absolute", field, file.Path))
+		case paths[strings.ToLower(filepath.Clean(file.Path))]:
+			errs = append(errs, fmt.Sprintf("%s: path %s is used more than once", field, file.Path))
+		}
+		paths[strings.ToLower(filepath.Clean(file.Path))] = true
+
+		if file.Directory && file.Content != "" {
+			errs = append(errs, field+": a directory cannot have content")
+		}
+		if _, err := base64.StdEncoding.DecodeString(file.Content); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: content is not base64 encoded: %v", field, err))
+		}
+		if file.Permissions != "" {
+			if _, err := strconv.ParseUint(file.Permissions, 8, 32); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: permissions %q are not octal", field, file.Permissions))
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	checkInstruction := func(field string, instruction applyinator.CommonInstruction) {
+		if instruction.Image == "" && instruction.Command == "" {
+			errs = append(errs, field+": either image or command is required")
+		}
+		if instruction.Name == "" {
+			warnings = append(warnings, field+": instruction has no name, its output cannot be told apart from other unnamed instructions")
+			return
+		}
+		if names[instruction.Name] {
+			errs = append(errs, fmt.Sprintf("%s: name %s is used more than once", field, instruction.Name))
+		}
+		names[instruction.Name] = true
+	}
+	for i, instruction := range plan.OneTimeInstructions {
+		checkInstruction(fmt.Sprintf("instructions[%d]", i), instruction.CommonInstruction)
+	}
+	names = map[string]bool{}
+	for i, instruction := range plan.PeriodicInstructions {
+		field := fmt.Sprintf("periodicInstructions[%d]", i)
+		checkInstruction(field, instruction.CommonInstruction)
+		if instruction.PeriodSeconds < 0 {
+			errs = append(errs, field+": periodSeconds cannot be negative")
+		}
+	}
+
+	for _, name := range probeNames(plan) {
+		if plan.Probes[name].HTTPGetAction.URL == "" {
+			errs = append(errs, fmt.Sprintf("probes[%s]: httpGet.url is required", name))
+		}
+	}
+	return errs, warnings
+}
+
+// Describe returns what applying the plan loaded from path would do, without applying anything
+func Describe(path string, calculated *applyinator.CalculatedPlan) *DryRun {
+	plan := &calculated.Plan
+	d := &DryRun{
+		File:     path,
+		Checksum: calculated.Checksum,
+	}
+	for _, file := range plan.Files {
+		change := FileChange{
+			Path:        file.Path,
+			Action:      "write",
+			Permissions: file.Permissions,
+		}
+		switch {
+		case file.Action == "delete":
+			change.Action = "delete"
+		case file.Directory:
+			change.Action = "create directory"
+		default:
+			content, _ := base64.StdEncoding.DecodeString(file.Content)
+			change.Size = len(content)
+		}
+		d.Files = append(d.Files, change)
+	}
+
+	images := map[string]bool{}
+	addInstruction := func(instructionType string, instruction applyinator.CommonInstruction, periodSeconds int) {
+		d.Instructions = append(d.Instructions, InstructionRun{
+			Name:          instruction.Name,
+			Type:          instructionType,
+			Image:         instruction.Image,
+			Command:       instruction.Command,
+			Args:          instruction.Args,
+			Env:           instruction.Env,
+			PeriodSeconds: periodSeconds,
+		})
+		if instruction.Image != "" && !images[instruction.Image] {
+			images[instruction.Image] = true
+			d.Images = append(d.Images, instruction.Image)
+		}
+	}
+	for _, instruction := range plan.OneTimeInstructions {
+		addInstruction("one-time", instruction.CommonInstruction, 0)
+	}
+	for _, instruction := range plan.PeriodicInstructions {
+		periodSeconds := instruction.PeriodSeconds
+		if periodSeconds == 0 {
+			periodSeconds = defaultPeriodSeconds
+		}
+		addInstruction("periodic", instruction.CommonInstruction, periodSeconds)
+	}
+
+	for _, name := range probeNames(plan) {
+		d.Probes = append(d.Probes, ProbeCheck{Name: name, URL: plan.Probes[name].HTTPGetAction.URL})
+	}
+	return d
+}
+
+func probeNames(plan *applyinator.Plan) []string {
+	names := make([]string, 0, len(plan.Probes))
+	for name := range plan.Probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isAbs reports whether path is absolute on Windows, either rooted or starting with a drive letter
+func isAbs(path string) bool {
+	if strings.HasPrefix(path, `/`) || strings.HasPrefix(path, `\`) {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/')
+}
@@ -0,0 +1,153 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writePlan(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const validPlan = `{
+  "files": [
+    {"path": "c:\\etc\\rancher\\config.yaml", "content": "aGVsbG8=", "permissions": "0600"},
+    {"path": "c:\\var\\lib\\rancher", "directory": true},
+    {"path": "c:\\etc\\rancher\\old.yaml", "action": "delete"}
+  ],
+  "instructions": [
+    {"name": "install", "image": "rancher/system-agent-installer-rke2:v1.28.2", "command": "powershell.exe", "args": ["-File", "run.ps1"], "env": ["INSTALL_RKE2_VERSION=v1.28.2"]}
+  ],
+  "periodicInstructions": [
+    {"name": "status", "command": "rke2.exe", "args": ["status"]},
+    {"name": "install-check", "image": "rancher/system-agent-installer-rke2:v1.28.2", "periodSeconds": 60}
+  ],
+  "probes": {
+    "kubelet": {"httpGet": {"url": "http://127.0.0.1:10248/healthz"}}
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		errs     []string
+		warnings []string
+	}{
+		{
+			name:    "valid",
+			file:    "rke2.plan",
+			content: validPlan,
+		},
+		{
+			name:     "not picked up",
+			file:     "rke2.json",
+			content:  `{}`,
+			warnings: []string{"does not end with .plan"},
+		},
+		{
+			name:    "unknown field",
+			file:    "rke2.plan",
+			content: `{"instruction": []}`,
+			errs:    []string{`unknown field "instruction"`},
+		},
+		{
+			name: "invalid files",
+			file: "rke2.plan",
+			content: `{"files": [
+				{"path": "etc/config.yaml"},
+				{"path": "c:\\a", "content": "not base64", "permissions": "rw"},
+				{"path": "c:\\A"},
+				{"path": "c:\\dir", "directory": true, "content": "aGVsbG8="}
+			]}`,
+			errs: []string{
+				"files[0]: path etc/config.yaml is not absolute",
+				"files[1]: content is not base64 encoded",
+				`files[1]: permissions "rw" are not octal`,
+				`files[2]: path c:\A is used more than once`,
+				"files[3]: a directory cannot have content",
+			},
+		},
+		{
+			name: "invalid instructions",
+			file: "rke2.plan",
+			content: `{
+				"instructions": [{"name": "a", "command": "a.exe"}, {"name": "a", "command": "b.exe"}, {"command": "c.exe"}],
+				"periodicInstructions": [{"name": "a", "periodSeconds": -1}],
+				"probes": {"kubelet": {}}
+			}`,
+			errs: []string{
+				"instructions[1]: name a is used more than once",
+				"periodicInstructions[0]: either image or command is required",
+				"periodicInstructions[0]: periodSeconds cannot be negative",
+				"probes[kubelet]: httpGet.url is required",
+			},
+			warnings: []string{"instructions[2]: instruction has no name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validate(writePlan(t, tt.file, tt.content))
+			if v.Valid != (len(tt.errs) == 0) {
+				t.Errorf("expected valid to be %t, got errors %v", len(tt.errs) == 0, v.Errors)
+			}
+			assertMessages(t, "errors", v.Errors, tt.errs)
+			assertMessages(t, "warnings", v.Warnings, tt.warnings)
+		})
+	}
+}
+
+func assertMessages(t *testing.T, kind string, actual, expected []string) {
+	t.Helper()
+	if len(actual) != len(expected) {
+		t.Errorf("expected %s %v, got %v", kind, expected, actual)
+		return
+	}
+	for i := range expected {
+		if !strings.Contains(actual[i], expected[i]) {
+			t.Errorf("expected %s %q to contain %q", kind, actual[i], expected[i])
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	path := writePlan(t, "rke2.plan", validPlan)
+	calculated, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := Describe(path, calculated)
+	expectedFiles := []FileChange{
+		{Path: `c:\etc\rancher\config.yaml`, Action: "write", Permissions: "0600", Size: 5},
+		{Path: `c:\var\lib\rancher`, Action: "create directory"},
+		{Path: `c:\etc\rancher\old.yaml`, Action: "delete"},
+	}
+	if !reflect.DeepEqual(d.Files, expectedFiles) {
+		t.Errorf("expected files %+v, got %+v", expectedFiles, d.Files)
+	}
+	if len(d.Instructions) != 3 {
+		t.Fatalf("expected 3 instructions, got %+v", d.Instructions)
+	}
+	if install := d.Instructions[0]; install.Type != "one-time" || install.Env[0] != "INSTALL_RKE2_VERSION=v1.28.2" || install.Args[1] != "run.ps1" {
+		t.Errorf("unexpected one-time instruction %+v", install)
+	}
+	if status := d.Instructions[1]; status.Type != "periodic" || status.PeriodSeconds != defaultPeriodSeconds {
+		t.Errorf("expected periodic instruction with the default period, got %+v", status)
+	}
+	if images := []string{"rancher/system-agent-installer-rke2:v1.28.2"}; !reflect.DeepEqual(d.Images, images) {
+		t.Errorf("expected images %v, got %v", images, d.Images)
+	}
+	if probes := []ProbeCheck{{Name: "kubelet", URL: "http://127.0.0.1:10248/healthz"}}; !reflect.DeepEqual(d.Probes, probes) {
+		t.Errorf("expected probes %v, got %v", probes, d.Probes)
+	}
+}
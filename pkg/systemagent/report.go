@@ -0,0 +1,340 @@
+package systemagent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/system-agent/pkg/applyinator"
+	"github.com/rancher/system-agent/pkg/config"
+	"github.com/rancher/wins/pkg/systemagent/connection"
+)
+
+const (
+	// appliedPlanSuffix and appliedPlanTimeLayout match the names of the plans the applyinator writes to AppliedPlanDir
+	appliedPlanSuffix     = "-applied.plan"
+	appliedPlanTimeLayout = "20060102-150405"
+
+	// planSecretTimeout bounds reading the outcome of the last plan from the plan secret
+	planSecretTimeout = 10 * time.Second
+)
+
+// Keys of the plan secret the system agent records the outcome of remote plans in
+const (
+	appliedChecksumKey       = "applied-checksum"
+	appliedOutputKey         = "applied-output"
+	appliedPeriodicOutputKey = "applied-periodic-output"
+	failedChecksumKey        = "failed-checksum"
+	failedOutputKey          = "failed-output"
+	failureCountKey          = "failure-count"
+)
+
+// Outcomes of an AppliedPlan and its instructions
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeUnknown   = "unknown"
+)
+
+// Report describes what the agent is watching and the last plan it applied
+type Report struct {
+	Enabled        bool
+	State          string
+	Error          string
+	RemoteWatching bool
+	LocalWatching  bool
+	LocalPlanDir   string
+	// Connection is only set if remote plans are enabled
+	Connection *Connection
+	// LastPlan is nil if no plan has been applied yet
+	LastPlan      *AppliedPlan
+	LastPlanError string
+}
+
+// Connection is the target the agent watches remote plans from
+type Connection struct {
+	Server     string
+	Namespace  string
+	SecretName string
+	Error      string
+}
+
+// AppliedPlan is a plan read from AppliedPlanDir along with its outcome, which the system agent only records in the
+// plan secret of remote plans
+type AppliedPlan struct {
+	File      string
+	Checksum  string
+	AppliedAt time.Time
+	// Attempts counts the consecutive files of the plan in AppliedPlanDir, the applyinator writes one whenever it applies the plan
+	Attempts int
+	// Outcome is the outcome of the one-time instructions, it is OutcomeUnknown if it has not been recorded,
+	// which OutcomeError explains
+	Outcome      string
+	OutcomeError string
+	// FailureCount counts the failed applications of the plan if it failed
+	FailureCount int
+	Instructions []Instruction
+}
+
+// Instruction is an instruction of an applied plan and its outcome
+type Instruction struct {
+	Name    string
+	Type    string
+	Image   string
+	Command string
+	Args    []string
+	// Outcome of a one-time instruction is only known if the plan succeeded, the system agent does not
+	// record which instruction failed
+	Outcome string
+	// Output is the output saved for the instruction, Stderr is only saved for periodic instructions
+	Output string
+	Stderr string
+	// ExitCode, Failures and the times of the last runs are only recorded for periodic instructions
+	ExitCode        int
+	Failures        int
+	LastSucceededAt string
+	LastFailedAt    string
+}
+
+// periodicOutput is what the applyinator records for a periodic instruction in the plan secret
+type periodicOutput struct {
+	Stdout                []byte `json:"stdout"`
+	Stderr                []byte `json:"stderr"`
+	ExitCode              int    `json:"exitCode"`
+	LastSuccessfulRunTime string `json:"lastSuccessfulRunTime"`
+	Failures              int    `json:"failures"`
+	LastFailedRunTime     string `json:"lastFailedRunTime"`
+}
+
+// Inspect reports the state of the agent, its remote connection and the last plan it applied
+func (a *Agent) Inspect() *Report {
+	state, err := a.Status()
+	cfg, strictTLSMode := a.config()
+	r := &Report{
+		Enabled: cfg != nil,
+		State:   state,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if cfg == nil {
+		return r
+	}
+
+	watching := state == StateWatching
+	r.RemoteWatching = watching && cfg.RemoteEnabled
+	r.LocalWatching = watching && cfg.LocalEnabled
+	if cfg.LocalEnabled {
+		r.LocalPlanDir = cfg.LocalPlanDir
+	}
+	if cfg.RemoteEnabled {
+		r.Connection = readConnection(cfg.ConnectionInfoFile)
+	}
+	if cfg.AppliedPlanDir != "" {
+		plan, err := readLastAppliedPlan(cfg.AppliedPlanDir)
+		if err != nil {
+			r.LastPlanError = err.Error()
+		}
+		if plan != nil {
+			a.readOutcome(cfg, strictTLSMode, plan)
+		}
+		r.LastPlan = plan
+	}
+	return r
+}
+
+// readOutcome sets the outcome of the plan and its instructions recorded in the plan secret
+func (a *Agent) readOutcome(cfg *config.AgentConfig, strictTLSMode bool, plan *AppliedPlan) {
+	plan.Outcome = OutcomeUnknown
+	for i := range plan.Instructions {
+		plan.Instructions[i].Outcome = OutcomeUnknown
+	}
+	if !cfg.RemoteEnabled {
+		plan.OutcomeError = "the system agent does not record the outcome of local plans"
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), planSecretTimeout)
+	defer cancel()
+	data, err := a.readPlanSecret(ctx, cfg.ConnectionInfoFile, strictTLSMode)
+	if err != nil {
+		plan.OutcomeError = err.Error()
+		return
+	}
+	if err := applyOutcome(plan, data); err != nil {
+		plan.OutcomeError = err.Error()
+	}
+}
+
+// applyOutcome sets the outcome of the plan and its instructions from the data of the plan secret
+func applyOutcome(plan *AppliedPlan, data map[string][]byte) error {
+	var outputKey string
+	switch plan.Checksum {
+	case string(data[appliedChecksumKey]):
+		plan.Outcome, outputKey = OutcomeSucceeded, appliedOutputKey
+	case string(data[failedChecksumKey]):
+		plan.Outcome, outputKey = OutcomeFailed, failedOutputKey
+		plan.FailureCount, _ = strconv.Atoi(string(data[failureCountKey]))
+	default:
+		msg := "the plan secret does not record the outcome of the plan"
+		if plan.Checksum == "" {
+			msg += ", the plan has no checksum"
+		}
+		return errors.New(msg + ", it may have been a local plan or not been reported yet")
+	}
+
+	var errs []string
+	outputs := map[string][]byte{}
+	if err := decodeOutput(data[outputKey], &outputs); err != nil {
+		errs = append(errs, errors.Wrapf(err, "could not decode %s", outputKey).Error())
+	}
+	periodicOutputs := map[string]periodicOutput{}
+	if err := decodeOutput(data[appliedPeriodicOutputKey], &periodicOutputs); err != nil {
+		errs = append(errs, errors.Wrapf(err, "could not decode %s", appliedPeriodicOutputKey).Error())
+	}
+
+	for i := range plan.Instructions {
+		instruction := &plan.Instructions[i]
+		if instruction.Type != "periodic" {
+			if plan.Outcome == OutcomeSucceeded {
+				instruction.Outcome = OutcomeSucceeded
+			}
+			instruction.Output = string(outputs[instruction.Name])
+			continue
+		}
+
+		output, ok := periodicOutputs[instruction.Name]
+		if !ok {
+			continue
+		}
+		instruction.Outcome = OutcomeSucceeded
+		if output.ExitCode != 0 {
+			instruction.Outcome = OutcomeFailed
+		}
+		instruction.Output, instruction.Stderr = string(output.Stdout), string(output.Stderr)
+		instruction.ExitCode, instruction.Failures = output.ExitCode, output.Failures
+		instruction.LastSucceededAt, instruction.LastFailedAt = output.LastSuccessfulRunTime, output.LastFailedRunTime
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// decodeOutput decodes the gzipped JSON the applyinator records its output as into v, it is left alone if bs is empty
+func decodeOutput(bs []byte, v interface{}) error {
+	if len(bs) == 0 {
+		return nil
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(bs))
+	if err != nil {
+		return err
+	}
+	js, err := io.ReadAll(gz)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+// readConnection reads the API server and the plan secret from the connection info file
+func readConnection(path string) *Connection {
+	info, err := connection.Read(path)
+	c := &Connection{}
+	if info != nil {
+		c.Server, c.Namespace, c.SecretName = info.Server, info.Namespace, info.SecretName
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	return c
+}
+
+// readLastAppliedPlan reads the most recent plan in dir, it returns nil if no plan has been applied yet
+func readLastAppliedPlan(dir string) (*AppliedPlan, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "could not read applied plan directory %s", dir)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), appliedPlanSuffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	// the names start with the time the plan was applied, newest last
+	sort.Strings(names)
+
+	var plan *AppliedPlan
+	for i := len(names) - 1; i >= 0; i-- {
+		calculated, err := readAppliedPlan(filepath.Join(dir, names[i]))
+		if err != nil {
+			if plan == nil {
+				return nil, err
+			}
+			break
+		}
+		if plan == nil {
+			plan = newAppliedPlan(names[i], calculated)
+		} else if calculated.Checksum != plan.Checksum {
+			break
+		}
+		plan.Attempts++
+	}
+	return plan, nil
+}
+
+func readAppliedPlan(path string) (*applyinator.CalculatedPlan, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read applied plan %s", path)
+	}
+	var plan applyinator.CalculatedPlan
+	if err := json.Unmarshal(bs, &plan); err != nil {
+		return nil, errors.Wrapf(err, "could not parse applied plan %s", path)
+	}
+	return &plan, nil
+}
+
+func newAppliedPlan(name string, calculated *applyinator.CalculatedPlan) *AppliedPlan {
+	plan := &AppliedPlan{
+		File:     name,
+		Checksum: calculated.Checksum,
+	}
+	if appliedAt, err := time.ParseInLocation(appliedPlanTimeLayout, strings.TrimSuffix(name, appliedPlanSuffix), time.UTC); err == nil {
+		plan.AppliedAt = appliedAt
+	}
+	for _, instruction := range calculated.Plan.OneTimeInstructions {
+		plan.Instructions = append(plan.Instructions, newInstruction("one-time", instruction.CommonInstruction))
+	}
+	for _, instruction := range calculated.Plan.PeriodicInstructions {
+		plan.Instructions = append(plan.Instructions, newInstruction("periodic", instruction.CommonInstruction))
+	}
+	return plan
+}
+
+func newInstruction(instructionType string, instruction applyinator.CommonInstruction) Instruction {
+	return Instruction{
+		Name:    instruction.Name,
+		Type:    instructionType,
+		Image:   instruction.Image,
+		Command: instruction.Command,
+		Args:    instruction.Args,
+	}
+}
@@ -0,0 +1,209 @@
+package systemagent
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/system-agent/pkg/applyinator"
+	"github.com/rancher/system-agent/pkg/config"
+)
+
+func writeJSON(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	bs, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bs, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// gzipJSON encodes v the way the applyinator records its output in the plan secret
+func gzipJSON(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	appliedPlanDir := filepath.Join(dir, "applied")
+	if err := os.Mkdir(appliedPlanDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	connectionInfoFile := filepath.Join(dir, "connection-info.json")
+	writeJSON(t, connectionInfoFile, config.ConnectionInfo{
+		KubeConfig: "apiVersion: v1\nclusters:\n- cluster:\n    server: https://rancher.example.com\n  name: local\n",
+		Namespace:  "fleet-default",
+		SecretName: "custom-plan",
+	})
+
+	install := applyinator.OneTimeInstruction{CommonInstruction: applyinator.CommonInstruction{Name: "install", Image: "rke2-runtime", Command: "install.ps1"}}
+	probe := applyinator.PeriodicInstruction{CommonInstruction: applyinator.CommonInstruction{Name: "probe", Command: "probe.ps1", Args: []string{"-v"}}}
+	writeJSON(t, filepath.Join(appliedPlanDir, "20240101-100000-applied.plan"), applyinator.CalculatedPlan{Checksum: "old"})
+	writeJSON(t, filepath.Join(appliedPlanDir, "20240102-100000-applied.plan"), applyinator.CalculatedPlan{Checksum: "new"})
+	writeJSON(t, filepath.Join(appliedPlanDir, "20240102-100500-applied.plan"), applyinator.CalculatedPlan{
+		Checksum: "new",
+		Plan: applyinator.Plan{
+			OneTimeInstructions:  []applyinator.OneTimeInstruction{install},
+			PeriodicInstructions: []applyinator.PeriodicInstruction{probe},
+		},
+	})
+
+	a := New(&config.AgentConfig{
+		RemoteEnabled:      true,
+		ConnectionInfoFile: connectionInfoFile,
+		AppliedPlanDir:     appliedPlanDir,
+	}, false)
+	a.state = StateWatching
+	a.readPlanSecret = func(_ context.Context, path string, _ bool) (map[string][]byte, error) {
+		if path != connectionInfoFile {
+			t.Errorf("expected plan secret to be read with %s, got %s", connectionInfoFile, path)
+		}
+		return map[string][]byte{
+			appliedChecksumKey:       []byte("new"),
+			appliedOutputKey:         gzipJSON(t, map[string][]byte{"install": []byte("installed")}),
+			appliedPeriodicOutputKey: gzipJSON(t, map[string]periodicOutput{"probe": {Stdout: []byte("ok"), Stderr: []byte("warning"), ExitCode: 1, Failures: 3}}),
+		}, nil
+	}
+
+	r := a.Inspect()
+	if !r.Enabled || !r.RemoteWatching || r.LocalWatching {
+		t.Errorf("expected remote watching only, got %+v", r)
+	}
+	if c := r.Connection; c == nil || c.Server != "https://rancher.example.com" || c.Namespace != "fleet-default" || c.SecretName != "custom-plan" || c.Error != "" {
+		t.Errorf("unexpected connection %+v", c)
+	}
+
+	plan := r.LastPlan
+	if plan == nil || r.LastPlanError != "" {
+		t.Fatalf("expected last plan, got error %q", r.LastPlanError)
+	}
+	if plan.Checksum != "new" || plan.Attempts != 2 || plan.File != "20240102-100500-applied.plan" {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+	if expected := time.Date(2024, 1, 2, 10, 5, 0, 0, time.UTC); !plan.AppliedAt.Equal(expected) {
+		t.Errorf("expected plan to be applied at %v, got %v", expected, plan.AppliedAt)
+	}
+	if len(plan.Instructions) != 2 || plan.Instructions[0].Type != "one-time" || plan.Instructions[0].Image != "rke2-runtime" ||
+		plan.Instructions[1].Type != "periodic" || plan.Instructions[1].Args[0] != "-v" {
+		t.Errorf("unexpected instructions %+v", plan.Instructions)
+	}
+	if plan.Outcome != OutcomeSucceeded || plan.OutcomeError != "" {
+		t.Errorf("expected plan to succeed, got %s: %s", plan.Outcome, plan.OutcomeError)
+	}
+	if install := plan.Instructions[0]; install.Outcome != OutcomeSucceeded || install.Output != "installed" {
+		t.Errorf("unexpected one-time instruction outcome %+v", install)
+	}
+	if probe := plan.Instructions[1]; probe.Outcome != OutcomeFailed || probe.ExitCode != 1 || probe.Failures != 3 || probe.Output != "ok" || probe.Stderr != "warning" {
+		t.Errorf("unexpected periodic instruction outcome %+v", probe)
+	}
+}
+
+func TestApplyOutcome(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            map[string][]byte
+		expectedOutcome string
+		expectedErr     string
+		// expectedInstruction is the outcome of the one-time instruction
+		expectedInstruction string
+		expectedOutput      string
+	}{
+		{
+			name:                "failed plan",
+			data:                map[string][]byte{failedChecksumKey: []byte("abc"), failureCountKey: []byte("2"), failedOutputKey: gzipJSON(t, map[string][]byte{"install": []byte("boom")})},
+			expectedOutcome:     OutcomeFailed,
+			expectedInstruction: OutcomeUnknown,
+			expectedOutput:      "boom",
+		},
+		{
+			name:                "outcome of another plan",
+			data:                map[string][]byte{appliedChecksumKey: []byte("def")},
+			expectedOutcome:     OutcomeUnknown,
+			expectedErr:         "does not record the outcome of the plan",
+			expectedInstruction: OutcomeUnknown,
+		},
+		{
+			name:                "malformed output",
+			data:                map[string][]byte{appliedChecksumKey: []byte("abc"), appliedOutputKey: []byte("not gzip")},
+			expectedOutcome:     OutcomeSucceeded,
+			expectedErr:         "could not decode applied-output",
+			expectedInstruction: OutcomeSucceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &AppliedPlan{Checksum: "abc", Outcome: OutcomeUnknown, Instructions: []Instruction{{Name: "install", Type: "one-time", Outcome: OutcomeUnknown}}}
+			err := applyOutcome(plan, tt.data)
+			if (tt.expectedErr == "" && err != nil) || (tt.expectedErr != "" && (err == nil || !strings.Contains(err.Error(), tt.expectedErr))) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedErr, err)
+			}
+			if plan.Outcome != tt.expectedOutcome {
+				t.Errorf("expected outcome %s, got %s", tt.expectedOutcome, plan.Outcome)
+			}
+			if tt.expectedOutcome == OutcomeFailed && plan.FailureCount != 2 {
+				t.Errorf("expected 2 failures, got %d", plan.FailureCount)
+			}
+			if instruction := plan.Instructions[0]; instruction.Outcome != tt.expectedInstruction || instruction.Output != tt.expectedOutput {
+				t.Errorf("expected instruction outcome %s with output %q, got %+v", tt.expectedInstruction, tt.expectedOutput, instruction)
+			}
+		})
+	}
+}
+
+func TestInspectOutcomeUnavailable(t *testing.T) {
+	appliedPlanDir := t.TempDir()
+	writeJSON(t, filepath.Join(appliedPlanDir, "20240102-100000-applied.plan"), applyinator.CalculatedPlan{Checksum: "abc"})
+
+	local := New(&config.AgentConfig{LocalEnabled: true, AppliedPlanDir: appliedPlanDir}, false)
+	if plan := local.Inspect().LastPlan; plan == nil || plan.Outcome != OutcomeUnknown || !strings.Contains(plan.OutcomeError, "local plans") {
+		t.Errorf("expected the outcome of a local plan to be unknown, got %+v", plan)
+	}
+
+	remote := New(&config.AgentConfig{RemoteEnabled: true, AppliedPlanDir: appliedPlanDir}, false)
+	remote.readPlanSecret = func(context.Context, string, bool) (map[string][]byte, error) {
+		return nil, errors.New("could not read plan secret fleet-default/plan: 403 Forbidden")
+	}
+	if plan := remote.Inspect().LastPlan; plan == nil || plan.Outcome != OutcomeUnknown || !strings.Contains(plan.OutcomeError, "403 Forbidden") {
+		t.Errorf("expected the outcome to be unknown if the plan secret cannot be read, got %+v", plan)
+	}
+}
+
+func TestInspectWithoutPlans(t *testing.T) {
+	a := New(&config.AgentConfig{
+		LocalEnabled:   true,
+		LocalPlanDir:   `c:\var\lib\rancher\agent\plans`,
+		AppliedPlanDir: filepath.Join(t.TempDir(), "missing"),
+	}, false)
+
+	r := a.Inspect()
+	if r.State != StateNotStarted || r.LocalWatching || r.LocalPlanDir == "" || r.Connection != nil {
+		t.Errorf("unexpected report %+v", r)
+	}
+	if r.LastPlan != nil || r.LastPlanError != "" {
+		t.Errorf("expected no plan, got %+v: %s", r.LastPlan, r.LastPlanError)
+	}
+
+	if r := New(nil, false).Inspect(); r.Enabled || r.State != StateDisabled {
+		t.Errorf("expected disabled agent, got %+v", r)
+	}
+}
@@ -0,0 +1,1279 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: managedservice.proto
+
+package types
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type ManagedServiceRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+}
+
+func (m *ManagedServiceRequest) Reset()         { *m = ManagedServiceRequest{} }
+func (m *ManagedServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*ManagedServiceRequest) ProtoMessage()    {}
+func (*ManagedServiceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41eb55da2a78f856, []int{0}
+}
+func (m *ManagedServiceRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ManagedServiceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ManagedServiceRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ManagedServiceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManagedServiceRequest.Merge(m, src)
+}
+func (m *ManagedServiceRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ManagedServiceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManagedServiceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManagedServiceRequest proto.InternalMessageInfo
+
+func (m *ManagedServiceRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type ManagedServiceListResponse struct {
+	Services []*ManagedServiceStatus `protobuf:"bytes,1,rep,name=Services,proto3" json:"Services,omitempty"`
+}
+
+func (m *ManagedServiceListResponse) Reset()         { *m = ManagedServiceListResponse{} }
+func (m *ManagedServiceListResponse) String() string { return proto.CompactTextString(m) }
+func (*ManagedServiceListResponse) ProtoMessage()    {}
+func (*ManagedServiceListResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41eb55da2a78f856, []int{1}
+}
+func (m *ManagedServiceListResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ManagedServiceListResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ManagedServiceListResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ManagedServiceListResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManagedServiceListResponse.Merge(m, src)
+}
+func (m *ManagedServiceListResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ManagedServiceListResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManagedServiceListResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManagedServiceListResponse proto.InternalMessageInfo
+
+func (m *ManagedServiceListResponse) GetServices() []*ManagedServiceStatus {
+	if m != nil {
+		return m.Services
+	}
+	return nil
+}
+
+type ManagedServiceStatusResponse struct {
+	Status *ManagedServiceStatus `protobuf:"bytes,1,opt,name=Status,proto3" json:"Status,omitempty"`
+}
+
+func (m *ManagedServiceStatusResponse) Reset()         { *m = ManagedServiceStatusResponse{} }
+func (m *ManagedServiceStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*ManagedServiceStatusResponse) ProtoMessage()    {}
+func (*ManagedServiceStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41eb55da2a78f856, []int{2}
+}
+func (m *ManagedServiceStatusResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ManagedServiceStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ManagedServiceStatusResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ManagedServiceStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManagedServiceStatusResponse.Merge(m, src)
+}
+func (m *ManagedServiceStatusResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ManagedServiceStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManagedServiceStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManagedServiceStatusResponse proto.InternalMessageInfo
+
+func (m *ManagedServiceStatusResponse) GetStatus() *ManagedServiceStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type ManagedServiceStatus struct {
+	Name        string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	DisplayName string `protobuf:"bytes,2,opt,name=DisplayName,proto3" json:"DisplayName,omitempty"`
+	BinaryPath  string `protobuf:"bytes,3,opt,name=BinaryPath,proto3" json:"BinaryPath,omitempty"`
+	StartType   string `protobuf:"bytes,4,opt,name=StartType,proto3" json:"StartType,omitempty"`
+	State       string `protobuf:"bytes,5,opt,name=State,proto3" json:"State,omitempty"`
+	Error       string `protobuf:"bytes,6,opt,name=Error,proto3" json:"Error,omitempty"`
+}
+
+func (m *ManagedServiceStatus) Reset()         { *m = ManagedServiceStatus{} }
+func (m *ManagedServiceStatus) String() string { return proto.CompactTextString(m) }
+func (*ManagedServiceStatus) ProtoMessage()    {}
+func (*ManagedServiceStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_41eb55da2a78f856, []int{3}
+}
+func (m *ManagedServiceStatus) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ManagedServiceStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ManagedServiceStatus.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ManagedServiceStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManagedServiceStatus.Merge(m, src)
+}
+func (m *ManagedServiceStatus) XXX_Size() int {
+	return m.Size()
+}
+func (m *ManagedServiceStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManagedServiceStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManagedServiceStatus proto.InternalMessageInfo
+
+func (m *ManagedServiceStatus) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ManagedServiceStatus) GetDisplayName() string {
+	if m != nil {
+		return m.DisplayName
+	}
+	return ""
+}
+
+func (m *ManagedServiceStatus) GetBinaryPath() string {
+	if m != nil {
+		return m.BinaryPath
+	}
+	return ""
+}
+
+func (m *ManagedServiceStatus) GetStartType() string {
+	if m != nil {
+		return m.StartType
+	}
+	return ""
+}
+
+func (m *ManagedServiceStatus) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *ManagedServiceStatus) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*ManagedServiceRequest)(nil), "wins.ManagedServiceRequest")
+	proto.RegisterType((*ManagedServiceListResponse)(nil), "wins.ManagedServiceListResponse")
+	proto.RegisterType((*ManagedServiceStatusResponse)(nil), "wins.ManagedServiceStatusResponse")
+	proto.RegisterType((*ManagedServiceStatus)(nil), "wins.ManagedServiceStatus")
+}
+
+func init() { proto.RegisterFile("managedservice.proto", fileDescriptor_41eb55da2a78f856) }
+
+var fileDescriptor_41eb55da2a78f856 = []byte{
+	// 347 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcb, 0x4a, 0xf3, 0x40,
+	0x14, 0x80, 0x33, 0x7f, 0xd3, 0xfe, 0xf6, 0xd4, 0xd5, 0x50, 0x21, 0xc4, 0x32, 0x86, 0x59, 0x15,
+	0x84, 0x2e, 0xaa, 0xf4, 0x01, 0x8a, 0xae, 0xbc, 0x20, 0x69, 0x71, 0xe1, 0x6e, 0x6c, 0x07, 0x0d,
+	0x98, 0x4c, 0x9c, 0x99, 0x2a, 0x79, 0x0b, 0x9f, 0xc6, 0x67, 0x70, 0x59, 0x5c, 0xb9, 0x94, 0xf6,
+	0x45, 0x24, 0x33, 0xa9, 0x4d, 0x25, 0x64, 0x95, 0xcc, 0x77, 0xbe, 0x9c, 0x39, 0x97, 0x40, 0x37,
+	0x66, 0x09, 0x7b, 0xe0, 0x73, 0xc5, 0xe5, 0x4b, 0x34, 0xe3, 0x83, 0x54, 0x0a, 0x2d, 0xb0, 0xfb,
+	0x1a, 0x25, 0xca, 0xdf, 0x9f, 0x89, 0x38, 0x16, 0x89, 0x65, 0xf4, 0x18, 0x0e, 0xae, 0xac, 0x3b,
+	0xb1, 0x6e, 0xc8, 0x9f, 0x17, 0x5c, 0x69, 0x8c, 0xc1, 0xbd, 0x66, 0x31, 0xf7, 0x50, 0x80, 0xfa,
+	0xed, 0xd0, 0xbc, 0xd3, 0x29, 0xf8, 0xbb, 0xf2, 0x65, 0xa4, 0x74, 0xc8, 0x55, 0x2a, 0x12, 0xc5,
+	0xf1, 0x08, 0xf6, 0x0a, 0xac, 0x3c, 0x14, 0x34, 0xfa, 0x9d, 0xa1, 0x3f, 0xc8, 0x6f, 0x1c, 0xec,
+	0x7e, 0x33, 0xd1, 0x4c, 0x2f, 0x54, 0xf8, 0xeb, 0xd2, 0x10, 0x7a, 0x95, 0xc6, 0x26, 0xef, 0x10,
+	0x5a, 0x96, 0x98, 0x5a, 0xea, 0xb3, 0x16, 0x26, 0x7d, 0x47, 0xd0, 0xad, 0x12, 0xaa, 0xda, 0xc2,
+	0x01, 0x74, 0xce, 0x22, 0x95, 0x3e, 0xb1, 0xcc, 0x84, 0xfe, 0x99, 0x50, 0x19, 0x61, 0x02, 0x30,
+	0x8e, 0x12, 0x26, 0xb3, 0x1b, 0xa6, 0x1f, 0xbd, 0x86, 0x11, 0x4a, 0x04, 0xf7, 0xa0, 0x3d, 0xd1,
+	0x4c, 0xea, 0x69, 0x96, 0x72, 0xcf, 0x35, 0xe1, 0x2d, 0xc0, 0x5d, 0x68, 0xe6, 0xb7, 0x73, 0xaf,
+	0x69, 0x22, 0xf6, 0x90, 0xd3, 0x73, 0x29, 0x85, 0xf4, 0x5a, 0x96, 0x9a, 0xc3, 0xf0, 0x13, 0xfd,
+	0x5d, 0x48, 0xf1, 0xc0, 0x23, 0x70, 0xf3, 0x71, 0x63, 0xb0, 0xed, 0xdf, 0x8a, 0x68, 0xee, 0x07,
+	0x55, 0xa3, 0x28, 0x2f, 0x85, 0x3a, 0xf8, 0x62, 0x33, 0x3e, 0x7c, 0x58, 0x65, 0x17, 0xfb, 0xf6,
+	0x69, 0xcd, 0x54, 0xb7, 0xc9, 0x4e, 0xe1, 0x7f, 0xc8, 0x55, 0xde, 0x59, 0x7d, 0xb6, 0x52, 0x91,
+	0xd4, 0x19, 0x1f, 0x7d, 0xac, 0x08, 0x5a, 0xae, 0x08, 0xfa, 0x5e, 0x11, 0xf4, 0xb6, 0x26, 0xce,
+	0x72, 0x4d, 0x9c, 0xaf, 0x35, 0x71, 0xee, 0x9a, 0x3a, 0x4b, 0xb9, 0xba, 0x6f, 0x99, 0x9f, 0xf1,
+	0xe4, 0x67, 0x00, 0xb6, 0xef, 0x30, 0x9f, 0xb8, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ManagedServiceServiceClient is the client API for ManagedServiceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ManagedServiceServiceClient interface {
+	List(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ManagedServiceListResponse, error)
+	Status(ctx context.Context, in *ManagedServiceRequest, opts ...grpc.CallOption) (*ManagedServiceStatusResponse, error)
+	Restart(ctx context.Context, in *ManagedServiceRequest, opts ...grpc.CallOption) (*Void, error)
+}
+
+type managedServiceServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewManagedServiceServiceClient(cc *grpc.ClientConn) ManagedServiceServiceClient {
+	return &managedServiceServiceClient{cc}
+}
+
+func (c *managedServiceServiceClient) List(ctx context.Context, in *Void, opts ...grpc.CallOption) (*ManagedServiceListResponse, error) {
+	out := new(ManagedServiceListResponse)
+	err := c.cc.Invoke(ctx, "/wins.ManagedServiceService/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managedServiceServiceClient) Status(ctx context.Context, in *ManagedServiceRequest, opts ...grpc.CallOption) (*ManagedServiceStatusResponse, error) {
+	out := new(ManagedServiceStatusResponse)
+	err := c.cc.Invoke(ctx, "/wins.ManagedServiceService/Status", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managedServiceServiceClient) Restart(ctx context.Context, in *ManagedServiceRequest, opts ...grpc.CallOption) (*Void, error) {
+	out := new(Void)
+	err := c.cc.Invoke(ctx, "/wins.ManagedServiceService/Restart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ManagedServiceServiceServer is the server API for ManagedServiceService service.
+type ManagedServiceServiceServer interface {
+	List(context.Context, *Void) (*ManagedServiceListResponse, error)
+	Status(context.Context, *ManagedServiceRequest) (*ManagedServiceStatusResponse, error)
+	Restart(context.Context, *ManagedServiceRequest) (*Void, error)
+}
+
+// UnimplementedManagedServiceServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedManagedServiceServiceServer struct {
+}
+
+func (*UnimplementedManagedServiceServiceServer) List(ctx context.Context, req *Void) (*ManagedServiceListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (*UnimplementedManagedServiceServiceServer) Status(ctx context.Context, req *ManagedServiceRequest) (*ManagedServiceStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (*UnimplementedManagedServiceServiceServer) Restart(ctx context.Context, req *ManagedServiceRequest) (*Void, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Restart not implemented")
+}
+
+func RegisterManagedServiceServiceServer(s *grpc.Server, srv ManagedServiceServiceServer) {
+	s.RegisterService(&_ManagedServiceService_serviceDesc, srv)
+}
+
+func _ManagedServiceService_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Void)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagedServiceServiceServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wins.ManagedServiceService/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagedServiceServiceServer).List(ctx, req.(*Void))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagedServiceService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManagedServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagedServiceServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wins.ManagedServiceService/Status",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagedServiceServiceServer).Status(ctx, req.(*ManagedServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagedServiceService_Restart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManagedServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagedServiceServiceServer).Restart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/wins.ManagedServiceService/Restart",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagedServiceServiceServer).Restart(ctx, req.(*ManagedServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ManagedServiceService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "wins.ManagedServiceService",
+	HandlerType: (*ManagedServiceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _ManagedServiceService_List_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _ManagedServiceService_Status_Handler,
+		},
+		{
+			MethodName: "Restart",
+			Handler:    _ManagedServiceService_Restart_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "managedservice.proto",
+}
+
+func (m *ManagedServiceRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ManagedServiceRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ManagedServiceRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ManagedServiceListResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ManagedServiceListResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ManagedServiceListResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Services) > 0 {
+		for iNdEx := len(m.Services) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Services[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintManagedservice(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ManagedServiceStatusResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ManagedServiceStatusResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ManagedServiceStatusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Status != nil {
+		{
+			size, err := m.Status.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintManagedservice(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ManagedServiceStatus) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ManagedServiceStatus) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ManagedServiceStatus) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Error) > 0 {
+		i -= len(m.Error)
+		copy(dAtA[i:], m.Error)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.Error)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.State) > 0 {
+		i -= len(m.State)
+		copy(dAtA[i:], m.State)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.State)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.StartType) > 0 {
+		i -= len(m.StartType)
+		copy(dAtA[i:], m.StartType)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.StartType)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.BinaryPath) > 0 {
+		i -= len(m.BinaryPath)
+		copy(dAtA[i:], m.BinaryPath)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.BinaryPath)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.DisplayName) > 0 {
+		i -= len(m.DisplayName)
+		copy(dAtA[i:], m.DisplayName)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.DisplayName)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintManagedservice(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintManagedservice(dAtA []byte, offset int, v uint64) int {
+	offset -= sovManagedservice(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ManagedServiceRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	return n
+}
+
+func (m *ManagedServiceListResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Services) > 0 {
+		for _, e := range m.Services {
+			l = e.Size()
+			n += 1 + l + sovManagedservice(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ManagedServiceStatusResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Status != nil {
+		l = m.Status.Size()
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	return n
+}
+
+func (m *ManagedServiceStatus) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	l = len(m.DisplayName)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	l = len(m.BinaryPath)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	l = len(m.StartType)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	l = len(m.State)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	l = len(m.Error)
+	if l > 0 {
+		n += 1 + l + sovManagedservice(uint64(l))
+	}
+	return n
+}
+
+func sovManagedservice(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozManagedservice(x uint64) (n int) {
+	return sovManagedservice(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ManagedServiceRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowManagedservice
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ManagedServiceRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ManagedServiceRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipManagedservice(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ManagedServiceListResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowManagedservice
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ManagedServiceListResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ManagedServiceListResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Services", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Services = append(m.Services, &ManagedServiceStatus{})
+			if err := m.Services[len(m.Services)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipManagedservice(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ManagedServiceStatusResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowManagedservice
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ManagedServiceStatusResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ManagedServiceStatusResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Status == nil {
+				m.Status = &ManagedServiceStatus{}
+			}
+			if err := m.Status.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipManagedservice(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ManagedServiceStatus) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowManagedservice
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ManagedServiceStatus: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ManagedServiceStatus: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DisplayName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DisplayName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BinaryPath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BinaryPath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StartType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.State = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Error = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipManagedservice(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthManagedservice
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipManagedservice(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowManagedservice
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowManagedservice
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthManagedservice
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupManagedservice
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthManagedservice
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthManagedservice        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowManagedservice          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupManagedservice = fmt.Errorf("proto: unexpected end of group")
+)
@@ -0,0 +1,161 @@
+package winservice
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type fakeService struct {
+	cfg   Config
+	state State
+}
+
+// Fake is an in-memory Controller for tests. Services change their state immediately, a started service is
+// stopped right away if FailStart returns true for it.
+type Fake struct {
+	// FailStart decides whether a service fails right after it is started
+	FailStart func(name string, cfg *Config) bool
+
+	mu       sync.Mutex
+	services map[string]*fakeService
+	calls    []string
+}
+
+var _ Controller = &Fake{}
+
+// NewFake creates a Fake without any services
+func NewFake() *Fake {
+	return &Fake{services: map[string]*fakeService{}}
+}
+
+// Add adds a service in the given state without recording a call
+func (f *Fake) Add(name string, cfg *Config, state State) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.services[name] = &fakeService{cfg: copyConfig(cfg), state: state}
+}
+
+// SetState changes the state of a service without recording a call
+func (f *Fake) SetState(name string, state State) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.services[name].state = state
+}
+
+// TakeCalls returns the calls changing services since the last TakeCalls, formatted as "<method> <name>"
+func (f *Fake) TakeCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func (f *Fake) Exists(name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.services[name] != nil, nil
+}
+
+func (f *Fake) Create(name string, cfg *Config) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "create "+name)
+	if f.services[name] != nil {
+		return errors.Errorf("service %s already exists", name)
+	}
+	f.services[name] = &fakeService{cfg: copyConfig(cfg), state: Stopped}
+	return nil
+}
+
+func (f *Fake) Config(name string) (*Config, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, err := f.service(name)
+	if err != nil {
+		return nil, err
+	}
+	cfg := copyConfig(&s.cfg)
+	return &cfg, nil
+}
+
+func (f *Fake) Update(name string, cfg *Config) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "update "+name)
+	s, err := f.service(name)
+	if err != nil {
+		return err
+	}
+	s.cfg = copyConfig(cfg)
+	return nil
+}
+
+func (f *Fake) Delete(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "delete "+name)
+	if _, err := f.service(name); err != nil {
+		return err
+	}
+	delete(f.services, name)
+	return nil
+}
+
+func (f *Fake) Start(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "start "+name)
+	s, err := f.service(name)
+	if err != nil {
+		return err
+	}
+	if s.cfg.StartType == StartDisabled {
+		return errors.Errorf("service %s is disabled", name)
+	}
+	s.state = Running
+	if f.FailStart != nil && f.FailStart(name, &s.cfg) {
+		s.state = Stopped
+	}
+	return nil
+}
+
+func (f *Fake) Stop(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "stop "+name)
+	s, err := f.service(name)
+	if err != nil {
+		return err
+	}
+	s.state = Stopped
+	return nil
+}
+
+func (f *Fake) State(name string) (State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, err := f.service(name)
+	if err != nil {
+		return 0, err
+	}
+	return s.state, nil
+}
+
+func (f *Fake) service(name string) (*fakeService, error) {
+	s := f.services[name]
+	if s == nil {
+		return nil, errors.Errorf("service %s does not exist", name)
+	}
+	return s, nil
+}
+
+func copyConfig(cfg *Config) Config {
+	c := *cfg
+	c.Args = append([]string(nil), cfg.Args...)
+	c.Dependencies = append([]string(nil), cfg.Dependencies...)
+	c.RecoveryActions = append([]RecoveryAction(nil), cfg.RecoveryActions...)
+	c.EnvVars = append([]string(nil), cfg.EnvVars...)
+	return c
+}
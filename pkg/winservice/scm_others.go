@@ -0,0 +1,23 @@
+//go:build !windows
+
+package winservice
+
+import "github.com/pkg/errors"
+
+// New returns a Controller failing every call, services can only be controlled on Windows
+func New() Controller {
+	return unsupported{}
+}
+
+type unsupported struct{}
+
+var errUnsupported = errors.New("services can only be controlled on Windows")
+
+func (unsupported) Exists(string) (bool, error)    { return false, errUnsupported }
+func (unsupported) Create(string, *Config) error   { return errUnsupported }
+func (unsupported) Config(string) (*Config, error) { return nil, errUnsupported }
+func (unsupported) Update(string, *Config) error   { return errUnsupported }
+func (unsupported) Delete(string) error            { return errUnsupported }
+func (unsupported) Start(string) error             { return errUnsupported }
+func (unsupported) Stop(string) error              { return errUnsupported }
+func (unsupported) State(string) (State, error)    { return 0, errUnsupported }
@@ -0,0 +1,296 @@
+package winservice
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// SCM controls Windows services through the service control manager
+type SCM struct{}
+
+var _ Controller = SCM{}
+
+// New returns the Controller of the host
+func New() Controller {
+	return SCM{}
+}
+
+// Exists returns whether the service exists.
+func (SCM) Exists(name string) (bool, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return false, errors.Wrap(err, "could not open SCM")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "could not open service %s", name)
+	}
+	s.Close()
+	return true, nil
+}
+
+// Create creates the service, without starting it.
+func (SCM) Create(name string, cfg *Config) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "could not open SCM")
+	}
+	defer m.Disconnect()
+
+	s, err := m.CreateService(name, cfg.BinaryPath, mgr.Config{
+		ServiceType:      windows.SERVICE_WIN32_OWN_PROCESS,
+		StartType:        uint32(cfg.startType()),
+		DelayedAutoStart: cfg.DelayedAutoStart,
+		ErrorControl:     mgr.ErrorNormal,
+		DisplayName:      cfg.DisplayName,
+		Description:      cfg.Description,
+		Dependencies:     cfg.Dependencies,
+	}, cfg.Args...)
+	if err != nil {
+		return errors.Wrapf(err, "could not create service %s", name)
+	}
+	defer s.Close()
+
+	if len(cfg.RecoveryActions) != 0 {
+		if err := s.SetRecoveryActions(toMgrRecoveryActions(cfg.RecoveryActions), uint32(cfg.RecoveryResetPeriod/time.Second)); err != nil {
+			return errors.Wrapf(err, "could not set recovery actions of service %s", name)
+		}
+	}
+	if len(cfg.EnvVars) != 0 {
+		return setEnvVars(name, cfg.EnvVars)
+	}
+	return nil
+}
+
+// Config returns the live configuration of the service.
+func (SCM) Config(name string) (*Config, error) {
+	s, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	config, err := s.Config()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not query config of service %s", name)
+	}
+	cmdline, err := windows.DecomposeCommandLine(config.BinaryPathName)
+	if err != nil || len(cmdline) == 0 {
+		return nil, errors.Errorf("could not parse command line %q of service %s: %v", config.BinaryPathName, name, err)
+	}
+	cfg := &Config{
+		BinaryPath:       cmdline[0],
+		Args:             cmdline[1:],
+		DisplayName:      config.DisplayName,
+		Description:      config.Description,
+		StartType:        StartType(config.StartType),
+		DelayedAutoStart: config.DelayedAutoStart,
+		Dependencies:     config.Dependencies,
+	}
+
+	recoveryActions, err := s.RecoveryActions()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not query recovery actions of service %s", name)
+	}
+	for _, action := range recoveryActions {
+		cfg.RecoveryActions = append(cfg.RecoveryActions, RecoveryAction{Type: RecoveryType(action.Type), Delay: action.Delay})
+	}
+	resetPeriod, err := s.ResetPeriod()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not query recovery reset period of service %s", name)
+	}
+	cfg.RecoveryResetPeriod = time.Duration(resetPeriod) * time.Second
+
+	if cfg.EnvVars, err = envVars(name); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Update changes the settings of the service that differ from cfg, leaving the others untouched.
+func (scm SCM) Update(name string, cfg *Config) error {
+	live, err := scm.Config(name)
+	if err != nil {
+		return err
+	}
+	changed := Diff(live, cfg)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if contains(changed, "binary path", "args", "description", "start type", "dependencies") {
+		config, err := s.Config()
+		if err != nil {
+			return errors.Wrapf(err, "could not query config of service %s", name)
+		}
+		if contains(changed, "binary path", "args") {
+			config.BinaryPathName = windows.ComposeCommandLine(append([]string{cfg.BinaryPath}, cfg.Args...))
+		}
+		config.DisplayName = cfg.DisplayName
+		config.Description = cfg.Description
+		config.StartType = uint32(cfg.startType())
+		config.DelayedAutoStart = cfg.DelayedAutoStart
+		config.Dependencies = cfg.Dependencies
+		if len(cfg.Dependencies) == 0 && len(live.Dependencies) != 0 {
+			// an empty list leaves the dependencies untouched, '/' clears them
+			config.Dependencies = []string{"/"}
+		}
+		if err := s.UpdateConfig(config); err != nil {
+			return errors.Wrapf(err, "could not update config of service %s", name)
+		}
+	}
+
+	if contains(changed, "recovery actions") {
+		if len(cfg.RecoveryActions) == 0 {
+			err = s.ResetRecoveryActions()
+		} else {
+			err = s.SetRecoveryActions(toMgrRecoveryActions(cfg.RecoveryActions), uint32(cfg.RecoveryResetPeriod/time.Second))
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not update recovery actions of service %s", name)
+		}
+	}
+
+	if contains(changed, "environment variables") {
+		if len(cfg.EnvVars) == 0 {
+			return clearEnvVars(name)
+		}
+		return setEnvVars(name, cfg.EnvVars)
+	}
+	return nil
+}
+
+// Delete marks the service for deletion.
+func (SCM) Delete(name string) error {
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return errors.Wrapf(err, "could not delete service %s", name)
+	}
+	return nil
+}
+
+// Start requests the service to start.
+func (SCM) Start(name string) error {
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Start()
+}
+
+// Stop requests the service to stop.
+func (SCM) Stop(name string) error {
+	s, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	_, err = s.Control(svc.Stop)
+	return err
+}
+
+// State returns the state of the service.
+func (SCM) State(name string) (State, error) {
+	s, err := open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	status, err := s.Query()
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not query service %s", name)
+	}
+	return State(status.State), nil
+}
+
+func open(name string) (*mgr.Service, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open SCM")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not open service %s", name)
+	}
+	return s, nil
+}
+
+func toMgrRecoveryActions(actions []RecoveryAction) []mgr.RecoveryAction {
+	var recoveryActions []mgr.RecoveryAction
+	for _, action := range actions {
+		recoveryActions = append(recoveryActions, mgr.RecoveryAction{Type: int(action.Type), Delay: action.Delay})
+	}
+	return recoveryActions
+}
+
+func registryKey(name string) string {
+	return fmt.Sprintf(`SYSTEM\CurrentControlSet\Services\%s`, name)
+}
+
+// envVars returns the environment variables of the service from its registry key.
+func envVars(name string) ([]string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, registryKey(name), registry.QUERY_VALUE)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening registry key")
+	}
+	defer k.Close()
+
+	envVars, _, err := k.GetStringsValue("Environment")
+	if err != nil && err != registry.ErrNotExist {
+		return nil, errors.Wrap(err, "error reading environment variables")
+	}
+	return envVars, nil
+}
+
+// setEnvVars sets the environment variables of the service in its registry key.
+func setEnvVars(name string, envVars []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, registryKey(name), registry.WRITE)
+	if err != nil {
+		return errors.Wrap(err, "error opening registry key")
+	}
+	defer k.Close()
+
+	return k.SetStringsValue("Environment", envVars)
+}
+
+// clearEnvVars removes the environment variables of the service from its registry key.
+func clearEnvVars(name string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, registryKey(name), registry.SET_VALUE)
+	if err != nil {
+		return errors.Wrap(err, "error opening registry key")
+	}
+	defer k.Close()
+
+	if err := k.DeleteValue("Environment"); err != nil && err != registry.ErrNotExist {
+		return errors.Wrap(err, "error deleting environment variables")
+	}
+	return nil
+}
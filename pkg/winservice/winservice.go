@@ -0,0 +1,235 @@
+package winservice
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pollInterval is the interval the state of a service is polled at while waiting for it to change
+var pollInterval = time.Second
+
+// State is the state of a service, the values match the SERVICE_* states of the SCM
+type State uint32
+
+// States of a service
+const (
+	Stopped         State = 1
+	StartPending    State = 2
+	StopPending     State = 3
+	Running         State = 4
+	ContinuePending State = 5
+	PausePending    State = 6
+	Paused          State = 7
+)
+
+func (s State) String() string {
+	switch s {
+	case Stopped:
+		return "Stopped"
+	case StartPending:
+		return "Start Pending"
+	case StopPending:
+		return "Stop Pending"
+	case Running:
+		return "Running"
+	case ContinuePending:
+		return "Continue Pending"
+	case PausePending:
+		return "Pause Pending"
+	case Paused:
+		return "Paused"
+	default:
+		return "Unknown State"
+	}
+}
+
+// StartType is the start type of a service, the values match the SERVICE_* start types of the SCM
+type StartType uint32
+
+// Start types of a service
+const (
+	StartAutomatic StartType = 2
+	StartManual    StartType = 3
+	StartDisabled  StartType = 4
+)
+
+// RecoveryType is the type of action taken by the SCM when a service fails
+type RecoveryType int
+
+// Recovery action types
+const (
+	RecoveryNone    RecoveryType = 0
+	RecoveryRestart RecoveryType = 1
+)
+
+// RecoveryAction is taken by the SCM when a service fails
+type RecoveryAction struct {
+	Type  RecoveryType
+	Delay time.Duration
+}
+
+// Config is the configuration of a service
+type Config struct {
+	BinaryPath  string
+	Args        []string
+	DisplayName string
+	Description string
+	// StartType defaults to StartAutomatic
+	StartType        StartType
+	DelayedAutoStart bool
+	// Dependencies are the services that have to be started before the service
+	Dependencies []string
+	// RecoveryActions are taken in order on subsequent failures of the service, the failure count
+	// is reset once the service did not fail for RecoveryResetPeriod
+	RecoveryActions     []RecoveryAction
+	RecoveryResetPeriod time.Duration
+	// EnvVars are formatted as NAME=value
+	EnvVars []string
+}
+
+// Controller controls Windows services by name. Start and Stop only request the state change,
+// use the Start, Stop and Restart functions to wait for the service to reach the state.
+type Controller interface {
+	Exists(name string) (bool, error)
+	Create(name string, cfg *Config) error
+	// Config returns the live configuration of the service
+	Config(name string) (*Config, error)
+	// Update changes the settings of the service that differ from cfg, the service has to be
+	// restarted to pick up the changes
+	Update(name string, cfg *Config) error
+	Delete(name string) error
+	Start(name string) error
+	Stop(name string) error
+	State(name string) (State, error)
+}
+
+// WaitForState polls the state of the service until it reaches the desired state, failing once timeout passed.
+func WaitForState(ctl Controller, name string, desired State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		state, err := ctl.State(name)
+		if err != nil {
+			return err
+		}
+		if state == desired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("service %s did not reach state %s within %v, state is %s", name, desired, timeout, state)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Start starts the service if it is not running already and waits for it to be running within timeout.
+func Start(ctl Controller, name string, timeout time.Duration) error {
+	state, err := ctl.State(name)
+	if err != nil {
+		return err
+	}
+	if state == Running {
+		return nil
+	}
+	if state != StartPending {
+		if err := ctl.Start(name); err != nil {
+			return errors.Wrapf(err, "could not start service %s", name)
+		}
+	}
+	return WaitForState(ctl, name, Running, timeout)
+}
+
+// Stop stops the service if it is not stopped already and waits for it to be stopped within timeout.
+func Stop(ctl Controller, name string, timeout time.Duration) error {
+	state, err := ctl.State(name)
+	if err != nil {
+		return err
+	}
+	if state == Stopped {
+		return nil
+	}
+	if state != StopPending {
+		if err := ctl.Stop(name); err != nil {
+			return errors.Wrapf(err, "could not stop service %s", name)
+		}
+	}
+	return WaitForState(ctl, name, Stopped, timeout)
+}
+
+// Restart stops the service if it is running and starts it again, waiting for each within timeout.
+func Restart(ctl Controller, name string, timeout time.Duration) error {
+	if err := Stop(ctl, name, timeout); err != nil {
+		return err
+	}
+	return Start(ctl, name, timeout)
+}
+
+// Diff returns the settings that differ between the live and the desired configuration of a service.
+func Diff(live, desired *Config) []string {
+	var changed []string
+	if live.BinaryPath != desired.BinaryPath {
+		changed = append(changed, "binary path")
+	}
+	if !equalStrings(live.Args, desired.Args) {
+		changed = append(changed, "args")
+	}
+	if live.DisplayName != desired.DisplayName || live.Description != desired.Description {
+		changed = append(changed, "description")
+	}
+	if live.startType() != desired.startType() || live.DelayedAutoStart != desired.DelayedAutoStart {
+		changed = append(changed, "start type")
+	}
+	if !equalStrings(live.Dependencies, desired.Dependencies) {
+		changed = append(changed, "dependencies")
+	}
+	if !equalRecoveryActions(live.RecoveryActions, desired.RecoveryActions) || live.RecoveryResetPeriod != desired.RecoveryResetPeriod {
+		changed = append(changed, "recovery actions")
+	}
+	if !equalStrings(live.EnvVars, desired.EnvVars) {
+		changed = append(changed, "environment variables")
+	}
+	return changed
+}
+
+func (c *Config) startType() StartType {
+	if c.StartType == 0 {
+		return StartAutomatic
+	}
+	return c.StartType
+}
+
+// equalStrings treats nil and empty slices as equal
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalRecoveryActions(a, b []RecoveryAction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, values ...string) bool {
+	for _, e := range list {
+		for _, v := range values {
+			if e == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package winservice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	live := &Config{
+		BinaryPath:      `c:\bin\agent.exe`,
+		Args:            []string{"--debug"},
+		DisplayName:     "Agent",
+		RecoveryActions: []RecoveryAction{{Type: RecoveryRestart, Delay: 10 * time.Second}},
+		EnvVars:         []string{"A=1"},
+	}
+
+	tests := []struct {
+		name     string
+		update   func(cfg *Config)
+		expected []string
+	}{
+		{
+			name:   "unchanged",
+			update: func(cfg *Config) {},
+		},
+		{
+			name:   "default start type",
+			update: func(cfg *Config) { cfg.StartType = StartAutomatic },
+		},
+		{
+			name: "command line",
+			update: func(cfg *Config) {
+				cfg.BinaryPath = `c:\bin\agent2.exe`
+				cfg.Args = nil
+			},
+			expected: []string{"binary path", "args"},
+		},
+		{
+			name:     "delayed start",
+			update:   func(cfg *Config) { cfg.DelayedAutoStart = true },
+			expected: []string{"start type"},
+		},
+		{
+			name:     "dependencies",
+			update:   func(cfg *Config) { cfg.Dependencies = []string{"rancher-wins"} },
+			expected: []string{"dependencies"},
+		},
+		{
+			name: "recovery actions",
+			update: func(cfg *Config) {
+				cfg.RecoveryActions = []RecoveryAction{{Type: RecoveryRestart, Delay: 5 * time.Second}}
+			},
+			expected: []string{"recovery actions"},
+		},
+		{
+			name:     "environment variables",
+			update:   func(cfg *Config) { cfg.EnvVars = append(cfg.EnvVars, "B=2") },
+			expected: []string{"environment variables"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := copyConfig(live)
+			tt.update(&desired)
+			if changed := Diff(live, &desired); !reflect.DeepEqual(changed, tt.expected) {
+				t.Errorf("expected changes %v, got %v", tt.expected, changed)
+			}
+		})
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	pollInterval = time.Millisecond
+	ctl := NewFake()
+	ctl.Add("agent", &Config{BinaryPath: `c:\bin\agent.exe`}, Stopped)
+
+	if err := Start(ctl, "agent", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// a running service is not started again
+	if err := Start(ctl, "agent", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if calls := ctl.TakeCalls(); !reflect.DeepEqual(calls, []string{"start agent"}) {
+		t.Errorf("expected a single start, got %v", calls)
+	}
+
+	if err := Restart(ctl, "agent", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if calls := ctl.TakeCalls(); !reflect.DeepEqual(calls, []string{"stop agent", "start agent"}) {
+		t.Errorf("expected the service to be stopped and started, got %v", calls)
+	}
+
+	if err := Stop(ctl, "agent", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := ctl.State("agent"); state != Stopped {
+		t.Errorf("expected service to be stopped, got %s", state)
+	}
+
+	// a service failing right after it was started never reaches the running state
+	ctl.FailStart = func(string, *Config) bool { return true }
+	if err := Start(ctl, "agent", 10*time.Millisecond); err == nil || !strings.Contains(err.Error(), "did not reach state Running") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+
+	if err := Start(ctl, "missing", time.Second); err == nil {
+		t.Error("expected error starting a missing service")
+	}
+}
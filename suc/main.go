@@ -31,6 +31,9 @@ func main() {
 		}
 		if c.Bool("quiet") {
 			logrus.SetOutput(io.Discard)
+		} else if !c.Bool("dry-run") || c.String("output") == pkg.OutputJSON {
+			// keep the result of the run and the report of the dry run parsable
+			logrus.SetOutput(c.App.ErrWriter)
 		} else {
 			logrus.SetOutput(c.App.Writer)
 		}
@@ -48,6 +51,15 @@ func main() {
 			Name:  "quiet",
 			Usage: "Turn off all logging",
 		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print the changes that would be made to the host without making them",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "[optional] Specifies the output format of the dry run, either 'table' or 'json'",
+			Value: pkg.OutputTable,
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil && err != io.EOF {
@@ -3,8 +3,10 @@
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 
 	"github.com/rancher/wins/suc/pkg/host"
+	"github.com/rancher/wins/suc/pkg/lock"
 	"github.com/rancher/wins/suc/pkg/rancher"
 	"github.com/rancher/wins/suc/pkg/service"
 	"github.com/rancher/wins/suc/pkg/service/config"
@@ -13,65 +15,220 @@
 	"github.com/urfave/cli/v2"
 )
 
-func Run(_ *cli.Context) error {
-	var errs []error
-	initialState, err := state.BuildInitialState()
+// Run updates rancher-wins on the host, restoring the initial state if any step fails. The host lock shared
+// with install.ps1 is held for the whole run, so that they never change the host at the same time. Unless it is a dry run,
+// the Result is printed once the run finished and written to the ResultFileName in the rancher-wins config directory.
+// It is the only output printed to the Writer of the app, the logs are written to its ErrWriter.
+func Run(cliCtx *cli.Context) error {
+	if cliCtx.Bool("dry-run") {
+		return DryRun(cliCtx.App.Writer, cliCtx.String("output"))
+	}
+
+	result := newResult()
+	var hostLock *lock.Lock
+	err := result.step("acquire host lock", func() (err error) {
+		hostLock, err = lock.Acquire(filepath.Join(host.GetWinsConfigDir(), lock.FileName), cliCtx.App.Name)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("could not build initial state for rancher-wins: %w", err)
+		// another SUC or install.ps1 is changing the host, the run is retried by the controller
+		err = fmt.Errorf("could not acquire host lock: %w", err)
+	} else {
+		err = run(result)
 	}
 
-	logrus.Info("Updating rancher connection info")
-	output, err := rancher.UpdateConnectionInformation()
+	result.finish(err)
+	if writeErr := result.write(cliCtx.App.Writer, host.GetWinsConfigDir()); writeErr != nil {
+		logrus.Errorf("Could not record the result of the run: %v", writeErr)
+	}
+	if hostLock != nil {
+		if releaseErr := hostLock.Release(); releaseErr != nil {
+			logrus.Errorf("Could not release host lock: %v", releaseErr)
+		}
+	}
+	return err
+}
+
+// run applies the steps of the run in a transaction, which is rolled back if any of them fails. The transaction
+// is journaled, so that a run that was interrupted is resumed or rolled back by the next run. Once an interrupted run
+// has been rolled back, this run applies its steps to the restored host, so that it never succeeds without them.
+func run(result *Result) error {
+	var errs []error
+	var tx *state.Transaction
+	begin := func() (err error) {
+		tx, err = state.Begin(filepath.Join(host.GetWinsConfigDir(), state.JournalFileName))
+		return err
+	}
+	err := result.step(state.StepBuildInitialState, begin)
 	if err != nil {
-		logrus.Errorf("Could not update rancher connection information")
-		logrus.Errorf("Script output:\n%s", output)
-		return fmt.Errorf("error encountered while refreshing connection information: %w", err)
+		return err
 	}
 
-	if output != "" {
-		logrus.Debugf("Script output:\n%s", output)
+	if tx.Interrupted() {
+		result.Steps[len(result.Steps)-1].Message = "using the initial state journaled by an interrupted run"
+		var rolledBack bool
+		err = result.step("recover interrupted run", func() (err error) {
+			rolledBack, err = tx.Recover()
+			return err
+		})
+		switch {
+		case rolledBack && err != nil:
+			result.RolledBack = true
+			return fmt.Errorf("failed to roll back interrupted run: %w", err)
+		case rolledBack:
+			logrus.Info("Successfully rolled back interrupted run, applying this run to the restored host")
+			result.Steps[len(result.Steps)-1].Message = "rolled back the interrupted run"
+			// the journal of the interrupted run has been removed, the initial state is built from the restored host
+			if err := result.step(state.StepBuildInitialState, begin); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		}
+	}
+
+	// every step is journaled, the ones changing the host are undone in reverse order on roll back
+	apply := func(name string, fn func() error) error {
+		return result.step(name, func() error {
+			return tx.Apply(name, fn)
+		})
+	}
+
+	if version, err := host.InstalledVersion(); err == nil {
+		result.VersionBefore = version
+	}
+
+	err = apply("update connection info", func() error {
+		logrus.Info("Updating rancher connection info")
+		output, err := rancher.UpdateConnectionInformation()
+		if err != nil {
+			logrus.Errorf("Could not update rancher connection information")
+			logrus.Errorf("Script output:\n%s", output)
+			return err
+		}
+
+		if output != "" {
+			logrus.Debugf("Script output:\n%s", output)
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("error encountered while refreshing connection information: %w", err)
+		// nothing has been changed by this run yet, but the interrupted run it resumes may have
+		if tx.Interrupted() {
+			return rollBack(result, tx, []error{err})
+		}
+		return errors.Join(err, tx.Commit())
 	}
 
 	// update the config using env vars
-	restartServiceDueToConfigChange, updateErr := config.UpdateConfigFromEnvVars()
+	updateErr := apply(state.StepUpdateConfig, func() (err error) {
+		result.ConfigChanged, err = config.UpdateConfigFromEnvVars()
+		return err
+	})
 	if updateErr != nil {
 		errs = append(errs, updateErr)
 	}
+	// rancher-wins is restarted for every changed field, rather than reloading the ones it can apply while running
+	restartServiceDueToConfigChange := len(result.ConfigChanged) > 0
+
+	// verify the updated connection info with the strict TLS mode that is configured now
+	pipeName := ""
+	if cfg, err := config.LoadConfig(""); err != nil {
+		logrus.Warnf("Could not load config to check the Rancher connection information: %v", err)
+	} else {
+		rancher.CheckConnection(cfg)
+		pipeName = cfg.Listen
+	}
 
 	// Neither changing the start type nor service
 	// dependencies require any service restarts
-	err = service.ConfigureWinsDelayedStart()
+	err = apply(state.StepConfigureDelayedStart, service.ConfigureWinsDelayedStart)
 	if err != nil {
 		errs = append(errs, err)
 	}
 
-	err = service.ConfigureRKE2ServiceDependency()
+	err = apply(state.StepConfigureRKE2Dependency, service.ConfigureRKE2ServiceDependency)
 	if err != nil {
 		errs = append(errs, err)
 	}
 
-	restartServiceDueToBinaryUpgrade, err := host.UpgradeRancherWinsBinary()
+	err = apply(state.StepConfigureServiceOrdering, service.ConfigureServiceOrdering)
 	if err != nil {
-		return fmt.Errorf("failed to upgrade wins.exe: %w", err)
+		errs = append(errs, err)
 	}
 
-	if restartServiceDueToConfigChange || restartServiceDueToBinaryUpgrade {
-		err = service.RefreshWinsService()
+	// a failed upgrade may have replaced some of the binaries already, they are restored
+	// and the service is restarted together with the rest of the initial state
+	var restartServiceDueToBinaryUpgrade bool
+	upgradeErr := apply(state.StepUpgradeBinary, func() (err error) {
+		result.Binary, restartServiceDueToBinaryUpgrade, err = host.UpgradeRancherWinsBinary()
+		return err
+	})
+	if upgradeErr != nil {
+		errs = append(errs, fmt.Errorf("failed to upgrade wins.exe: %w", upgradeErr))
+	} else if !result.Binary.Replace() {
+		result.Steps[len(result.Steps)-1].Message = fmt.Sprintf("%s: %s", result.Binary.Action, result.Binary.Reason)
+	}
+
+	// rancher-wins is stopped while CSI Proxy is replaced, so that its health monitor does not restart the service
+	var restartServiceDueToCSIProxyUpgrade bool
+	csiProxyErr := apply(state.StepUpgradeCSIProxy, func() (err error) {
+		result.CSIProxy, restartServiceDueToCSIProxyUpgrade, err = host.UpgradeCSIProxy()
+		return err
+	})
+	switch {
+	case csiProxyErr != nil:
+		errs = append(errs, fmt.Errorf("failed to upgrade CSI Proxy: %w", csiProxyErr))
+	case result.CSIProxy == nil:
+		result.Steps[len(result.Steps)-1].Message = "no CSI Proxy version requested"
+	case !result.CSIProxy.Replace():
+		result.Steps[len(result.Steps)-1].Message = fmt.Sprintf("%s: %s", result.CSIProxy.Action, result.CSIProxy.Reason)
+	}
+
+	switch {
+	case upgradeErr != nil || csiProxyErr != nil:
+		result.skip("restart rancher-wins", "the service is restarted when the initial state is restored")
+	case !restartServiceDueToConfigChange && !restartServiceDueToBinaryUpgrade && !restartServiceDueToCSIProxyUpgrade:
+		result.skip("restart rancher-wins", "neither the config nor the binary changed")
+	default:
+		err = apply("restart rancher-wins", service.RefreshWinsService)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("error encountered while attempting to restart rancher-wins: %w", err))
+			break
 		}
-	}
+		result.Restarted = true
 
-	if errs != nil && len(errs) > 0 {
-		logrus.Errorf("Attempting to restore initial state due to error(s) encountered while updating rancher-wins: %v", errors.Join(errs...))
-		err = state.RestoreInitialState(initialState)
+		err = apply("verify rancher-wins", func() error {
+			return host.VerifyRancherWins(result.Binary, pipeName)
+		})
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to restore initial state: %w", err))
-		} else {
-			logrus.Info("Successfully restored initial config state")
+			// the service reaching Running does not mean that it is serving requests
+			errs = append(errs, fmt.Errorf("rancher-wins failed verification after restart: %w", err))
 		}
-		return errors.Join(errs...)
+	}
+
+	if errs != nil && len(errs) > 0 {
+		return rollBack(result, tx, errs)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logrus.Warnf("Could not finish the transaction, the next run will resume it: %v", err)
 	}
 
 	return nil
 }
+
+// rollBack restores the initial state due to the errors encountered by the run
+func rollBack(result *Result, tx *state.Transaction, errs []error) error {
+	logrus.Errorf("Attempting to restore initial state due to error(s) encountered while updating rancher-wins: %v", errors.Join(errs...))
+	result.RolledBack = true
+	err := result.step("restore initial state", tx.Rollback)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to restore initial state: %w", err))
+	} else {
+		result.RollbackSucceeded = true
+		logrus.Info("Successfully restored initial config state")
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,251 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	winsConfig "github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/suc/pkg/host"
+	"github.com/rancher/wins/suc/pkg/service"
+	"github.com/rancher/wins/suc/pkg/service/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Output formats of the dry run
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// DryRunReport lists the changes a run of the SUC would make to the host. The connection
+// information is refreshed by a script on every run, so it is not part of the report.
+type DryRunReport struct {
+	Config         []ConfigChange          `json:"config"`
+	DelayedStart   *service.Setting        `json:"delayedStart,omitempty"`
+	RKE2Dependency *service.Setting        `json:"rke2Dependency,omitempty"`
+	Services       []service.ServiceChange `json:"services,omitempty"`
+	Binary         *host.UpgradeDecision   `json:"binary,omitempty"`
+	CSIProxy       *host.CSIProxyDecision  `json:"csiProxy,omitempty"`
+	Restart        bool                    `json:"restart"`
+	Errors         []string                `json:"errors,omitempty"`
+}
+
+// ConfigChange is a field of the rancher-wins config file that would be changed, values are JSON encoded
+// and empty if the field is not set
+type ConfigChange struct {
+	Field   string `json:"field"`
+	Current string `json:"current"`
+	Desired string `json:"desired"`
+}
+
+// DryRun computes the changes a run of the SUC would make without touching the host and writes them to w,
+// either as a table or as JSON. An error is returned if the run would fail.
+func DryRun(w io.Writer, output string) error {
+	if output != OutputTable && output != OutputJSON {
+		return fmt.Errorf("unknown output format '%s', expected '%s' or '%s'", output, OutputTable, OutputJSON)
+	}
+
+	report := buildDryRunReport()
+	var err error
+	if output == OutputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = writeDryRunTable(w, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("the run would fail with %d error(s)", len(report.Errors))
+	}
+	return nil
+}
+
+func buildDryRunReport() *DryRunReport {
+	report := &DryRunReport{}
+	addErr := func(err error) {
+		logrus.Errorf("%v", err)
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	// CSI Proxy is upgraded once the config has been updated
+	current, err := config.LoadConfig("")
+	if err != nil {
+		addErr(fmt.Errorf("failed to load config: %w", err))
+	} else if updated, changed, err := config.ConfigFromEnvVars(""); err != nil {
+		addErr(err)
+	} else {
+		if len(changed) > 0 {
+			report.Config, err = configChanges(current, updated)
+			if err != nil {
+				addErr(err)
+			}
+		}
+		report.CSIProxy, err = host.DecideCSIProxyUpgrade(updated)
+		if err != nil {
+			addErr(fmt.Errorf("failed to decide CSI Proxy upgrade: %w", err))
+		}
+	}
+
+	delayedStart, err := service.WinsDelayedStartSetting()
+	if err != nil {
+		addErr(err)
+	} else {
+		report.DelayedStart = &delayedStart
+	}
+
+	report.RKE2Dependency, err = service.RKE2ServiceDependencySetting()
+	if err != nil {
+		addErr(err)
+	}
+
+	if ordering, err := service.ServiceOrderingFromEnvVars(); err != nil {
+		addErr(err)
+	} else if ordering != nil {
+		report.Services, err = service.PlanServiceOrdering(ordering)
+		if err != nil {
+			addErr(err)
+		}
+	}
+
+	report.Binary, err = host.DecideUpgrade()
+	if err != nil {
+		addErr(fmt.Errorf("failed to decide wins.exe upgrade: %w", err))
+	}
+
+	report.Restart = len(report.Config) > 0 || (report.Binary != nil && report.Binary.Replace()) ||
+		(report.CSIProxy != nil && (report.CSIProxy.Replace() || report.CSIProxy.UpdateConfig()))
+	return report
+}
+
+// configChanges compares the fields of the current and updated config as they appear in the config file. Secrets are
+// compared as they are but reported redacted the way 'wins.exe srv app config show' does, a change of a secret alone is
+// reported with the same redacted current and desired value.
+func configChanges(current, updated *winsConfig.Config) ([]ConfigChange, error) {
+	values := map[string]*ConfigChange{}
+	redacted := map[string]*ConfigChange{}
+	for _, c := range []struct {
+		config  *winsConfig.Config
+		values  map[string]*ConfigChange
+		desired bool
+	}{
+		{config: current, values: values},
+		{config: updated, values: values, desired: true},
+		{config: current.Redacted(), values: redacted},
+		{config: updated.Redacted(), values: redacted, desired: true},
+	} {
+		fields, err := winsConfig.EffectiveFields(c.config, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten config: %w", err)
+		}
+		for _, field := range fields {
+			change, ok := c.values[field.Path]
+			if !ok {
+				change = &ConfigChange{Field: field.Path}
+				c.values[field.Path] = change
+			}
+			if c.desired {
+				change.Desired = field.Value
+			} else {
+				change.Current = field.Value
+			}
+		}
+	}
+
+	var changes []ConfigChange
+	for _, c := range values {
+		if c.Current == c.Desired {
+			continue
+		}
+		// a block that is added or removed only lists the fields that are set
+		if (c.Current == "" && zeroValue(c.Desired)) || (c.Desired == "" && zeroValue(c.Current)) {
+			continue
+		}
+		changes = append(changes, *redacted[c.Field])
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+func writeDryRunTable(w io.Writer, report *DryRunReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	var errs []error
+	row := func(format string, args ...interface{}) {
+		if _, err := fmt.Fprintf(tw, format+"\n", args...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	row("CHANGE\tCURRENT\tDESIRED\tACTION")
+	for _, c := range report.Config {
+		row("config %s\t%s\t%s\tupdate", c.Field, valueOrUnset(c.Current), valueOrUnset(c.Desired))
+	}
+	if len(report.Config) == 0 {
+		row("config\t-\t-\tnone")
+	}
+	if report.DelayedStart != nil {
+		row("rancher-wins delayed start\t%t\t%t\t%s", report.DelayedStart.Current, report.DelayedStart.Desired, settingAction(report.DelayedStart))
+	}
+	if report.RKE2Dependency != nil {
+		row("rke2 depends on rancher-wins\t%t\t%t\t%s", report.RKE2Dependency.Current, report.RKE2Dependency.Desired, settingAction(report.RKE2Dependency))
+	} else {
+		row("rke2 depends on rancher-wins\t-\t-\tnone, rke2 is not installed")
+	}
+	for _, c := range report.Services {
+		row("%s service ordering\t%s\t%s\tupdate", c.Name, c.Current, c.Desired)
+	}
+	if report.Binary != nil {
+		row("wins.exe version\t%s\t%s\t%s, %s", valueOrUnset(report.Binary.InstalledVersion), report.Binary.DesiredVersion, report.Binary.Action, report.Binary.Reason)
+	}
+	if report.CSIProxy != nil {
+		row("csi-proxy.exe version\t%s\t%s\t%s, %s", valueOrUnset(report.CSIProxy.InstalledVersion), report.CSIProxy.DesiredVersion, report.CSIProxy.Action, report.CSIProxy.Reason)
+		if report.CSIProxy.UpdateConfig() {
+			row("config csi-proxy.version\t%s\t%s\tupdate", report.CSIProxy.ConfiguredVersion, report.CSIProxy.DesiredVersion)
+		}
+	}
+	restart := "none"
+	if report.Restart {
+		restart = "restart"
+	}
+	row("rancher-wins service\t-\t-\t%s", restart)
+	for _, e := range report.Errors {
+		row("error\t-\t-\t%s", e)
+	}
+
+	if err := tw.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// zeroValue returns whether the JSON encoded value is the zero value of its type
+func zeroValue(v string) bool {
+	switch v {
+	case `""`, "0", "false", "null", "[]", "{}":
+		return true
+	}
+	return false
+}
+
+func settingAction(s *service.Setting) string {
+	if s.Changed() {
+		return "update"
+	}
+	return "none"
+}
+
+func valueOrUnset(v string) string {
+	if v == "" {
+		return "<unset>"
+	}
+	return v
+}
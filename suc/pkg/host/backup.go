@@ -0,0 +1,154 @@
+package host
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/suc/pkg/service"
+	"github.com/sirupsen/logrus"
+)
+
+const backupSuffix = ".bak"
+
+// stopRancherWins stops the rancher-wins service so that its binary can be replaced, it is replaced by a fake in tests
+var stopRancherWins = func() error {
+	rw, exists, err := service.OpenRancherWinsService()
+	if err != nil || !exists {
+		return err
+	}
+	return rw.Stop()
+}
+
+// BinaryBackup is a copy of an installed file, e.g. the wins.exe binary, taken before the file is replaced.
+// If the file did not exist, Existed is false and restoring the backup removes the file.
+type BinaryBackup struct {
+	Path       string `json:"path"`
+	BackupPath string `json:"backupPath"`
+	Existed    bool   `json:"existed"`
+}
+
+// BackupBinaries copies the installed wins.exe binaries next to themselves, so that they can be
+// restored with RestoreBinaries if the upgrade fails.
+func BackupBinaries() ([]BinaryBackup, error) {
+	return backupFiles(defaultWinsPath, getWinsUsrLocalBinBinary())
+}
+
+// RestoreBinaries puts the backed up binaries back into place. Binaries that still match their backup
+// are left alone, if any binary has to be replaced the rancher-wins service is stopped first.
+// A boolean is returned to indicate if binaries were restored and the rancher-wins service needs to be restarted.
+func RestoreBinaries(backups []BinaryBackup) (bool, error) {
+	changed, err := modifiedBackups(backups)
+	if err != nil {
+		return false, err
+	}
+
+	if len(changed) == 0 {
+		logrus.Debug("wins.exe binaries were not modified, nothing to restore")
+		return false, nil
+	}
+
+	logrus.Infof("Stopping %s to restore wins.exe binaries", defaults.WindowsServiceName)
+	if err := stopRancherWins(); err != nil {
+		return false, fmt.Errorf("failed to stop %s while restoring wins.exe binaries: %w", defaults.WindowsServiceName, err)
+	}
+	return true, restoreBackups(changed)
+}
+
+// RemoveBackups removes the backed up binaries once they are no longer needed
+func RemoveBackups(backups []BinaryBackup) error {
+	var errs []error
+	for _, backup := range backups {
+		if !backup.Existed {
+			continue
+		}
+		logrus.Debugf("Removing backup %s", backup.BackupPath)
+		if err := files.Remove(backup.BackupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to remove backup '%s': %w", backup.BackupPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backupFiles copies the files next to themselves, files which do not exist are recorded as such
+func backupFiles(paths ...string) ([]BinaryBackup, error) {
+	var backups []BinaryBackup
+	for _, path := range paths {
+		backup := BinaryBackup{
+			Path:       path,
+			BackupPath: path + backupSuffix,
+		}
+
+		_, err := files.Stat(path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			logrus.Debugf("%s is not installed, nothing to back up", path)
+		case err != nil:
+			return nil, fmt.Errorf("failed to stat '%s' while backing up: %w", path, err)
+		default:
+			logrus.Debugf("Backing up %s to %s", path, backup.BackupPath)
+			if err := copyFile(path, backup.BackupPath); err != nil {
+				return nil, fmt.Errorf("failed to back up '%s': %w", path, err)
+			}
+			backup.Existed = true
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+// modifiedBackups returns the backups whose file has been modified since the backup was taken
+func modifiedBackups(backups []BinaryBackup) ([]BinaryBackup, error) {
+	var changed []BinaryBackup
+	for _, backup := range backups {
+		modified, err := backupModified(backup)
+		if err != nil {
+			return nil, err
+		}
+		if modified {
+			changed = append(changed, backup)
+		}
+	}
+	return changed, nil
+}
+
+// restoreBackups copies the backups over their files, files which did not exist are removed
+func restoreBackups(backups []BinaryBackup) error {
+	var errs []error
+	for _, backup := range backups {
+		if !backup.Existed {
+			logrus.Infof("Removing %s as it was not installed before the upgrade", backup.Path)
+			if err := files.Remove(backup.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("failed to remove '%s': %w", backup.Path, err))
+			}
+			continue
+		}
+		logrus.Infof("Restoring %s from %s", backup.Path, backup.BackupPath)
+		if err := copyFile(backup.BackupPath, backup.Path); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore '%s': %w", backup.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backupModified reports whether the installed file differs from its backup
+func backupModified(backup BinaryBackup) (bool, error) {
+	current, err := files.ReadFile(backup.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return backup.Existed, nil
+		}
+		return false, fmt.Errorf("failed to read '%s' while restoring backups: %w", backup.Path, err)
+	}
+	if !backup.Existed {
+		return true, nil
+	}
+
+	previous, err := files.ReadFile(backup.BackupPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read backup '%s': %w", backup.BackupPath, err)
+	}
+	return !bytes.Equal(current, previous), nil
+}
@@ -0,0 +1,159 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// fakeFileSystem keeps files in memory
+type fakeFileSystem struct {
+	files map[string][]byte
+}
+
+type fakeFileInfo struct {
+	os.FileInfo
+}
+
+func (f *fakeFileSystem) Stat(name string) (os.FileInfo, error) {
+	if _, ok := f.files[name]; !ok {
+		return nil, os.ErrNotExist
+	}
+	return fakeFileInfo{}, nil
+}
+
+func (f *fakeFileSystem) ReadFile(name string) ([]byte, error) {
+	b, ok := f.files[name]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return b, nil
+}
+
+func (f *fakeFileSystem) WriteFile(name string, data []byte, _ os.FileMode) error {
+	f.files[name] = data
+	return nil
+}
+
+func (f *fakeFileSystem) Remove(name string) error {
+	if _, ok := f.files[name]; !ok {
+		return os.ErrNotExist
+	}
+	delete(f.files, name)
+	return nil
+}
+
+func setupBackupTest(t *testing.T, installed map[string][]byte) (fs *fakeFileSystem, stops *int) {
+	t.Helper()
+	t.Setenv("CATTLE_AGENT_BIN_PREFIX", "")
+	fs = &fakeFileSystem{files: installed}
+	stops = new(int)
+
+	previousFiles, previousStop := files, stopRancherWins
+	t.Cleanup(func() { files, stopRancherWins = previousFiles, previousStop })
+	files = fs
+	stopRancherWins = func() error {
+		*stops++
+		return nil
+	}
+	return fs, stops
+}
+
+func TestRestoreBinaries(t *testing.T) {
+	old, upgraded := []byte("v0.4.20"), []byte("v0.4.21")
+
+	tests := []struct {
+		name      string
+		installed map[string][]byte
+		upgrade   func(fs *fakeFileSystem)
+		restored  bool
+		expected  map[string][]byte
+	}{
+		{
+			name:      "binaries not modified",
+			installed: map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+			upgrade:   func(*fakeFileSystem) {},
+			expected:  map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+		},
+		{
+			name:      "upgrade failed after the first copy",
+			installed: map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+			upgrade: func(fs *fakeFileSystem) {
+				fs.files[defaultWinsPath] = upgraded
+			},
+			restored: true,
+			expected: map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+		},
+		{
+			name:      "binary created by the upgrade is removed",
+			installed: map[string][]byte{defaultWinsPath: old},
+			upgrade: func(fs *fakeFileSystem) {
+				fs.files[defaultWinsPath] = upgraded
+				fs.files[defaultWinsUsrLocalBinPath] = upgraded
+			},
+			restored: true,
+			expected: map[string][]byte{defaultWinsPath: old},
+		},
+		{
+			name:      "binary removed during the upgrade is restored",
+			installed: map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+			upgrade: func(fs *fakeFileSystem) {
+				delete(fs.files, defaultWinsPath)
+			},
+			restored: true,
+			expected: map[string][]byte{defaultWinsPath: old, defaultWinsUsrLocalBinPath: old},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, stops := setupBackupTest(t, tt.installed)
+
+			backups, err := BackupBinaries()
+			if err != nil {
+				t.Fatalf("BackupBinaries returned an unexpected error: %v", err)
+			}
+			tt.upgrade(fs)
+
+			restored, err := RestoreBinaries(backups)
+			if err != nil {
+				t.Fatalf("RestoreBinaries returned an unexpected error: %v", err)
+			}
+			if restored != tt.restored {
+				t.Errorf("expected restored to be %t, got %t", tt.restored, restored)
+			}
+			if expectedStops := map[bool]int{true: 1}[tt.restored]; *stops != expectedStops {
+				t.Errorf("expected rancher-wins to be stopped %d times, got %d", expectedStops, *stops)
+			}
+
+			if err := RemoveBackups(backups); err != nil {
+				t.Fatalf("RemoveBackups returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(fs.files, tt.expected) {
+				t.Errorf("expected files %q, got %q", tt.expected, fs.files)
+			}
+		})
+	}
+}
+
+func TestRestoreBinariesStopFailure(t *testing.T) {
+	fs, _ := setupBackupTest(t, map[string][]byte{defaultWinsPath: []byte("v0.4.20")})
+	stopRancherWins = func() error { return errors.New("dependent services are running") }
+
+	backups, err := BackupBinaries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.files[defaultWinsPath] = []byte("v0.4.21")
+
+	if _, err := RestoreBinaries(backups); err == nil {
+		t.Fatal("expected RestoreBinaries to fail when rancher-wins cannot be stopped")
+	}
+	if string(fs.files[defaultWinsPath]) != "v0.4.21" {
+		t.Errorf("expected binary to be left alone while rancher-wins is running, got %s", fs.files[defaultWinsPath])
+	}
+	if string(fs.files[defaultWinsPath+backupSuffix]) != "v0.4.20" {
+		t.Errorf("expected backup to be kept, got %s", fs.files[defaultWinsPath+backupSuffix])
+	}
+}
@@ -22,8 +22,33 @@
 	// This is primarily used in CI, to allow for test cases to run without having to completely
 	// install rancher-wins.
 	skipBinaryUpgradeEnvVar = "CATTLE_WINS_SKIP_BINARY_UPGRADE"
+
+	// allowDowngradeEnvVar allows the suc image to replace the wins binary with an older version.
+	allowDowngradeEnvVar = "CATTLE_WINS_ALLOW_DOWNGRADE"
 )
 
+// fileSystem is used to copy, back up and restore binaries, it is replaced by a fake in tests
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Remove(name string) error
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+func (osFileSystem) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (osFileSystem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (osFileSystem) Remove(name string) error { return os.Remove(name) }
+
+var files fileSystem = osFileSystem{}
+
 // getRancherWinsVersionFromBinary executes the wins.exe binary located at 'path' and passes the '--version'
 // flag. The release version or commit hash is returned. If the binary returns unexpected output,
 // was built with a dirty commit, or does not exist, an error will be returned.
@@ -108,7 +133,7 @@ func copyFile(source, dest string) error {
 	var err error
 	var b []byte
 
-	_, err = os.Stat(source)
+	_, err = files.Stat(source)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return fmt.Errorf("specified source file '%s' cannot be copied as it does not exist: %w", source, err)
@@ -117,7 +142,7 @@ func copyFile(source, dest string) error {
 	}
 
 	for i := 0; i < fileOperationAttempts; i++ {
-		b, err = os.ReadFile(source)
+		b, err = files.ReadFile(source)
 		if err != nil {
 			if strings.Contains(err.Error(), "because it is being used by another process") {
 				logrus.Debugf("file copy attempt failed as the source file is in use, waiting %d seconds before reattempting", fileOperationAttemptDelayInSeconds)
@@ -127,7 +152,7 @@ func copyFile(source, dest string) error {
 			return fmt.Errorf("failed to read from '%s': %w", source, err)
 		}
 
-		err = os.WriteFile(dest, b, os.ModePerm)
+		err = files.WriteFile(dest, b, os.ModePerm)
 		if err != nil {
 			if strings.Contains(err.Error(), "because it is being used by another process") {
 				logrus.Debugf("file copy attempt failed as the destination file is in use, waiting %d seconds before reattempting", fileOperationAttemptDelayInSeconds)
@@ -136,6 +161,7 @@ func copyFile(source, dest string) error {
 			}
 			return fmt.Errorf("failed to write to '%s': %w", dest, err)
 		}
+		break
 	}
 
 	if err != nil {
@@ -145,7 +171,14 @@ func copyFile(source, dest string) error {
 	return nil
 }
 
-func getWinsConfigDir() string {
+// envVarTrue returns whether the environment variable is set to 'true' or '$true'
+func envVarTrue(name string) bool {
+	v := strings.ToLower(os.Getenv(name))
+	return v == "true" || v == "$true"
+}
+
+// GetWinsConfigDir returns the rancher-wins config directory, c:\etc\rancher\wins unless CATTLE_AGENT_CONFIG_DIR is set
+func GetWinsConfigDir() string {
 	customPath := os.Getenv("CATTLE_AGENT_CONFIG_DIR")
 	if customPath != "" {
 		return customPath
@@ -153,6 +186,11 @@ func getWinsConfigDir() string {
 	return defaultConfigDir
 }
 
+// InstalledVersion returns the version of the wins.exe binary used by the rancher-wins service
+func InstalledVersion() (string, error) {
+	return getRancherWinsVersionFromBinary(defaultWinsPath)
+}
+
 func getWinsUsrLocalBinBinary() string {
 	customPath := os.Getenv("CATTLE_AGENT_BIN_PREFIX")
 	if customPath != "" {
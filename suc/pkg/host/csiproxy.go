@@ -0,0 +1,382 @@
+package host
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	winsConfig "github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/csiproxy"
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/winservice"
+	"github.com/rancher/wins/suc/pkg/service"
+	sucConfig "github.com/rancher/wins/suc/pkg/service/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// csiProxyVersionEnvVar is the version of CSI Proxy the SUC installs. It defaults to the version of the
+	// embedded artifact, CSI Proxy is left alone if it is not set and no artifact is embedded.
+	csiProxyVersionEnvVar = "CATTLE_WINS_CSI_PROXY_VERSION"
+
+	// csiProxyArtifactEnvVar is the path of a csi-proxy.exe on the host which is installed instead of the embedded
+	// artifact, the version of the binary has to be set with csiProxyVersionEnvVar.
+	csiProxyArtifactEnvVar = "CATTLE_WINS_CSI_PROXY_ARTIFACT"
+
+	// csiProxySHA256EnvVar is the checksum of the CSI Proxy archive of the requested version, which rancher-wins
+	// downloads if it installs CSI Proxy itself. It is written to the rancher-wins config along with the version,
+	// and has to be set if the config pins the checksum of the archive of the configured version.
+	csiProxySHA256EnvVar = "CATTLE_WINS_CSI_PROXY_SHA256"
+
+	embeddedCSIProxyBinary  = "artifacts/csi-proxy.exe"
+	embeddedCSIProxyVersion = "artifacts/csi-proxy.version"
+)
+
+var (
+	// csiProxyArtifacts, csiProxyService and csiProxySettleTime are replaced by fakes in tests
+	csiProxyArtifacts  fs.FS              = artifacts
+	csiProxyService    csiProxyController = scmCSIProxy{}
+	csiProxySettleTime                    = 5 * time.Second
+)
+
+// csiProxyController controls the CSI Proxy service installed by rancher-wins
+type csiProxyController interface {
+	// BinaryPath returns the binary of the service, exists is false if the service is not installed
+	BinaryPath() (path string, exists bool, err error)
+	Stop() error
+	Start() error
+	Running() (bool, error)
+}
+
+type scmCSIProxy struct{}
+
+func (scmCSIProxy) BinaryPath() (string, bool, error) {
+	svc, exists, err := service.Open(csiproxy.ServiceName)
+	if err != nil || !exists {
+		return "", false, err
+	}
+	return svc.Config.BinaryPath, true, nil
+}
+
+func (scmCSIProxy) Stop() error {
+	svc, exists, err := service.Open(csiproxy.ServiceName)
+	if err != nil || !exists {
+		return err
+	}
+	return svc.Stop()
+}
+
+func (scmCSIProxy) Start() error {
+	svc, exists, err := service.Open(csiproxy.ServiceName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("the %s service does not exist", csiproxy.ServiceName)
+	}
+	return svc.Start()
+}
+
+func (scmCSIProxy) Running() (bool, error) {
+	svc, exists, err := service.Open(csiproxy.ServiceName)
+	if err != nil || !exists {
+		return false, err
+	}
+	state, err := svc.GetState()
+	if err != nil {
+		return false, err
+	}
+	return state == winservice.Running, nil
+}
+
+// CSIProxyDecision records whether the installed CSI Proxy is replaced by the requested artifact and
+// whether the version in the csi-proxy block of the rancher-wins config is updated
+type CSIProxyDecision struct {
+	UpgradeDecision
+	// ConfiguredVersion is the version in the rancher-wins config, it is empty if CSI Proxy is not configured
+	ConfiguredVersion string `json:"configuredVersion,omitempty"`
+	BinaryPath        string `json:"binaryPath,omitempty"`
+	// Artifact is the path of the binary that is installed, it is empty if the embedded artifact is installed
+	Artifact string `json:"artifact,omitempty"`
+	// SHA256 is the checksum of the archive of the desired version that is written to the config along with the version
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// UpdateConfig returns whether the version in the rancher-wins config is updated to the desired version
+func (d *CSIProxyDecision) UpdateConfig() bool {
+	return d.ConfiguredVersion != "" && d.ConfiguredVersion != d.DesiredVersion && d.Action != ActionRefuse
+}
+
+// UpgradeCSIProxy upgrades CSI Proxy to the version set by the CATTLE_WINS_CSI_PROXY_VERSION environment variable,
+// or the embedded artifact if it is not set. The version in the rancher-wins config is updated first, then both
+// rancher-wins and the csiproxy service are stopped, so that the CSI Proxy health monitor of rancher-wins does not
+// restart the service while the binary is replaced. Once replaced, csiproxy is started and verified. If the
+// csiproxy service is not installed, only the config is updated, rancher-wins installs the version once restarted.
+// The sha256 pin of the archive in the config is replaced by the CATTLE_WINS_CSI_PROXY_SHA256 environment variable
+// along with the version.
+// Older versions are only installed if the CATTLE_WINS_ALLOW_DOWNGRADE environment variable is set to 'true' or '$true'.
+// The decision is returned along with a boolean to indicate if the rancher-wins service needs to be restarted.
+func UpgradeCSIProxy() (*CSIProxyDecision, bool, error) {
+	cfg, err := sucConfig.LoadConfig("")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	decision, err := DecideCSIProxyUpgrade(cfg)
+	if err != nil || decision == nil {
+		return decision, false, err
+	}
+
+	if decision.UpdateConfig() {
+		logrus.Infof("Updating CSI Proxy version in the rancher-wins config from %s to %s", decision.ConfiguredVersion, decision.DesiredVersion)
+		cfg.CSIProxy.Version, cfg.CSIProxy.SHA256 = decision.DesiredVersion, decision.SHA256
+		if err := sucConfig.SaveConfig(cfg, ""); err != nil {
+			return decision, false, fmt.Errorf("failed to update CSI Proxy version in the rancher-wins config: %w", err)
+		}
+	}
+
+	if decision.Replace() {
+		if err := replaceCSIProxy(decision); err != nil {
+			return decision, false, err
+		}
+		// rancher-wins has been stopped to replace the binary
+		return decision, true, nil
+	}
+	return decision, decision.UpdateConfig(), nil
+}
+
+// DecideCSIProxyUpgrade compares the installed CSI Proxy version with the requested one and decides whether the
+// installed binary is replaced, based on the csi-proxy block of cfg. A nil decision is returned if no CSI Proxy
+// version is requested.
+func DecideCSIProxyUpgrade(cfg *winsConfig.Config) (*CSIProxyDecision, error) {
+	desiredVersion, artifact, err := requestedCSIProxy()
+	if err != nil || desiredVersion == "" {
+		return nil, err
+	}
+
+	decision := &CSIProxyDecision{
+		UpgradeDecision: UpgradeDecision{DesiredVersion: desiredVersion},
+		Artifact:        artifact,
+	}
+	if cfg.CSIProxy == nil {
+		decision.Action, decision.Reason = ActionSkip, "CSI Proxy is not configured"
+		logrus.Infof("Will not upgrade CSI Proxy: %s", decision.Reason)
+		return decision, nil
+	}
+	decision.ConfiguredVersion = cfg.CSIProxy.Version
+
+	binaryPath, exists, err := csiProxyService.BinaryPath()
+	if err != nil {
+		return nil, fmt.Errorf("could not find installed CSI Proxy: %w", err)
+	}
+	if !exists {
+		decision.Action = ActionSkip
+		decision.Reason = fmt.Sprintf("the %s service is not installed, %s installs the configured version", csiproxy.ServiceName, defaults.WindowsServiceName)
+		logrus.Infof("Will not replace CSI Proxy: %s", decision.Reason)
+		return decision, decision.pinSHA256(cfg.CSIProxy)
+	}
+	decision.BinaryPath = binaryPath
+
+	// services installed before the installation was recorded are assumed to match the config
+	installedVersion, err := csiproxy.RecordedVersion(binaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not determine installed CSI Proxy version: %w", err)
+	}
+	if installedVersion == "" {
+		installedVersion = decision.ConfiguredVersion
+	}
+
+	upgrade, err := decideUpgrade("csi-proxy.exe", installedVersion, desiredVersion, envVarTrue(allowDowngradeEnvVar))
+	if err != nil {
+		return nil, err
+	}
+	decision.UpgradeDecision = *upgrade
+
+	if decision.Action == ActionRefuse {
+		logrus.Warnf("Will not replace csi-proxy.exe: %s", decision.Reason)
+	} else {
+		logrus.Infof("csi-proxy.exe upgrade decision is %s: %s", decision.Action, decision.Reason)
+	}
+	return decision, decision.pinSHA256(cfg.CSIProxy)
+}
+
+// pinSHA256 takes the checksum of the archive of the desired version from the CATTLE_WINS_CSI_PROXY_SHA256
+// environment variable if the version in the config is updated. A checksum pinned by the config only matches the
+// archive of the configured version, so it is never dropped without a new one.
+func (d *CSIProxyDecision) pinSHA256(cfg *csiproxy.Config) error {
+	if !d.UpdateConfig() {
+		return nil
+	}
+	d.SHA256 = strings.TrimSpace(os.Getenv(csiProxySHA256EnvVar))
+	if d.SHA256 == "" {
+		if cfg.SHA256 != "" {
+			return fmt.Errorf("the rancher-wins config pins the sha256 of the CSI Proxy %s archive, %s has to be set to the sha256 of the %s archive", d.ConfiguredVersion, csiProxySHA256EnvVar, d.DesiredVersion)
+		}
+		return nil
+	}
+	if checksum, err := hex.DecodeString(d.SHA256); err != nil || len(checksum) != sha256.Size {
+		return fmt.Errorf("%s '%s' is not a hex encoded SHA256 checksum", csiProxySHA256EnvVar, d.SHA256)
+	}
+	return nil
+}
+
+// BackupCSIProxy copies the installed CSI Proxy binary and its recorded installation next to themselves if a
+// CSI Proxy version is requested, so that they can be restored with RestoreCSIProxy if the upgrade fails.
+func BackupCSIProxy() ([]BinaryBackup, error) {
+	desiredVersion, _, err := requestedCSIProxy()
+	if err != nil || desiredVersion == "" {
+		// an invalid request fails the run once CSI Proxy is upgraded
+		return nil, nil
+	}
+
+	binaryPath, exists, err := csiProxyService.BinaryPath()
+	if err != nil {
+		return nil, fmt.Errorf("could not find installed CSI Proxy: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return backupFiles(binaryPath, csiproxy.StatePath(binaryPath))
+}
+
+// RestoreCSIProxy puts the backed up CSI Proxy binary and installation back into place. If either has to be
+// replaced, rancher-wins and the csiproxy service are stopped first and csiproxy is started again afterwards.
+func RestoreCSIProxy(backups []BinaryBackup) error {
+	changed, err := modifiedBackups(backups)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		logrus.Debug("CSI Proxy was not modified, nothing to restore")
+		return nil
+	}
+
+	logrus.Infof("Stopping %s and %s to restore CSI Proxy", defaults.WindowsServiceName, csiproxy.ServiceName)
+	if err := stopRancherWins(); err != nil {
+		return fmt.Errorf("failed to stop %s while restoring CSI Proxy: %w", defaults.WindowsServiceName, err)
+	}
+	if err := csiProxyService.Stop(); err != nil {
+		return fmt.Errorf("failed to stop %s while restoring CSI Proxy: %w", csiproxy.ServiceName, err)
+	}
+
+	if err := restoreBackups(changed); err != nil {
+		return err
+	}
+	if err := csiProxyService.Start(); err != nil {
+		return fmt.Errorf("failed to start %s after restoring CSI Proxy: %w", csiproxy.ServiceName, err)
+	}
+	return nil
+}
+
+// requestedCSIProxy returns the requested CSI Proxy version and the path of its binary, the path is empty if
+// the embedded artifact is installed. The version is empty if no CSI Proxy version is requested.
+func requestedCSIProxy() (version, artifact string, err error) {
+	version = strings.TrimSpace(os.Getenv(csiProxyVersionEnvVar))
+	artifact = os.Getenv(csiProxyArtifactEnvVar)
+	if artifact != "" {
+		if version == "" {
+			return "", "", fmt.Errorf("%s must be set to the version of %s", csiProxyVersionEnvVar, artifact)
+		}
+		return version, artifact, nil
+	}
+
+	b, err := fs.ReadFile(csiProxyArtifacts, embeddedCSIProxyVersion)
+	if errors.Is(err, fs.ErrNotExist) {
+		if version != "" {
+			return "", "", fmt.Errorf("%s is set to %s, but no CSI Proxy artifact is embedded, set %s to install a binary from the host", csiProxyVersionEnvVar, version, csiProxyArtifactEnvVar)
+		}
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("could not read embedded CSI Proxy version: %w", err)
+	}
+
+	embeddedVersion := strings.TrimSpace(string(b))
+	if version != "" && version != embeddedVersion {
+		return "", "", fmt.Errorf("%s is set to %s, but the embedded CSI Proxy artifact is version %s, set %s to install a binary from the host", csiProxyVersionEnvVar, version, embeddedVersion, csiProxyArtifactEnvVar)
+	}
+	return embeddedVersion, "", nil
+}
+
+// replaceCSIProxy stops rancher-wins and csiproxy, replaces the CSI Proxy binary with the artifact, records its
+// version for rancher-wins and starts csiproxy again. The service has to keep running and use the new binary.
+func replaceCSIProxy(decision *CSIProxyDecision) error {
+	var binary []byte
+	var err error
+	if decision.Artifact == "" {
+		binary, err = fs.ReadFile(csiProxyArtifacts, embeddedCSIProxyBinary)
+	} else {
+		binary, err = files.ReadFile(decision.Artifact)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSI Proxy %s artifact: %w", decision.DesiredVersion, err)
+	}
+	checksum := sha256.Sum256(binary)
+
+	// the binary is staged first, so that the copy into place is retried if the binary is still in use
+	stagedPath := filepath.Join(GetWinsConfigDir(), fmt.Sprintf("csi-proxy-%s.exe", decision.DesiredVersion))
+	if err := files.WriteFile(stagedPath, binary, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to stage CSI Proxy binary: %w", err)
+	}
+	defer func() {
+		if err := files.Remove(stagedPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logrus.Warnf("Failed to remove staged CSI Proxy binary (%s): %v", stagedPath, err)
+		}
+	}()
+
+	logrus.Infof("Stopping %s so that it does not restart %s while it is upgraded", defaults.WindowsServiceName, csiproxy.ServiceName)
+	if err := stopRancherWins(); err != nil {
+		return fmt.Errorf("failed to stop %s while attempting to upgrade CSI Proxy: %w", defaults.WindowsServiceName, err)
+	}
+	if err := csiProxyService.Stop(); err != nil {
+		return fmt.Errorf("failed to stop %s while attempting to upgrade CSI Proxy: %w", csiproxy.ServiceName, err)
+	}
+
+	logrus.Infof("Copying %s to %s", stagedPath, decision.BinaryPath)
+	if err := copyFile(stagedPath, decision.BinaryPath); err != nil {
+		return fmt.Errorf("failed to copy new CSI Proxy binary to %s: %w", decision.BinaryPath, err)
+	}
+	if err := csiproxy.RecordVersion(decision.BinaryPath, decision.DesiredVersion); err != nil {
+		return err
+	}
+
+	if err := csiProxyService.Start(); err != nil {
+		return fmt.Errorf("failed to start %s after upgrading CSI Proxy: %w", csiproxy.ServiceName, err)
+	}
+	if err := verifyCSIProxy(decision.BinaryPath, checksum[:]); err != nil {
+		return err
+	}
+
+	logrus.Infof("Successfully upgraded CSI Proxy to version %s (checksum %s)", decision.DesiredVersion, hex.EncodeToString(checksum[:]))
+	return nil
+}
+
+// verifyCSIProxy ensures that the csiproxy service keeps running past the settle time and that the binary at
+// binaryPath has the expected checksum
+func verifyCSIProxy(binaryPath string, expected []byte) error {
+	time.Sleep(csiProxySettleTime)
+	running, err := csiProxyService.Running()
+	if err != nil {
+		return fmt.Errorf("failed to query %s after upgrading CSI Proxy: %w", csiproxy.ServiceName, err)
+	}
+	if !running {
+		return fmt.Errorf("%s stopped after upgrading CSI Proxy", csiproxy.ServiceName)
+	}
+
+	installed, err := files.ReadFile(binaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read '%s' while verifying CSI Proxy: %w", binaryPath, err)
+	}
+	if actual := sha256.Sum256(installed); !bytes.Equal(actual[:], expected) {
+		return fmt.Errorf("'%s' checksum (%s) did not match the upgraded artifact (%s)", binaryPath, hex.EncodeToString(actual[:]), hex.EncodeToString(expected))
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package host
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	winsConfig "github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/csiproxy"
+	sucConfig "github.com/rancher/wins/suc/pkg/service/config"
+)
+
+// fakeCSIProxy records the calls made to the csiproxy service
+type fakeCSIProxy struct {
+	path    string
+	missing bool
+	stopped bool
+	crashes bool
+	calls   []string
+}
+
+func (f *fakeCSIProxy) BinaryPath() (string, bool, error) {
+	return f.path, !f.missing, nil
+}
+
+func (f *fakeCSIProxy) Stop() error {
+	f.calls = append(f.calls, "stop")
+	f.stopped = true
+	return nil
+}
+
+func (f *fakeCSIProxy) Start() error {
+	f.calls = append(f.calls, "start")
+	f.stopped = f.crashes
+	return nil
+}
+
+func (f *fakeCSIProxy) Running() (bool, error) {
+	return !f.stopped, nil
+}
+
+const (
+	// csiProxySHA256 pins the archive of CSI Proxy v1.0.0 in the config set up by setupCSIProxyTest
+	csiProxySHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	// csiProxyNewSHA256 is the checksum of the archive of the requested version
+	csiProxyNewSHA256 = "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
+)
+
+// setupCSIProxyTest installs CSI Proxy v1.0.0 into a temporary directory, which is used as rancher-wins config directory as well
+func setupCSIProxyTest(t *testing.T) (svc *fakeCSIProxy, stops *int) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("CATTLE_AGENT_CONFIG_DIR", dir)
+	t.Setenv(csiProxyVersionEnvVar, "")
+	t.Setenv(csiProxyArtifactEnvVar, "")
+	t.Setenv(allowDowngradeEnvVar, "")
+	t.Setenv(csiProxySHA256EnvVar, csiProxyNewSHA256)
+
+	t.Setenv(sucConfig.DirEnvVar, filepath.Join(dir, "config"))
+	cfg := winsConfig.DefaultConfig()
+	cfg.CSIProxy = &csiproxy.Config{
+		URL:         "https://example.com/csi-proxy-%s.tar.gz",
+		Version:     "v1.0.0",
+		KubeletPath: "c:/var/lib/kubelet",
+		SHA256:      csiProxySHA256,
+	}
+	if err := sucConfig.SaveConfig(cfg, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	svc = &fakeCSIProxy{path: filepath.Join(dir, "csi-proxy.exe")}
+	if err := os.WriteFile(svc.path, []byte("v1.0.0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := csiproxy.RecordVersion(svc.path, "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	stops = new(int)
+	previousArtifacts, previousService, previousStop, previousSettle := csiProxyArtifacts, csiProxyService, stopRancherWins, csiProxySettleTime
+	t.Cleanup(func() {
+		csiProxyArtifacts, csiProxyService, stopRancherWins, csiProxySettleTime = previousArtifacts, previousService, previousStop, previousSettle
+	})
+	csiProxyArtifacts = fstest.MapFS{}
+	csiProxyService = svc
+	csiProxySettleTime = 0
+	stopRancherWins = func() error {
+		*stops++
+		return nil
+	}
+	return svc, stops
+}
+
+// embedCSIProxy replaces the embedded artifact with a binary of the given version
+func embedCSIProxy(version string) {
+	csiProxyArtifacts = fstest.MapFS{
+		embeddedCSIProxyBinary:  {Data: []byte(version)},
+		embeddedCSIProxyVersion: {Data: []byte(version + "\n")},
+	}
+}
+
+func assertCSIProxy(t *testing.T, svc *fakeCSIProxy, binary, recorded, configured string) {
+	t.Helper()
+	if b, err := os.ReadFile(svc.path); err != nil || string(b) != binary {
+		t.Errorf("expected binary %s, got %s: %v", binary, b, err)
+	}
+	if version, err := csiproxy.RecordedVersion(svc.path); err != nil || version != recorded {
+		t.Errorf("expected recorded version %s, got %s: %v", recorded, version, err)
+	}
+	cfg, err := sucConfig.LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CSIProxy.Version != configured {
+		t.Errorf("expected configured version %s, got %s", configured, cfg.CSIProxy.Version)
+	}
+	// the pin is replaced along with the configured version
+	expectedSHA256 := csiProxyNewSHA256
+	if configured == "v1.0.0" {
+		expectedSHA256 = csiProxySHA256
+	}
+	if cfg.CSIProxy.SHA256 != expectedSHA256 {
+		t.Errorf("expected sha256 pin '%s' for version %s, got '%s'", expectedSHA256, configured, cfg.CSIProxy.SHA256)
+	}
+}
+
+func TestUpgradeCSIProxy(t *testing.T) {
+	type test struct {
+		name            string
+		setup           func(t *testing.T, svc *fakeCSIProxy)
+		expectedErr     string
+		expectedAction  string
+		expectedRestart bool
+		expectedCalls   []string
+		// expectedVersion is the binary, recorded and configured version after the upgrade
+		expectedVersion string
+	}
+
+	tests := []test{
+		{
+			name:            "No version requested",
+			setup:           func(*testing.T, *fakeCSIProxy) {},
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name:            "Embedded artifact",
+			setup:           func(*testing.T, *fakeCSIProxy) { embedCSIProxy("v1.1.0") },
+			expectedAction:  ActionUpgrade,
+			expectedRestart: true,
+			expectedCalls:   []string{"stop", "start"},
+			expectedVersion: "v1.1.0",
+		},
+		{
+			name: "Local artifact",
+			setup: func(t *testing.T, svc *fakeCSIProxy) {
+				artifact := filepath.Join(filepath.Dir(svc.path), "artifact.exe")
+				if err := os.WriteFile(artifact, []byte("v1.2.0"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				embedCSIProxy("v1.1.0")
+				t.Setenv(csiProxyVersionEnvVar, "v1.2.0")
+				t.Setenv(csiProxyArtifactEnvVar, artifact)
+			},
+			expectedAction:  ActionUpgrade,
+			expectedRestart: true,
+			expectedCalls:   []string{"stop", "start"},
+			expectedVersion: "v1.2.0",
+		},
+		{
+			name:            "Older version",
+			setup:           func(*testing.T, *fakeCSIProxy) { embedCSIProxy("v0.9.0") },
+			expectedAction:  ActionRefuse,
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name:            "Up to date",
+			setup:           func(*testing.T, *fakeCSIProxy) { embedCSIProxy("v1.0.0") },
+			expectedAction:  ActionNone,
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name: "Version differs from embedded artifact",
+			setup: func(t *testing.T, _ *fakeCSIProxy) {
+				embedCSIProxy("v1.1.0")
+				t.Setenv(csiProxyVersionEnvVar, "v1.2.0")
+			},
+			expectedErr:     "the embedded CSI Proxy artifact is version v1.1.0",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name:            "Local artifact without version",
+			setup:           func(t *testing.T, _ *fakeCSIProxy) { t.Setenv(csiProxyArtifactEnvVar, "c:/csi-proxy.exe") },
+			expectedErr:     "CATTLE_WINS_CSI_PROXY_VERSION must be set",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name: "Pinned checksum without the checksum of the requested version",
+			setup: func(t *testing.T, _ *fakeCSIProxy) {
+				embedCSIProxy("v1.1.0")
+				t.Setenv(csiProxySHA256EnvVar, "")
+			},
+			expectedErr:     "CATTLE_WINS_CSI_PROXY_SHA256 has to be set",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name: "Invalid checksum of the requested version",
+			setup: func(t *testing.T, _ *fakeCSIProxy) {
+				embedCSIProxy("v1.1.0")
+				t.Setenv(csiProxySHA256EnvVar, "1234")
+			},
+			expectedErr:     "is not a hex encoded SHA256 checksum",
+			expectedVersion: "v1.0.0",
+		},
+		{
+			name: "Service stops after upgrade",
+			setup: func(_ *testing.T, svc *fakeCSIProxy) {
+				embedCSIProxy("v1.1.0")
+				svc.crashes = true
+			},
+			expectedErr:   "csiproxy stopped after upgrading CSI Proxy",
+			expectedCalls: []string{"stop", "start"},
+			// the failed upgrade is rolled back with RestoreCSIProxy
+			expectedVersion: "v1.1.0",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			svc, stops := setupCSIProxyTest(t)
+			tst.setup(t, svc)
+
+			decision, restart, err := UpgradeCSIProxy()
+			if tst.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tst.expectedErr) {
+					t.Fatalf("expected error containing '%s', got %v", tst.expectedErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+
+			switch {
+			case tst.expectedAction == "" && decision != nil && tst.expectedErr == "":
+				t.Errorf("expected no decision, got %+v", decision)
+			case tst.expectedAction != "" && decision.Action != tst.expectedAction:
+				t.Errorf("expected action %s, got %s (%s)", tst.expectedAction, decision.Action, decision.Reason)
+			}
+			if restart != tst.expectedRestart {
+				t.Errorf("expected restart to be %t, got %t", tst.expectedRestart, restart)
+			}
+			if !reflect.DeepEqual(svc.calls, tst.expectedCalls) {
+				t.Errorf("expected calls %v to the csiproxy service, got %v", tst.expectedCalls, svc.calls)
+			}
+			if len(tst.expectedCalls) > 0 && *stops != 1 {
+				t.Errorf("expected rancher-wins to be stopped before csiproxy, got %d stops", *stops)
+			}
+			assertCSIProxy(t, svc, tst.expectedVersion, tst.expectedVersion, tst.expectedVersion)
+			if _, err := os.Stat(filepath.Join(GetWinsConfigDir(), "csi-proxy-v1.1.0.exe")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected staged binary to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpgradeCSIProxyServiceNotInstalled(t *testing.T) {
+	svc, stops := setupCSIProxyTest(t)
+	svc.missing = true
+	embedCSIProxy("v1.1.0")
+
+	decision, restart, err := UpgradeCSIProxy()
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	if decision.Action != ActionSkip || !decision.UpdateConfig() {
+		t.Errorf("expected only the config to be updated, got %+v", decision)
+	}
+	if !restart {
+		t.Error("expected rancher-wins to be restarted to install the configured version")
+	}
+	if len(svc.calls) != 0 || *stops != 0 {
+		t.Errorf("expected no service to be stopped, got calls %v and %d stops", svc.calls, *stops)
+	}
+	assertCSIProxy(t, svc, "v1.0.0", "v1.0.0", "v1.1.0")
+}
+
+func TestRestoreCSIProxy(t *testing.T) {
+	svc, stops := setupCSIProxyTest(t)
+	embedCSIProxy("v1.1.0")
+
+	backups, err := BackupCSIProxy()
+	if err != nil {
+		t.Fatalf("encountered unexpected error backing up CSI Proxy: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected the binary and its installation to be backed up, got %+v", backups)
+	}
+
+	// nothing is stopped if CSI Proxy has not been modified
+	if err := RestoreCSIProxy(backups); err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	if len(svc.calls) != 0 || *stops != 0 {
+		t.Fatalf("expected no service to be stopped, got calls %v and %d stops", svc.calls, *stops)
+	}
+
+	if _, _, err := UpgradeCSIProxy(); err != nil {
+		t.Fatalf("encountered unexpected error upgrading CSI Proxy: %v", err)
+	}
+	svc.calls, *stops = nil, 0
+
+	// the config is restored by the undoer of the step along with the rest of the initial state
+	if err := RestoreCSIProxy(backups); err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	assertCSIProxy(t, svc, "v1.0.0", "v1.0.0", "v1.1.0")
+	if expected := []string{"stop", "start"}; !reflect.DeepEqual(svc.calls, expected) || *stops != 1 {
+		t.Errorf("expected calls %v and one stop of rancher-wins, got %v and %d stops", expected, svc.calls, *stops)
+	}
+
+	if err := RemoveBackups(backups); err != nil {
+		t.Fatalf("encountered unexpected error removing backups: %v", err)
+	}
+	for _, backup := range backups {
+		if _, err := os.Stat(backup.BackupPath); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected backup %s to be removed, got %v", backup.BackupPath, err)
+		}
+	}
+}
+
+func TestUpgradeCSIProxyServiceNotInstalledPinned(t *testing.T) {
+	svc, _ := setupCSIProxyTest(t)
+	svc.missing = true
+	embedCSIProxy("v1.1.0")
+	t.Setenv(csiProxySHA256EnvVar, "")
+
+	// rancher-wins would download the archive of the new version without verifying it
+	if _, _, err := UpgradeCSIProxy(); err == nil || !strings.Contains(err.Error(), csiProxySHA256EnvVar) {
+		t.Fatalf("expected the update of a pinned config to require %s, got %v", csiProxySHA256EnvVar, err)
+	}
+	assertCSIProxy(t, svc, "v1.0.0", "v1.0.0", "v1.0.0")
+}
@@ -1,6 +1,12 @@
 package host
 
-import _ "embed"
+import "embed"
 
 //go:embed wins.exe
 var winsBinary []byte
+
+// artifacts optionally contains the CSI Proxy binary installed by the SUC as artifacts/csi-proxy.exe,
+// along with its version in artifacts/csi-proxy.version. They are placed there by the BuildSUC mage target.
+//
+//go:embed all:artifacts
+var artifacts embed.FS
@@ -10,52 +10,151 @@
 	"github.com/sirupsen/logrus"
 )
 
+// Actions of an UpgradeDecision
+const (
+	ActionNone      = "none"
+	ActionSkip      = "skip"
+	ActionInstall   = "install"
+	ActionUpgrade   = "upgrade"
+	ActionDowngrade = "downgrade"
+	ActionRefuse    = "refuse"
+)
+
+// UpgradeDecision records whether an installed binary, e.g. wins.exe, is replaced by the desired version and why
+type UpgradeDecision struct {
+	InstalledVersion string `json:"installedVersion,omitempty"`
+	DesiredVersion   string `json:"desiredVersion"`
+	Action           string `json:"action"`
+	Reason           string `json:"reason"`
+}
+
+// Replace returns whether the installed binary is replaced
+func (d *UpgradeDecision) Replace() bool {
+	switch d.Action {
+	case ActionInstall, ActionUpgrade, ActionDowngrade:
+		return true
+	}
+	return false
+}
+
 // UpgradeRancherWinsBinary will attempt to upgrade the wins.exe binary installed on the host.
 // The version to be installed is embedded within the SUC binary, located in the winsBinary variable.
 // Upgrades will only be attempted if the CATTLE_WINS_SKIP_BINARY_UPGRADE environment variable is not set to 'true' or '$true',
-// and the currently installed version differs from the one embedded (determined by the output of 'wins.exe --version').
+// and the embedded version is newer than the currently installed one (determined by the output of 'wins.exe --version').
+// Older versions are only installed if the CATTLE_WINS_ALLOW_DOWNGRADE environment variable is set to 'true' or '$true'.
+// The decision is logged and recorded in the result of the SUC run.
 // During an upgrade attempt the rancher-wins service will be temporarily stopped.
-// A boolean is returned to indicate if the rancher-wins service needs to be restarted due to a successful upgrade.
-func UpgradeRancherWinsBinary() (bool, error) {
-	if strings.ToLower(os.Getenv(skipBinaryUpgradeEnvVar)) == "true" ||
-		strings.ToLower(os.Getenv(skipBinaryUpgradeEnvVar)) == "$true" {
-		logrus.Warnf("environment variable '%s' was set to true, will not attempt to upgrade binary", skipBinaryUpgradeEnvVar)
-		return false, nil
+// The decision is returned to verify the restarted service with VerifyRancherWins, along with a
+// boolean to indicate if the rancher-wins service needs to be restarted due to a successful upgrade.
+func UpgradeRancherWinsBinary() (*UpgradeDecision, bool, error) {
+	decision, err := DecideUpgrade()
+	if err != nil {
+		return nil, false, err
 	}
 
+	if !decision.Replace() {
+		return decision, false, nil
+	}
+
+	restartService, upgradeErr := updateBinaries(decision.DesiredVersion)
+	if upgradeErr != nil {
+		return decision, false, upgradeErr
+	}
+
+	return decision, restartService, nil
+}
+
+// DecideUpgrade compares the installed wins.exe version with the embedded one and decides whether the installed binary is replaced
+func DecideUpgrade() (*UpgradeDecision, error) {
 	// we use the AppVersion set during compilation to indicate
 	// the version of wins.exe that is packaged in the SUC binary.
 	// See magetools/gotool.go for more information.
 	desiredVersion := defaults.AppVersion
 
+	if envVarTrue(skipBinaryUpgradeEnvVar) {
+		logrus.Warnf("environment variable '%s' was set to true, will not attempt to upgrade binary", skipBinaryUpgradeEnvVar)
+		return &UpgradeDecision{
+			DesiredVersion: desiredVersion,
+			Action:         ActionSkip,
+			Reason:         fmt.Sprintf("%s is set", skipBinaryUpgradeEnvVar),
+		}, nil
+	}
+
 	// We should never install a dirty version of wins.exe onto a host.
 	if strings.Contains(desiredVersion, "-dirty") {
-		return false, fmt.Errorf("will not attempt to upgrade wins.exe version, refusing to install embedded dirty version (version: %s)", desiredVersion)
+		return nil, fmt.Errorf("will not attempt to upgrade wins.exe version, refusing to install embedded dirty version (version: %s)", desiredVersion)
 	}
 
 	binaryExists, err := confirmWinsBinaryIsInstalled()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
+	installedVersion := ""
 	if binaryExists {
-		currentVersion, err := getRancherWinsVersionFromBinary(defaultWinsPath)
+		installedVersion, err = getRancherWinsVersionFromBinary(defaultWinsPath)
 		if err != nil {
-			return false, fmt.Errorf("could not determine current wins.exe version: %w", err)
+			return nil, fmt.Errorf("could not determine current wins.exe version: %w", err)
 		}
+	}
 
-		if currentVersion == desiredVersion {
-			logrus.Debugf("wins.exe is up to date (%s)", currentVersion)
-			return false, nil
-		}
+	decision, err := decideUpgrade("wins.exe", installedVersion, desiredVersion, envVarTrue(allowDowngradeEnvVar))
+	if err != nil {
+		return nil, err
 	}
 
-	restartService, upgradeErr := updateBinaries(desiredVersion)
-	if upgradeErr != nil {
-		return false, upgradeErr
+	if decision.Action == ActionRefuse {
+		logrus.Warnf("Will not replace wins.exe: %s", decision.Reason)
+	} else {
+		logrus.Infof("wins.exe upgrade decision is %s: %s", decision.Action, decision.Reason)
+	}
+	return decision, nil
+}
+
+// decideUpgrade decides whether the installed version of the component is replaced by desiredVersion,
+// installedVersion is empty if the component is not installed
+func decideUpgrade(component, installedVersion, desiredVersion string, allowDowngrade bool) (*UpgradeDecision, error) {
+	decision := &UpgradeDecision{
+		InstalledVersion: installedVersion,
+		DesiredVersion:   desiredVersion,
 	}
 
-	return restartService, nil
+	desired, err := parseVersion(desiredVersion)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse desired %s version: %w", component, err)
+	}
+
+	if installedVersion == "" {
+		decision.Action, decision.Reason = ActionInstall, fmt.Sprintf("%s is not installed", component)
+		return decision, nil
+	}
+
+	if installedVersion == desiredVersion {
+		decision.Action, decision.Reason = ActionNone, fmt.Sprintf("%s is up to date", component)
+		return decision, nil
+	}
+
+	installed, err := parseVersion(installedVersion)
+	if err != nil {
+		decision.Action, decision.Reason = ActionUpgrade, fmt.Sprintf("installed version cannot be compared: %v", err)
+		return decision, nil
+	}
+
+	c, ordered := desired.compare(installed)
+	switch {
+	case !ordered:
+		decision.Action, decision.Reason = ActionUpgrade, "commit builds cannot be ordered, installing the embedded version"
+	case c > 0:
+		decision.Action, decision.Reason = ActionUpgrade, fmt.Sprintf("%s is newer than %s", desired, installed)
+	case c == 0:
+		// versions which only differ in build metadata
+		decision.Action, decision.Reason = ActionNone, fmt.Sprintf("%s has the same precedence as %s", desired, installed)
+	case allowDowngrade:
+		decision.Action, decision.Reason = ActionDowngrade, fmt.Sprintf("%s is older than %s and %s is set", desired, installed, allowDowngradeEnvVar)
+	default:
+		decision.Action, decision.Reason = ActionRefuse, fmt.Sprintf("%s is older than %s, set %s to downgrade", desired, installed, allowDowngradeEnvVar)
+	}
+	return decision, nil
 }
 
 // updateBinaries writes the embedded binary onto the disk in the rancher-wins config directory (c:\etc\rancher\wins, by default).
@@ -66,7 +165,7 @@ func UpgradeRancherWinsBinary() (bool, error) {
 func updateBinaries(desiredVersion string) (bool, error) {
 	logrus.Info("Writing updated wins.exe to disk")
 	// write the embedded binary to disk
-	updatedBinaryPath := fmt.Sprintf("%s/wins-%s.exe", getWinsConfigDir(), strings.Trim(desiredVersion, "\n"))
+	updatedBinaryPath := fmt.Sprintf("%s/wins-%s.exe", GetWinsConfigDir(), strings.Trim(desiredVersion, "\n"))
 	err := os.WriteFile(updatedBinaryPath, winsBinary, os.ModePerm)
 	if err != nil {
 		return false, err
@@ -0,0 +1,124 @@
+package host
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/npipes"
+	"github.com/rancher/wins/pkg/types"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	verifyAttempts = 12
+	verifyTimeout  = 10 * time.Second
+)
+
+// verifyDelay is how long VerifyRancherWins waits between attempts, it is shortened in tests
+var verifyDelay = 5 * time.Second
+
+// dialRancherWins connects to the gRPC API rancher-wins serves on the named pipe, it is replaced by a fake in tests
+var dialRancherWins = func(pipeName string) (types.ApplicationServiceClient, func() error, error) {
+	pipePath := npipes.GetFullPath(pipeName)
+	dialer, err := npipes.NewDialer(pipePath, verifyTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create dialer for %s: %w", pipePath, err)
+	}
+
+	conn, err := grpc.Dial(pipePath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(dialer),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", pipePath, err)
+	}
+	return types.NewApplicationServiceClient(conn), conn.Close, nil
+}
+
+// VerifyRancherWins confirms that the restarted rancher-wins service serves its gRPC API on pipeName.
+// If the binary has been replaced by the embedded one, the version and checksum it reports must match the embedded binary.
+// If the binary was up to date, only the installed version is compared, which may differ from the embedded one in its build metadata. Otherwise, it is only verified that the API responds.
+func VerifyRancherWins(decision *UpgradeDecision, pipeName string) error {
+	if pipeName == "" {
+		pipeName = defaults.NamedPipeName
+	}
+
+	var expected types.ApplicationInfo
+	switch {
+	case decision == nil:
+	case decision.Replace():
+		expected.Version = decision.DesiredVersion
+		expected.Checksum = embeddedBinaryChecksum()
+	case decision.Action == ActionNone:
+		// nothing was installed, so the installed binary is still running
+		expected.Version = decision.InstalledVersion
+	}
+
+	var err error
+	for i := 0; i < verifyAttempts; i++ {
+		if i > 0 {
+			logrus.Debugf("rancher-wins verification attempt failed, waiting %v before reattempting: %v", verifyDelay, err)
+			time.Sleep(verifyDelay)
+		}
+
+		var info *types.ApplicationInfo
+		info, err = queryInfo(pipeName)
+		if err != nil {
+			continue
+		}
+		if err := compareInfo(info, &expected); err != nil {
+			// a running rancher-wins reporting the wrong binary does not change by waiting
+			return err
+		}
+		logrus.Infof("Verified that %s serves version %s (checksum %s)", defaults.WindowsServiceName, info.Version, info.Checksum)
+		return nil
+	}
+	return fmt.Errorf("%s did not respond on pipe %s after %d attempts: %w", defaults.WindowsServiceName, pipeName, verifyAttempts, err)
+}
+
+func queryInfo(pipeName string) (*types.ApplicationInfo, error) {
+	client, closeConn, err := dialRancherWins(pipeName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := closeConn(); err != nil {
+			logrus.Debugf("failed to close connection to %s: %v", defaults.WindowsServiceName, err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	resp, err := client.Info(ctx, &types.Void{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s info: %w", defaults.WindowsServiceName, err)
+	}
+	if resp.Info == nil {
+		return nil, fmt.Errorf("%s returned empty info", defaults.WindowsServiceName)
+	}
+	return resp.Info, nil
+}
+
+// compareInfo compares the info reported by rancher-wins with the expected fields that are set
+func compareInfo(actual, expected *types.ApplicationInfo) error {
+	if expected.Version != "" && actual.Version != expected.Version {
+		return fmt.Errorf("%s reported version '%s', expected '%s'", defaults.WindowsServiceName, actual.Version, expected.Version)
+	}
+	if expected.Checksum != "" && actual.Checksum != expected.Checksum {
+		return fmt.Errorf("%s reported checksum '%s', expected '%s' of the embedded binary", defaults.WindowsServiceName, actual.Checksum, expected.Checksum)
+	}
+	return nil
+}
+
+// embeddedBinaryChecksum returns the checksum of the embedded binary the way rancher-wins reports the checksum of its own binary
+func embeddedBinaryChecksum() string {
+	sum := sha1.Sum(winsBinary)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,119 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rancher/wins/pkg/types"
+	"google.golang.org/grpc"
+)
+
+// fakeApplicationClient answers Info with info once it has failed the given number of times
+type fakeApplicationClient struct {
+	types.ApplicationServiceClient
+	info     *types.ApplicationInfo
+	failures int
+	calls    int
+}
+
+func (f *fakeApplicationClient) Info(context.Context, *types.Void, ...grpc.CallOption) (*types.ApplicationInfoResponse, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("pipe not found")
+	}
+	return &types.ApplicationInfoResponse{Info: f.info}, nil
+}
+
+func TestVerifyRancherWins(t *testing.T) {
+	checksum := embeddedBinaryChecksum()
+
+	type test struct {
+		name        string
+		decision    *UpgradeDecision
+		info        *types.ApplicationInfo
+		failures    int
+		expectedErr string
+	}
+
+	tests := []test{
+		{
+			name:     "Upgraded",
+			decision: &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionUpgrade},
+			info:     &types.ApplicationInfo{Version: "v0.4.21", Checksum: checksum},
+		},
+		{
+			name:     "Serving after restart",
+			decision: &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionUpgrade},
+			info:     &types.ApplicationInfo{Version: "v0.4.21", Checksum: checksum},
+			failures: 2,
+		},
+		{
+			name:        "Not serving",
+			decision:    &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionUpgrade},
+			failures:    verifyAttempts,
+			expectedErr: "did not respond",
+		},
+		{
+			name:        "Old version still running",
+			decision:    &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionUpgrade},
+			info:        &types.ApplicationInfo{Version: "v0.4.20", Checksum: "0123"},
+			expectedErr: "reported version 'v0.4.20'",
+		},
+		{
+			name:        "Checksum mismatch",
+			decision:    &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionUpgrade},
+			info:        &types.ApplicationInfo{Version: "v0.4.21", Checksum: "0123"},
+			expectedErr: "reported checksum '0123'",
+		},
+		{
+			name:     "Up to date binary is not compared by checksum",
+			decision: &UpgradeDecision{InstalledVersion: "v0.4.21", DesiredVersion: "v0.4.21", Action: ActionNone},
+			info:     &types.ApplicationInfo{Version: "v0.4.21", Checksum: "0123"},
+		},
+		{
+			name:     "Up to date binary differing in build metadata",
+			decision: &UpgradeDecision{InstalledVersion: "v0.4.21+build.1", DesiredVersion: "v0.4.21+build.2", Action: ActionNone},
+			info:     &types.ApplicationInfo{Version: "v0.4.21+build.1", Checksum: "0123"},
+		},
+		{
+			name:        "Up to date binary replaced by another version",
+			decision:    &UpgradeDecision{InstalledVersion: "v0.4.21+build.1", DesiredVersion: "v0.4.21+build.2", Action: ActionNone},
+			info:        &types.ApplicationInfo{Version: "v0.4.21+build.2", Checksum: "0123"},
+			expectedErr: "reported version 'v0.4.21+build.2', expected 'v0.4.21+build.1'",
+		},
+		{
+			name:     "Upgrade skipped",
+			decision: &UpgradeDecision{DesiredVersion: "v0.4.21", Action: ActionSkip},
+			info:     &types.ApplicationInfo{Version: "v0.4.19", Checksum: "0123"},
+		},
+	}
+
+	previousDial, previousDelay := dialRancherWins, verifyDelay
+	defer func() { dialRancherWins, verifyDelay = previousDial, previousDelay }()
+	verifyDelay = 0
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			client := &fakeApplicationClient{info: tst.info, failures: tst.failures}
+			dialRancherWins = func(pipeName string) (types.ApplicationServiceClient, func() error, error) {
+				if pipeName != "rancher_wins" {
+					t.Errorf("expected default pipe, got %s", pipeName)
+				}
+				return client, func() error { return nil }, nil
+			}
+
+			err := VerifyRancherWins(tst.decision, "")
+			if tst.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("encountered unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tst.expectedErr) {
+				t.Fatalf("expected error containing '%s', got %v", tst.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package host
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	semverRegexp = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+	commitRegexp = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+// winsVersion is a version reported by 'wins.exe --version', either a semantic version like v0.4.20 or v0.4.20-rc.1,
+// or the commit hash of a build that has not been tagged. Commit builds cannot be ordered.
+type winsVersion struct {
+	raw        string
+	major      uint64
+	minor      uint64
+	patch      uint64
+	preRelease []string
+	commit     string
+}
+
+func (v winsVersion) String() string {
+	return v.raw
+}
+
+// parseVersion parses a version returned by parseWinsVersion or set as defaults.AppVersion
+func parseVersion(version string) (winsVersion, error) {
+	version = strings.TrimSpace(version)
+	if commitRegexp.MatchString(version) {
+		return winsVersion{raw: version, commit: version}, nil
+	}
+
+	m := semverRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return winsVersion{}, fmt.Errorf("'%s' is neither a semantic version nor a commit hash", version)
+	}
+
+	v := winsVersion{raw: version}
+	for i, p := range []*uint64{&v.major, &v.minor, &v.patch} {
+		n, err := strconv.ParseUint(m[i+1], 10, 64)
+		if err != nil {
+			return winsVersion{}, fmt.Errorf("invalid version '%s': %w", version, err)
+		}
+		*p = n
+	}
+	if m[4] != "" {
+		v.preRelease = strings.Split(m[4], ".")
+	}
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 if v is older than, equal to or newer than o by semantic version precedence.
+// The boolean is false if either version is a commit build, as they cannot be ordered.
+func (v winsVersion) compare(o winsVersion) (int, bool) {
+	if v.commit != "" || o.commit != "" {
+		return 0, false
+	}
+
+	for _, c := range [][2]uint64{{v.major, o.major}, {v.minor, o.minor}, {v.patch, o.patch}} {
+		if c[0] != c[1] {
+			if c[0] < c[1] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+
+	// a release is newer than any of its pre-releases
+	switch {
+	case len(v.preRelease) == 0 && len(o.preRelease) == 0:
+		return 0, true
+	case len(v.preRelease) == 0:
+		return 1, true
+	case len(o.preRelease) == 0:
+		return -1, true
+	}
+
+	for i := 0; i < len(v.preRelease) && i < len(o.preRelease); i++ {
+		if c := comparePreRelease(v.preRelease[i], o.preRelease[i]); c != 0 {
+			return c, true
+		}
+	}
+	switch {
+	case len(v.preRelease) < len(o.preRelease):
+		return -1, true
+	case len(v.preRelease) > len(o.preRelease):
+		return 1, true
+	}
+	return 0, true
+}
+
+// comparePreRelease compares pre-release identifiers, numeric identifiers are compared numerically
+// and have lower precedence than alphanumeric ones
+func comparePreRelease(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,132 @@
+package host
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	type test struct {
+		a, b     string
+		expected int
+		ordered  bool
+	}
+
+	tests := []test{
+		{a: "v0.4.20", b: "v0.4.20", expected: 0, ordered: true},
+		{a: "v0.4.21", b: "v0.4.20", expected: 1, ordered: true},
+		{a: "v0.4.9", b: "v0.4.10", expected: -1, ordered: true},
+		{a: "v1.0.0", b: "v0.99.99", expected: 1, ordered: true},
+		{a: "v0.4.20", b: "v0.4.20-rc.3", expected: 1, ordered: true},
+		{a: "v0.4.20-rc.2", b: "v0.4.20-rc.10", expected: -1, ordered: true},
+		{a: "v0.4.20-rc.1", b: "v0.4.20-alpha.1", expected: 1, ordered: true},
+		{a: "v0.4.20-rc", b: "v0.4.20-rc.1", expected: -1, ordered: true},
+		{a: "v0.4.20-1", b: "v0.4.20-rc", expected: -1, ordered: true},
+		{a: "v0.4.20+build.1", b: "v0.4.20", expected: 0, ordered: true},
+		{a: "06685df", b: "v0.4.20", ordered: false},
+		{a: "v0.4.20", b: "06685df", ordered: false},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.a+" "+tst.b, func(t *testing.T) {
+			a, err := parseVersion(tst.a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := parseVersion(tst.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, ordered := a.compare(b)
+			if ordered != tst.ordered || c != tst.expected {
+				t.Fatalf("expected compare to return (%d, %t), got (%d, %t)", tst.expected, tst.ordered, c, ordered)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "dev", "v0.4", "v0.4.20-", "v01.4.20", "06685df-dirty"} {
+		if _, err := parseVersion(invalid); err == nil {
+			t.Errorf("expected '%s' to be rejected", invalid)
+		}
+	}
+}
+
+func TestDecideUpgrade(t *testing.T) {
+	type test struct {
+		name             string
+		installedVersion string
+		desiredVersion   string
+		allowDowngrade   bool
+		expectedAction   string
+		errExpected      bool
+	}
+
+	tests := []test{
+		{
+			name:           "Not installed",
+			desiredVersion: "v0.4.20",
+			expectedAction: ActionInstall,
+		},
+		{
+			name:             "Up to date",
+			installedVersion: "v0.4.20",
+			desiredVersion:   "v0.4.20",
+			expectedAction:   ActionNone,
+		},
+		{
+			name:             "Newer version",
+			installedVersion: "v0.4.20-rc.1",
+			desiredVersion:   "v0.4.20",
+			expectedAction:   ActionUpgrade,
+		},
+		{
+			name:             "Older version",
+			installedVersion: "v0.4.21",
+			desiredVersion:   "v0.4.20",
+			expectedAction:   ActionRefuse,
+		},
+		{
+			name:             "Older version with downgrades allowed",
+			installedVersion: "v0.4.21",
+			desiredVersion:   "v0.4.20",
+			allowDowngrade:   true,
+			expectedAction:   ActionDowngrade,
+		},
+		{
+			name:             "Commit build",
+			installedVersion: "v0.4.21",
+			desiredVersion:   "06685df",
+			expectedAction:   ActionUpgrade,
+		},
+		{
+			name:             "Unparsable installed version",
+			installedVersion: "dev",
+			desiredVersion:   "v0.4.20",
+			expectedAction:   ActionUpgrade,
+		},
+		{
+			name:             "Unparsable embedded version",
+			installedVersion: "v0.4.20",
+			desiredVersion:   "dev",
+			errExpected:      true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			decision, err := decideUpgrade("wins.exe", tst.installedVersion, tst.desiredVersion, tst.allowDowngrade)
+			if tst.errExpected {
+				if err == nil {
+					t.Fatalf("expected an error, got decision %+v", decision)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			if decision.Action != tst.expectedAction {
+				t.Fatalf("expected action %s, got %s (%s)", tst.expectedAction, decision.Action, decision.Reason)
+			}
+			if decision.Reason == "" {
+				t.Error("expected decision to have a reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FileName is the name of the lock file in the rancher-wins config directory, install.ps1 uses the same file
+	FileName = "suc.lock"
+
+	// StaleAfter is how long a lock is held at most, a run of the SUC or install.ps1 takes a few minutes
+	StaleAfter = time.Hour
+)
+
+var (
+	// now and processRunning are replaced by fakes in tests
+	now            = time.Now
+	processRunning = running
+)
+
+// Owner identifies the process holding the lock, it is the content of the lock file
+type Owner struct {
+	PID        int       `json:"pid"`
+	Hostname   string    `json:"hostname"`
+	Command    string    `json:"command"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+func (o Owner) String() string {
+	if o.PID == 0 {
+		return fmt.Sprintf("an unknown process since %s", o.AcquiredAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("%s (pid %d on %s) since %s", o.Command, o.PID, o.Hostname, o.AcquiredAt.Format(time.RFC3339))
+}
+
+// HeldError is returned by Acquire if another process holds the lock
+type HeldError struct {
+	Path  string
+	Owner Owner
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("lock %s is held by %s", e.Path, e.Owner)
+}
+
+// Lock is a lock file held by this process
+type Lock struct {
+	path  string
+	owner Owner
+}
+
+// Acquire creates the lock file at path, recording this process as its owner. If the lock file exists
+// and its owner is no longer running or has held it for longer than StaleAfter, the lock is taken over.
+// Otherwise, a HeldError is returned.
+func Acquire(path, command string) (*Lock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine hostname: %w", err)
+	}
+	owner := Owner{
+		PID:        os.Getpid(),
+		Hostname:   hostname,
+		Command:    command,
+		AcquiredAt: now().UTC(),
+	}
+	content, err := json.Marshal(owner)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode lock owner: %w", err)
+	}
+
+	// a stale lock is only removed once, if creating the lock file fails again another process was faster
+	var held Owner
+	for attempt := 0; attempt < 2; attempt++ {
+		err := create(path, content)
+		if err == nil {
+			logrus.Infof("Acquired lock %s", path)
+			return &Lock{path: path, owner: owner}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("could not create lock file %s: %w", path, err)
+		}
+
+		held, err = readOwner(path)
+		if errors.Is(err, os.ErrNotExist) {
+			// released in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		reason := staleReason(held, hostname)
+		if reason == "" {
+			break
+		}
+		logrus.Warnf("Removing stale lock %s held by %s: %s", path, held, reason)
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("could not remove stale lock file %s: %w", path, err)
+		}
+	}
+	return nil, &HeldError{Path: path, Owner: held}
+}
+
+// Release removes the lock file, unless the lock has been taken over by another process in the meantime
+func (l *Lock) Release() error {
+	held, err := readOwner(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Warnf("Lock %s has been removed while it was held", l.path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if held.PID != l.owner.PID || !held.AcquiredAt.Equal(l.owner.AcquiredAt) {
+		logrus.Warnf("Lock %s has been taken over by %s, leaving it in place", l.path, held)
+		return nil
+	}
+
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("could not remove lock file %s: %w", l.path, err)
+	}
+	logrus.Infof("Released lock %s", l.path)
+	return nil
+}
+
+// create writes content to a new file at path, failing with os.ErrExist if the file exists
+func create(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(err, os.Remove(path))
+	}
+	return nil
+}
+
+// readOwner reads the owner of the lock file at path. A lock file that cannot be decoded, e.g. because
+// its owner has not finished writing it, belongs to an unknown process since the file was last modified.
+func readOwner(path string) (Owner, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Owner{}, fmt.Errorf("could not read lock file %s: %w", path, err)
+	}
+
+	var owner Owner
+	if err := json.Unmarshal(content, &owner); err == nil && !owner.AcquiredAt.IsZero() {
+		return owner, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Owner{}, fmt.Errorf("could not stat lock file %s: %w", path, err)
+	}
+	return Owner{AcquiredAt: info.ModTime().UTC()}, nil
+}
+
+// staleReason returns why the lock held by owner is stale, or an empty string if it is not
+func staleReason(owner Owner, hostname string) string {
+	if age := now().Sub(owner.AcquiredAt); age > StaleAfter {
+		return fmt.Sprintf("it has been held for %s", age.Round(time.Second))
+	}
+	// processes of another host, e.g. sharing the config directory, cannot be looked up
+	if owner.PID == 0 || owner.Hostname != hostname {
+		return ""
+	}
+
+	ok, err := processRunning(owner.PID)
+	if err != nil {
+		logrus.Warnf("Could not determine whether process %d holding the lock is running: %v", owner.PID, err)
+		return ""
+	}
+	if !ok {
+		return fmt.Sprintf("process %d is not running", owner.PID)
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatalf("could not determine hostname: %v", err)
+	}
+
+	type test struct {
+		name string
+		// content of the lock file before Acquire is called, no lock file is created if empty
+		content       string
+		modTime       time.Time
+		ownerRunning  bool
+		expectedHeld  bool
+		expectedOwner Owner
+	}
+
+	ownerContent := func(owner Owner) string {
+		b, err := json.Marshal(owner)
+		if err != nil {
+			t.Fatalf("could not encode owner: %v", err)
+		}
+		return string(b)
+	}
+	install := Owner{PID: 4242, Hostname: hostname, Command: "install.ps1", AcquiredAt: start.Add(-10 * time.Minute)}
+
+	tests := []test{
+		{
+			name: "Not held",
+		},
+		{
+			name:          "Held by running process",
+			content:       ownerContent(install),
+			ownerRunning:  true,
+			expectedHeld:  true,
+			expectedOwner: install,
+		},
+		{
+			name:    "Held by process that is not running",
+			content: ownerContent(install),
+		},
+		{
+			name: "Held for too long",
+			content: ownerContent(Owner{
+				PID: 4242, Hostname: hostname, Command: "install.ps1", AcquiredAt: start.Add(-2 * StaleAfter),
+			}),
+			ownerRunning: true,
+		},
+		{
+			name: "Held by process of another host",
+			content: ownerContent(Owner{
+				PID: 4242, Hostname: "other", Command: "install.ps1", AcquiredAt: start.Add(-10 * time.Minute),
+			}),
+			expectedHeld:  true,
+			expectedOwner: Owner{PID: 4242, Hostname: "other", Command: "install.ps1", AcquiredAt: start.Add(-10 * time.Minute)},
+		},
+		{
+			name:          "Being written",
+			content:       "{",
+			modTime:       start.Add(-time.Second),
+			expectedHeld:  true,
+			expectedOwner: Owner{AcquiredAt: start.Add(-time.Second)},
+		},
+		{
+			name:    "Left behind unreadable",
+			content: "{",
+			modTime: start.Add(-2 * StaleAfter),
+		},
+	}
+
+	previousNow, previousProcessRunning := now, processRunning
+	defer func() { now, processRunning = previousNow, previousProcessRunning }()
+	now = func() time.Time { return start }
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), FileName)
+			if tst.content != "" {
+				if err := os.WriteFile(path, []byte(tst.content), 0644); err != nil {
+					t.Fatalf("could not write lock file: %v", err)
+				}
+				if !tst.modTime.IsZero() {
+					if err := os.Chtimes(path, tst.modTime, tst.modTime); err != nil {
+						t.Fatalf("could not set modification time of lock file: %v", err)
+					}
+				}
+			}
+			processRunning = func(pid int) (bool, error) {
+				if pid != install.PID {
+					t.Errorf("looked up unexpected process %d", pid)
+				}
+				return tst.ownerRunning, nil
+			}
+
+			l, err := Acquire(path, "rancher-wins-suc")
+			if tst.expectedHeld {
+				var heldErr *HeldError
+				if !errors.As(err, &heldErr) {
+					t.Fatalf("expected lock to be held, got %v", err)
+				}
+				if heldErr.Owner != tst.expectedOwner {
+					t.Fatalf("expected lock to be held by %+v, got %+v", tst.expectedOwner, heldErr.Owner)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+
+			owner, err := readOwner(path)
+			if err != nil {
+				t.Fatalf("could not read lock file: %v", err)
+			}
+			expected := Owner{PID: os.Getpid(), Hostname: hostname, Command: "rancher-wins-suc", AcquiredAt: start}
+			if owner != expected {
+				t.Fatalf("expected lock to be held by %+v, got %+v", expected, owner)
+			}
+
+			if err := l.Release(); err != nil {
+				t.Fatalf("encountered unexpected error releasing lock: %v", err)
+			}
+			if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected lock file to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReleaseTakenOver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	l, err := Acquire(path, "rancher-wins-suc")
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+
+	// another process considered the lock stale and took it over
+	takenOver, err := json.Marshal(Owner{PID: 4242, Hostname: "other", Command: "install.ps1", AcquiredAt: time.Now()})
+	if err != nil {
+		t.Fatalf("could not encode owner: %v", err)
+	}
+	if err := os.WriteFile(path, takenOver, 0644); err != nil {
+		t.Fatalf("could not write lock file: %v", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("encountered unexpected error releasing lock: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected lock file of the other process to be left in place, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package rancher
+
+import (
+	"context"
+	"time"
+
+	"github.com/rancher/wins/cmd/server/config"
+	"github.com/rancher/wins/pkg/systemagent/connection"
+	"github.com/sirupsen/logrus"
+)
+
+const connCheckTimeout = 30 * time.Second
+
+// CheckConnection checks whether the system agent can connect to Rancher with the connection info configured in cfg.
+// A failing check is only logged, the system agent keeps retrying to connect on its own.
+func CheckConnection(cfg *config.Config) {
+	if cfg.SystemAgent == nil || !cfg.SystemAgent.RemoteEnabled {
+		logrus.Debug("Remote plans are not enabled, will not check the Rancher connection information")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connCheckTimeout)
+	defer cancel()
+
+	result := connection.Check(ctx, cfg.SystemAgent.ConnectionInfoFile, cfg.AgentStrictTLSMode)
+	for _, step := range result.Steps {
+		if step.Error != "" {
+			logrus.Errorf("Rancher connection check failed at step '%s': %s", step.Name, step.Error)
+			continue
+		}
+		logrus.Debugf("Rancher connection check step '%s' succeeded: %s", step.Name, step.Message)
+	}
+	if result.Err() == nil {
+		logrus.Infof("Successfully checked the connection to Rancher at %s", result.Server)
+	}
+}
@@ -0,0 +1,107 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/suc/pkg/host"
+	"github.com/sirupsen/logrus"
+)
+
+// ResultFileName is the name of the file in the rancher-wins config directory the result of the last run is written to
+const ResultFileName = "suc-result.json"
+
+// Outcomes of a Step
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeSkipped   = "skipped"
+)
+
+// Result describes what a run of the SUC did to the host, it is written as JSON once the run finished
+type Result struct {
+	SUCVersion        string                 `json:"sucVersion"`
+	StartedAt         time.Time              `json:"startedAt"`
+	FinishedAt        time.Time              `json:"finishedAt"`
+	Success           bool                   `json:"success"`
+	Error             string                 `json:"error,omitempty"`
+	Steps             []Step                 `json:"steps"`
+	VersionBefore     string                 `json:"versionBefore,omitempty"`
+	VersionAfter      string                 `json:"versionAfter,omitempty"`
+	Binary            *host.UpgradeDecision  `json:"binary,omitempty"`
+	CSIProxy          *host.CSIProxyDecision `json:"csiProxy,omitempty"`
+	ConfigChanged     []string               `json:"configChanged,omitempty"`
+	Restarted         bool                   `json:"restarted"`
+	RolledBack        bool                   `json:"rolledBack"`
+	RollbackSucceeded bool                   `json:"rollbackSucceeded"`
+}
+
+// Step is a step of the run and its outcome
+type Step struct {
+	Name    string `json:"name"`
+	Outcome string `json:"outcome"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func newResult() *Result {
+	return &Result{
+		SUCVersion: defaults.AppVersion,
+		StartedAt:  time.Now(),
+	}
+}
+
+// step runs fn as the step called name and records its outcome
+func (r *Result) step(name string, fn func() error) error {
+	err := fn()
+	s := Step{Name: name, Outcome: OutcomeSucceeded}
+	if err != nil {
+		s.Outcome, s.Error = OutcomeFailed, err.Error()
+	}
+	r.Steps = append(r.Steps, s)
+	return err
+}
+
+// skip records that the step called name was not needed
+func (r *Result) skip(name, reason string) {
+	r.Steps = append(r.Steps, Step{Name: name, Outcome: OutcomeSkipped, Message: reason})
+}
+
+// finish records the error the run finished with and the version of wins.exe that is installed now
+func (r *Result) finish(err error) {
+	r.FinishedAt = time.Now()
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	version, versionErr := host.InstalledVersion()
+	if versionErr != nil {
+		logrus.Warnf("Could not determine the installed wins.exe version for the result: %v", versionErr)
+		return
+	}
+	r.VersionAfter = version
+}
+
+// write writes the result to w and to the ResultFileName in dir
+func (r *Result) write(w io.Writer, dir string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal result: %w", err)
+	}
+	b = append(b, '\n')
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("could not print result: %w", err)
+	}
+	path := filepath.Join(dir, ResultFileName)
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("could not write result to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	result := newResult()
+	if err := result.step("update config", func() error { return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result.skip("restart rancher-wins", "neither the config nor the binary changed")
+	if err := result.step("verify rancher-wins", func() error { return errors.New("pipe not found") }); err == nil {
+		t.Fatal("expected the error of the step to be returned")
+	}
+	result.finish(errors.New("rancher-wins failed verification after restart"))
+
+	expectedSteps := []Step{
+		{Name: "update config", Outcome: OutcomeSucceeded},
+		{Name: "restart rancher-wins", Outcome: OutcomeSkipped, Message: "neither the config nor the binary changed"},
+		{Name: "verify rancher-wins", Outcome: OutcomeFailed, Error: "pipe not found"},
+	}
+	if !reflect.DeepEqual(result.Steps, expectedSteps) {
+		t.Errorf("expected steps %+v, got %+v", expectedSteps, result.Steps)
+	}
+	if result.Success || result.Error == "" || result.FinishedAt.Before(result.StartedAt) {
+		t.Errorf("expected failed result, got %+v", result)
+	}
+
+	var buf bytes.Buffer
+	dir := t.TempDir()
+	if err := result.write(&buf, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written, err := os.ReadFile(filepath.Join(dir, ResultFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, buf.Bytes()) {
+		t.Errorf("expected the printed and written result to match, got\n%s\nand\n%s", buf.String(), written)
+	}
+
+	var decoded Result
+	if err := json.Unmarshal(written, &decoded); err != nil {
+		t.Fatalf("expected result to be valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Steps, expectedSteps) || decoded.Error != result.Error {
+		t.Errorf("expected decoded result to match, got %+v", decoded)
+	}
+}
@@ -6,7 +6,6 @@
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sys/windows/svc"
 )
 
 func getStateTransitionAttempts() int {
@@ -64,19 +63,3 @@ func removeAllFromSlice[T comparable](x T, s []T) []T {
 	}
 	return n
 }
-
-// serviceStateToString translates a svc.State to its string representation
-func serviceStateToString(state svc.State) string {
-	switch state {
-	case svc.Running:
-		return "Running"
-	case svc.Stopped:
-		return "Stopped"
-	case svc.StopPending:
-		return "Stop Pending"
-	case svc.StartPending:
-		return "Start Pending"
-	default:
-		return "Unknown State"
-	}
-}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/rancher/wins/cmd/server/config"
@@ -13,8 +15,25 @@
 	DirEnvVar            = "CATTLE_WINS_CONFIG_DIR"
 	DebugEnvVar          = "CATTLE_WINS_DEBUG"
 	AgentStringTLSEnvVar = "STRICT_VERIFY"
+	// SetEnvVarPrefix is the prefix of the environment variables setting a single config field, nested fields are
+	// separated by a double underscore, e.g. CATTLE_WINS_CONFIG_SET_CSI_PROXY__VERSION sets csi-proxy.version
+	SetEnvVarPrefix = "CATTLE_WINS_CONFIG_SET_"
+	// PatchEnvVar holds a yaml or json document which is merged into the config
+	PatchEnvVar = "CATTLE_WINS_CONFIG_PATCH"
 )
 
+// AllowedPaths are the config fields that can be changed by the SetEnvVarPrefix and PatchEnvVar environment
+// variables, along with the fields nested within them. The systemagent block is managed by Rancher and the
+// pipes are relied upon by clients, so they cannot be changed.
+var AllowedPaths = []string{
+	"debug",
+	"agentStrictTLSMode",
+	"white_list",
+	"csi-proxy",
+	"tls-config",
+	"services",
+}
+
 const (
 	defaultConfigFile = "c:/etc/rancher/wins/config"
 )
@@ -53,47 +72,126 @@ func SaveConfig(cfg *config.Config, path string) error {
 // based off of the presence of particular environment variables. The path parameter is used
 // to specify the location of the config file on the host. If path is left empty, the defaultConfigFile
 // constant is used. The config file will only be updated if a given environment variable is present, and its
-// value does not equal the currently set value in the config file. UpdateConfigFromEnvVars returns a boolean
-// indicating if the config file has been updated and any errors encountered.
-func UpdateConfigFromEnvVars() (bool, error) {
-	logrus.Info("Loading config from host")
+// value does not equal the currently set value in the config file.
+//
+// After the DebugEnvVar and AgentStringTLSEnvVar variables, the document in PatchEnvVar is merged into the config,
+// followed by the fields set by the SetEnvVarPrefix variables in lexical order of their names. These may only change
+// the AllowedPaths. UpdateConfigFromEnvVars returns the paths of the config fields that have been changed and any errors
+// encountered, the config file is not updated if any of the variables is invalid.
+func UpdateConfigFromEnvVars() ([]string, error) {
 	path := getConfigPath("")
+	cfg, changed, err := ConfigFromEnvVars(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we haven't made any changes there is no reason to update the config file
+	if len(changed) > 0 {
+		logrus.Infof("Detected a change in configuration of %v, updating config file", changed)
+		err = SaveConfig(cfg, path)
+		if err != nil {
+			return changed, fmt.Errorf("failed to save config: %w", err)
+		}
+	} else {
+		logrus.Info("Did not detect a change in configuration")
+	}
+
+	return changed, nil
+}
+
+// ConfigFromEnvVars loads the config file at path and applies the environment variables to it the way
+// UpdateConfigFromEnvVars does, without saving it. The updated config is returned along with the paths of the changed fields.
+func ConfigFromEnvVars(path string) (*config.Config, []string, error) {
+	logrus.Info("Loading config from host")
 	cfg, err := LoadConfig(path)
 	if err != nil {
-		return false, fmt.Errorf("failed to load config: %v", err)
+		return nil, nil, fmt.Errorf("failed to load config: %v", err)
 	}
+	// the fields are changed one variable at a time, the loaded config is compared with the final one so
+	// that a field set back to its loaded value is not reported as changed
+	loaded := *cfg
 
-	configNeedsUpdate := false
 	logrus.Infof("Checking the %s value. This is a boolean flag, expecting 'true' or 'false'", DebugEnvVar)
 
 	v := os.Getenv(DebugEnvVar)
 	logrus.Infof("Found value '%s' for %s", v, DebugEnvVar)
-	givenBool := strings.ToLower(v) == "true"
-	if cfg.Debug != givenBool {
-		cfg.Debug = givenBool
-		configNeedsUpdate = true
-	}
+	cfg.Debug = strings.ToLower(v) == "true"
 
 	logrus.Infof("Checking the %s value. This is a boolean flag, expecting 'true' or 'false'", AgentStringTLSEnvVar)
 	if v := os.Getenv(AgentStringTLSEnvVar); v != "" {
 		logrus.Infof("Found value '%s' for %s", v, AgentStringTLSEnvVar)
-		givenBool = strings.ToLower(v) == "true"
-		if cfg.AgentStrictTLSMode != givenBool {
-			cfg.AgentStrictTLSMode = givenBool
-			configNeedsUpdate = true
+		cfg.AgentStrictTLSMode = strings.ToLower(v) == "true"
+	}
+
+	patches, err := patchesFromEnvVars()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(patches) > 0 {
+		if _, err := config.ApplyPatches(cfg, patches...); err != nil {
+			return nil, nil, fmt.Errorf("failed to apply config changes from environment variables: %w", err)
 		}
 	}
 
-	// If we haven't made any changes there is no reason to update the config file
-	if configNeedsUpdate {
-		logrus.Info("Detected a change in configuration, updating config file")
-		err = SaveConfig(cfg, path)
+	return cfg, config.ChangedFields(&loaded, cfg), nil
+}
+
+// patchesFromEnvVars parses the PatchEnvVar and SetEnvVarPrefix environment variables,
+// every invalid variable and every field outside of the AllowedPaths is reported
+func patchesFromEnvVars() ([]config.Patch, error) {
+	var patches []config.Patch
+	var errs []error
+
+	if v := os.Getenv(PatchEnvVar); v != "" {
+		logrus.Infof("Found config patch in %s", PatchEnvVar)
+		patch, err := config.ParsePatch(PatchEnvVar, []byte(v))
 		if err != nil {
-			return configNeedsUpdate, fmt.Errorf("failed to save config: %w", err)
+			errs = append(errs, err)
+		} else {
+			patches = append(patches, patch)
 		}
-	} else {
-		logrus.Info("Did not detect a change in configuration")
 	}
 
-	return configNeedsUpdate, nil
+	var names []string
+	values := map[string]string{}
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if len(name) > len(SetEnvVarPrefix) && strings.EqualFold(name[:len(SetEnvVarPrefix)], SetEnvVarPrefix) {
+			names = append(names, name)
+			values[name] = value
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		patch, err := config.FieldPatch(name, name[len(SetEnvVarPrefix):], values[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// the value is not logged, it may hold a secret such as credentials embedded into a download URL
+		logrus.Infof("Found a value for %v in %s", patch.Paths(), name)
+		patches = append(patches, patch)
+	}
+
+	for _, patch := range patches {
+		for _, path := range patch.Paths() {
+			if !pathAllowed(path) {
+				errs = append(errs, fmt.Errorf("%s: field %s cannot be changed, allowed fields are %v", patch.Source, path, AllowedPaths))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return patches, nil
+}
+
+// pathAllowed returns whether path is one of the AllowedPaths or a field nested within one of them
+func pathAllowed(path string) bool {
+	for _, allowed := range AllowedPaths {
+		if path == allowed || strings.HasPrefix(path, allowed+".") {
+			return true
+		}
+	}
+	return false
 }
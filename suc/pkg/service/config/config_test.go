@@ -30,7 +30,7 @@ func setupTest(vars []v1.EnvVar, t *testing.T) {
 
 	configFileLoc = fmt.Sprintf("%s\\wins-test-config", h)
 	for _, evar := range os.Environ() {
-		if !strings.Contains(evar, "CATTLE") && evar != "STRICT_VERIFY" {
+		if !strings.Contains(evar, "CATTLE") && !strings.HasPrefix(evar, AgentStringTLSEnvVar+"=") {
 			continue
 		}
 		err := os.Unsetenv(strings.Split(evar, "=")[0])
@@ -122,6 +122,26 @@ type test struct {
 			},
 			updateExpected: true,
 		},
+		{
+			name: "Update fields from patch and set env vars",
+			envVars: []v1.EnvVar{
+				{
+					Name:  PatchEnvVar,
+					Value: "white_list:\n  proxyPorts: [9796]\n  processPaths: [c:/etc/rancher/wins/powershell.exe]",
+				},
+				{
+					Name:  SetEnvVarPrefix + "WHITE_LIST__PROXY_PORTS",
+					Value: "9100,9796",
+				},
+			},
+			expectedConfig: func() *config.Config {
+				def := config.DefaultConfig()
+				def.WhiteList.ProxyPorts = []int{9100, 9796}
+				def.WhiteList.ProcessPaths = []string{"c:/etc/rancher/wins/powershell.exe"}
+				return def
+			},
+			updateExpected: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -129,13 +149,13 @@ type test struct {
 			setupTest(tc.envVars, t)
 			expectedConfig := tc.expectedConfig()
 
-			updated, err := UpdateConfigFromEnvVars()
+			changed, err := UpdateConfigFromEnvVars()
 			if err != nil {
 				t.Logf("UpdateConfigFromEnvVars returned an unexpected error: %v", err)
 				t.FailNow()
 			}
 
-			if updated && !tc.updateExpected {
+			if len(changed) > 0 && !tc.updateExpected {
 				j, _ := json.MarshalIndent(os.Environ(), "", " ")
 				t.Logf("Config was updated unexpectedly when the following env vars were used: %s", string(j))
 				t.FailNow()
@@ -157,3 +177,85 @@ type test struct {
 		})
 	}
 }
+
+func Test_UpdateConfigFromEnvVarsProtectedFields(t *testing.T) {
+	tests := []v1.EnvVar{
+		{
+			Name:  SetEnvVarPrefix + "SYSTEMAGENT__REMOTE_ENABLED",
+			Value: "true",
+		},
+		{
+			Name:  PatchEnvVar,
+			Value: `{"debug": true, "listen": "other_pipe"}`,
+		},
+		{
+			Name:  SetEnvVarPrefix + "WHITE_LIST__UNKNOWN",
+			Value: "true",
+		},
+	}
+
+	for _, evar := range tests {
+		t.Run(evar.Name, func(t *testing.T) {
+			setupTest([]v1.EnvVar{evar}, t)
+
+			changed, err := UpdateConfigFromEnvVars()
+			if err == nil {
+				t.Fatalf("expected %s to be rejected, got changed fields %v", evar.Name, changed)
+			}
+			if _, err := os.Stat(configFileLoc); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("expected config file not to be written, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_ConfigFromEnvVarsChangedFields(t *testing.T) {
+	tests := []struct {
+		name            string
+		envVars         []v1.EnvVar
+		expectedChanged []string
+	}{
+		{
+			name: "Field set back to its loaded value",
+			envVars: []v1.EnvVar{
+				{
+					Name:  SetEnvVarPrefix + "DEBUG",
+					Value: "true",
+				},
+			},
+		},
+		{
+			name: "Field changed by the legacy variable",
+			envVars: []v1.EnvVar{
+				{
+					Name:  DebugEnvVar,
+					Value: "false",
+				},
+				{
+					Name:  SetEnvVarPrefix + "WHITE_LIST__PROXY_PORTS",
+					Value: "9796",
+				},
+			},
+			expectedChanged: []string{"debug", "white_list.proxyPorts"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setupTest(tc.envVars, t)
+			loaded := config.DefaultConfig()
+			loaded.Debug = true
+			if err := SaveConfig(loaded, ""); err != nil {
+				t.Fatalf("failed to save config: %v", err)
+			}
+
+			_, changed, err := ConfigFromEnvVars("")
+			if err != nil {
+				t.Fatalf("ConfigFromEnvVars returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(changed, tc.expectedChanged) {
+				t.Errorf("expected changed fields %v, got %v", tc.expectedChanged, changed)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -16,7 +17,7 @@
 // this dependency must be temporarily removed.
 func ConfigureRKE2ServiceDependency() error {
 	logrus.Info("Configuring rke2 service dependencies")
-	add := strings.ToLower(os.Getenv("CATTLE_ENABLE_WINS_SERVICE_DEPENDENCY")) == "true"
+	add := rke2ServiceDependencyEnabled()
 
 	rke2, serviceExists, err := OpenRKE2Service()
 	if err != nil {
@@ -27,8 +28,6 @@ func ConfigureRKE2ServiceDependency() error {
 		logrus.Warn("Could not find rke2 service, will not attempt to configure service dependencies")
 		return nil
 	}
-	defer rke2.Close()
-
 	found, err := rke2.HasRancherWinsServiceDependency()
 	if err != nil {
 		return fmt.Errorf("error encountered determining rke2 service dependencies: %w", err)
@@ -67,7 +66,7 @@ func ConfigureRKE2ServiceDependency() error {
 // Enabling this flag does not require a restart of the service.
 func ConfigureWinsDelayedStart() error {
 	logrus.Info("Configuring start type for rancher-wins")
-	delayedStart := strings.ToLower(os.Getenv("CATTLE_ENABLE_WINS_DELAYED_START")) == "true"
+	delayedStart := winsDelayedStartEnabled()
 
 	wins, exists, err := OpenRancherWinsService()
 	if err != nil {
@@ -79,8 +78,6 @@ func ConfigureWinsDelayedStart() error {
 		return nil
 	}
 
-	defer wins.Close()
-
 	err = wins.ConfigureDelayedStart(delayedStart)
 	if err != nil {
 		return fmt.Errorf("error encountered configuring delayed start for %s service: %w", defaults.WindowsServiceName, err)
@@ -89,70 +86,132 @@ func ConfigureWinsDelayedStart() error {
 	return nil
 }
 
-// RefreshWinsService restarts the rancher-wins service. If a service dependency has
-// been configured on the rke2 service, the dependency will be temporarily removed and
+// Setting is a service setting as it is currently configured and as the SUC would configure it
+type Setting struct {
+	Current bool `json:"current"`
+	Desired bool `json:"desired"`
+}
+
+// Changed returns whether the setting would be changed
+func (s Setting) Changed() bool {
+	return s.Current != s.Desired
+}
+
+// WinsDelayedStartSetting returns the delayed start setting of the rancher-wins service, Desired is the value
+// ConfigureWinsDelayedStart would set.
+func WinsDelayedStartSetting() (Setting, error) {
+	wins, exists, err := OpenRancherWinsService()
+	if err != nil {
+		return Setting{}, fmt.Errorf("failed to open %s service while checking start type: %w", defaults.WindowsServiceName, err)
+	}
+	if !exists {
+		return Setting{}, fmt.Errorf("%s service does not exist", defaults.WindowsServiceName)
+	}
+	return Setting{Current: wins.Config.DelayedAutoStart, Desired: winsDelayedStartEnabled()}, nil
+}
+
+// RKE2ServiceDependencySetting returns whether the rke2 service depends on the rancher-wins service, Desired is
+// the value ConfigureRKE2ServiceDependency would set. A nil Setting is returned if the rke2 service does not exist.
+func RKE2ServiceDependencySetting() (*Setting, error) {
+	rke2, exists, err := OpenRKE2Service()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rke2 service while checking service dependencies: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	found, err := rke2.HasRancherWinsServiceDependency()
+	if err != nil {
+		return nil, fmt.Errorf("error encountered determining rke2 service dependencies: %w", err)
+	}
+	return &Setting{Current: found, Desired: rke2ServiceDependencyEnabled()}, nil
+}
+
+func winsDelayedStartEnabled() bool {
+	return strings.ToLower(os.Getenv("CATTLE_ENABLE_WINS_DELAYED_START")) == "true"
+}
+
+func rke2ServiceDependencyEnabled() bool {
+	return strings.ToLower(os.Getenv("CATTLE_ENABLE_WINS_SERVICE_DEPENDENCY")) == "true"
+}
+
+// RefreshWinsService restarts the rancher-wins service. If a service dependency on rancher-wins has
+// been configured on any of the managed services, the dependency will be temporarily removed and
 // restored once the service restart has completed.
 func RefreshWinsService() error {
 	winSrv, exists, err := OpenRancherWinsService()
 	if err != nil {
 		logrus.Errorf("Cannot restart %s as the service failed to open: %v", defaults.WindowsServiceName, err)
-		return fmt.Errorf("failed to refresh the %s service: %w", winSrv.Name, err)
+		return fmt.Errorf("failed to refresh the %s service: %w", defaults.WindowsServiceName, err)
 	}
 
 	if !exists {
-		logrus.Errorf("Cannot restart %s as the service does not exist", defaults.WindowsServiceName)
-		return nil
+		return fmt.Errorf("cannot restart %s as the service does not exist", defaults.WindowsServiceName)
 	}
 
-	defer winSrv.Close()
-
 	// We cannot restart a service which another service depends on.
 	// In the event that we need to update the rancher-wins config file
 	// (and thus restart the rancher-wins service),
 	// we will need to temporarily remove the service dependency from
-	// the rke2 service if it exists. This ensures that rancher-wins can be updated
+	// rke2 and any other managed service if it exists. This ensures that rancher-wins can be updated
 	// without potentially impacting node functionality due to a restart of rke2.
-
-	rke2Srv, rke2Exists, err := OpenRKE2Service()
-	if err != nil {
-		logrus.Errorf("error opening rke2 service while restarting rancher-wins: %v", err)
+	var removed []*Service
+	restoreDependencies := func() error {
+		var errs []error
+		for _, dependent := range removed {
+			logrus.Infof("Restoring %s service dependency", dependent.Name)
+			if err := dependent.RefreshConfig(); err != nil {
+				errs = append(errs, fmt.Errorf("error encountered while restoring %s service dependency: %w", dependent.Name, err))
+				continue
+			}
+			dependent.Config.Dependencies = append(dependent.Config.Dependencies, defaults.WindowsServiceName)
+			if err := dependent.UpdateConfig(); err != nil {
+				errs = append(errs, fmt.Errorf("error encountered while restoring %s service dependency: %w", dependent.Name, err))
+			}
+		}
+		return errors.Join(errs...)
 	}
 
-	depRemoved := false
-	if rke2Exists {
-		hasDep, err := rke2Srv.HasRancherWinsServiceDependency()
-		if err != nil {
-			return fmt.Errorf("error encountered while temporarily removing rke2 service dependency: %w", err)
-		}
-		if hasDep {
-			logrus.Info("Temporarily removing rke2 service dependency")
-			depRemoved = true
-			err = rke2Srv.RemoveRancherWinsServiceDependency()
-			rke2Srv.Close()
-			if err != nil {
-				return fmt.Errorf("error encountered while temporarily removing rke2 service dependency: %w", err)
-			}
+	for _, dependent := range openWinsDependents() {
+		logrus.Infof("Temporarily removing %s service dependency", dependent.Name)
+		dependent.Config.Dependencies = removeAllFromSlice(defaults.WindowsServiceName, dependent.Config.Dependencies)
+		if err := dependent.UpdateConfig(); err != nil {
+			err = fmt.Errorf("error encountered while temporarily removing %s service dependency: %w", dependent.Name, err)
+			return errors.Join(err, restoreDependencies())
 		}
+		removed = append(removed, dependent)
 	}
 
 	err = winSrv.Restart()
 	if err != nil {
-		return fmt.Errorf("failed to restart the %s service: %w", winSrv.Name, err)
+		err = fmt.Errorf("failed to restart the %s service: %w", winSrv.Name, err)
+		return errors.Join(err, restoreDependencies())
 	}
 
-	if depRemoved {
-		// if we removed the dependency then we know the service exists
-		rke2Srv, _, err = OpenRKE2Service()
-		if err != nil {
-			logrus.Errorf("error opening rke2 service while restarting rancher-wins: %v", err)
+	return restoreDependencies()
+}
+
+// openWinsDependents opens the managed services which depend on the rancher-wins service
+func openWinsDependents() []*Service {
+	ordering, err := ServiceOrderingFromEnvVars()
+	if err != nil {
+		logrus.Warnf("Only checking the default services for a dependency on %s: %v", defaults.WindowsServiceName, err)
+	}
+
+	var dependents []*Service
+	for _, name := range ManagedServices(ordering) {
+		if name == defaults.WindowsServiceName {
+			continue
 		}
-		logrus.Info("Restoring rke2 service dependency")
-		err = rke2Srv.AddRancherWinsServiceDependency()
-		rke2Srv.Close()
+		svc, exists, err := Open(name)
 		if err != nil {
-			return fmt.Errorf("error encountered while restoring rke2 service dependency: %w", err)
+			logrus.Errorf("error opening %s service while restarting rancher-wins: %v", name, err)
+			continue
+		}
+		if exists && len(removeAllFromSlice(defaults.WindowsServiceName, svc.Config.Dependencies)) != len(svc.Config.Dependencies) {
+			dependents = append(dependents, svc)
 		}
 	}
-
-	return nil
+	return dependents
 }
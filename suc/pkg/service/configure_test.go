@@ -0,0 +1,123 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/winservice"
+)
+
+func Test_RefreshWinsService(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	fake.Add(defaults.WindowsServiceName, &winservice.Config{BinaryPath: `c:\Windows\wins.exe`}, winservice.Running)
+	fake.Add("rke2", &winservice.Config{BinaryPath: `c:\usr\local\bin\rke2.exe`, Dependencies: []string{defaults.WindowsServiceName}}, winservice.Running)
+
+	if err := RefreshWinsService(); err != nil {
+		t.Fatalf("RefreshWinsService returned an unexpected error: %v", err)
+	}
+
+	expectedCalls := []string{
+		"update rke2",
+		"stop " + defaults.WindowsServiceName,
+		"start " + defaults.WindowsServiceName,
+		"update rke2",
+	}
+	if calls := fake.TakeCalls(); !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, calls)
+	}
+
+	rke2, err := fake.Config("rke2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rke2.Dependencies, []string{defaults.WindowsServiceName}) {
+		t.Errorf("expected rke2 service dependency to be restored, got %v", rke2.Dependencies)
+	}
+	if state, _ := fake.State(defaults.WindowsServiceName); state != winservice.Running {
+		t.Errorf("expected %s service to be running, got %s", defaults.WindowsServiceName, state)
+	}
+}
+
+func Test_ConfigureWinsDelayedStart(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	fake.Add(defaults.WindowsServiceName, &winservice.Config{BinaryPath: `c:\Windows\wins.exe`}, winservice.Running)
+	t.Setenv("CATTLE_ENABLE_WINS_DELAYED_START", "true")
+
+	if err := ConfigureWinsDelayedStart(); err != nil {
+		t.Fatalf("ConfigureWinsDelayedStart returned an unexpected error: %v", err)
+	}
+	// the setting is only updated once
+	if err := ConfigureWinsDelayedStart(); err != nil {
+		t.Fatalf("ConfigureWinsDelayedStart returned an unexpected error: %v", err)
+	}
+
+	if calls := fake.TakeCalls(); !reflect.DeepEqual(calls, []string{"update " + defaults.WindowsServiceName}) {
+		t.Errorf("expected a single update, got %v", calls)
+	}
+	if cfg, _ := fake.Config(defaults.WindowsServiceName); !cfg.DelayedAutoStart {
+		t.Errorf("expected delayed auto start to be enabled")
+	}
+}
+
+func Test_Settings(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	fake.Add(defaults.WindowsServiceName, &winservice.Config{BinaryPath: `c:\Windows\wins.exe`, DelayedAutoStart: true}, winservice.Running)
+	t.Setenv("CATTLE_ENABLE_WINS_DELAYED_START", "false")
+	t.Setenv("CATTLE_ENABLE_WINS_SERVICE_DEPENDENCY", "true")
+
+	delayedStart, err := WinsDelayedStartSetting()
+	if err != nil {
+		t.Fatalf("WinsDelayedStartSetting returned an unexpected error: %v", err)
+	}
+	if expected := (Setting{Current: true, Desired: false}); delayedStart != expected || !delayedStart.Changed() {
+		t.Errorf("expected delayed start setting %+v, got %+v", expected, delayedStart)
+	}
+
+	dependency, err := RKE2ServiceDependencySetting()
+	if err != nil || dependency != nil {
+		t.Errorf("expected no setting without rke2 service, got %+v: %v", dependency, err)
+	}
+
+	fake.Add("rke2", &winservice.Config{BinaryPath: `c:\usr\local\bin\rke2.exe`, Dependencies: []string{defaults.WindowsServiceName}}, winservice.Running)
+	dependency, err = RKE2ServiceDependencySetting()
+	if err != nil {
+		t.Fatalf("RKE2ServiceDependencySetting returned an unexpected error: %v", err)
+	}
+	if expected := (Setting{Current: true, Desired: true}); *dependency != expected || dependency.Changed() {
+		t.Errorf("expected rke2 dependency setting %+v, got %+v", expected, *dependency)
+	}
+
+	if calls := fake.TakeCalls(); len(calls) != 0 {
+		t.Errorf("expected settings to be read without changes, got %v", calls)
+	}
+}
+
+func Test_MissingRancherWinsService(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	wins, exists, err := OpenRancherWinsService()
+	if err != nil || exists || wins != nil {
+		t.Fatalf("expected missing service to be reported as not existing, got %v, %t: %v", wins, exists, err)
+	}
+	if err := ConfigureWinsDelayedStart(); err != nil {
+		t.Errorf("ConfigureWinsDelayedStart returned an unexpected error: %v", err)
+	}
+	if _, err := WinsDelayedStartSetting(); err == nil {
+		t.Errorf("expected WinsDelayedStartSetting to fail without the service")
+	}
+	if err := RefreshWinsService(); err == nil {
+		t.Errorf("expected RefreshWinsService to fail without the service")
+	}
+}
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/winservice"
+	"github.com/sirupsen/logrus"
+)
+
+// ServiceOrderingEnvVar holds a yaml or json document declaring the dependencies, start type and delayed start
+// of services, which ConfigureServiceOrdering applies after the CATTLE_ENABLE_WINS_DELAYED_START and
+// CATTLE_ENABLE_WINS_SERVICE_DEPENDENCY settings, taking precedence over them. For example:
+//
+//	rke2:
+//	  dependsOn: [rancher-wins]
+//	csiproxy:
+//	  dependsOn: [rancher-wins]
+//	  startType: manual
+//	rancher-wins:
+//	  delayedStart: true
+const ServiceOrderingEnvVar = "CATTLE_WINS_SERVICE_ORDERING"
+
+// csiProxyServiceName is the name of the service rancher-wins installs CSI Proxy as
+const csiProxyServiceName = "csiproxy"
+
+// Start types of a ServiceSpec
+const (
+	StartTypeAutomatic = "automatic"
+	StartTypeManual    = "manual"
+	StartTypeDisabled  = "disabled"
+)
+
+// ServiceSpec declares the ordering settings of a service, settings that are not set are left as they are.
+// DependsOn replaces the dependencies of the service on managed services, dependencies on other services are
+// kept. An empty list removes all dependencies on managed services.
+type ServiceSpec struct {
+	DependsOn    []string `json:"dependsOn"`
+	StartType    string   `json:"startType,omitempty"`
+	DelayedStart *bool    `json:"delayedStart,omitempty"`
+}
+
+// ServiceOrdering maps the names of services to their ServiceSpec. The managed services are rancher-wins, rke2,
+// csiproxy and every service named in the ordering.
+type ServiceOrdering map[string]ServiceSpec
+
+// ServiceSettings are the ordering settings of a service as they are configured
+type ServiceSettings struct {
+	DependsOn    []string `json:"dependsOn"`
+	StartType    string   `json:"startType"`
+	DelayedStart bool     `json:"delayedStart"`
+}
+
+func (s ServiceSettings) String() string {
+	str := s.StartType
+	if s.DelayedStart {
+		str += " (delayed)"
+	}
+	if len(s.DependsOn) > 0 {
+		str += ", depends on " + strings.Join(s.DependsOn, ", ")
+	}
+	return str
+}
+
+func (s ServiceSettings) equal(o ServiceSettings) bool {
+	return s.StartType == o.StartType && s.DelayedStart == o.DelayedStart && UnorderedSlicesEqual(s.DependsOn, o.DependsOn)
+}
+
+// ServiceChange is a change of the ordering settings of a service
+type ServiceChange struct {
+	Name    string          `json:"name"`
+	Current ServiceSettings `json:"current"`
+	Desired ServiceSettings `json:"desired"`
+}
+
+// ServiceSnapshot records the ordering settings of a service, so that they can be restored by RestoreServices
+type ServiceSnapshot struct {
+	Name     string          `json:"name"`
+	Exists   bool            `json:"exists"`
+	Settings ServiceSettings `json:"settings"`
+}
+
+// ServiceOrderingFromEnvVars parses the ServiceOrderingEnvVar, nil is returned if it is not set
+func ServiceOrderingFromEnvVars() (ServiceOrdering, error) {
+	value := os.Getenv(ServiceOrderingEnvVar)
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	ordering, err := ParseServiceOrdering([]byte(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ServiceOrderingEnvVar, err)
+	}
+	return ordering, nil
+}
+
+// ParseServiceOrdering parses a yaml or json service ordering, unknown settings and invalid values are rejected
+func ParseServiceOrdering(bs []byte) (ServiceOrdering, error) {
+	js, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse service ordering: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.DisallowUnknownFields()
+	ordering := ServiceOrdering{}
+	if err := decoder.Decode(&ordering); err != nil {
+		return nil, fmt.Errorf("could not parse service ordering: %w", err)
+	}
+	if err := ordering.validate(); err != nil {
+		return nil, err
+	}
+	return ordering, nil
+}
+
+func (o ServiceOrdering) validate() error {
+	var errs []error
+	for _, name := range o.names() {
+		spec := o[name]
+		if spec.StartType != "" {
+			if _, err := parseStartType(spec.StartType); err != nil {
+				errs = append(errs, fmt.Errorf("service %s: %w", name, err))
+			}
+		}
+		if spec.DelayedStart != nil && *spec.DelayedStart && spec.StartType != "" && spec.StartType != StartTypeAutomatic {
+			errs = append(errs, fmt.Errorf("service %s: delayed start requires the %s start type", name, StartTypeAutomatic))
+		}
+		for _, dependency := range spec.DependsOn {
+			if dependency == "" {
+				errs = append(errs, fmt.Errorf("service %s: dependencies cannot be empty", name))
+			}
+			if strings.EqualFold(dependency, name) {
+				errs = append(errs, fmt.Errorf("service %s cannot depend on itself", name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (o ServiceOrdering) names() []string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// managed returns whether dependencies on the named service are managed by the ordering
+func (o ServiceOrdering) managed(name string) bool {
+	for _, managed := range ManagedServices(o) {
+		if strings.EqualFold(managed, name) {
+			return true
+		}
+	}
+	for _, spec := range o {
+		for _, dependency := range spec.DependsOn {
+			if strings.EqualFold(dependency, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// desired returns the settings of a service once spec has been applied to its current settings
+func (o ServiceOrdering) desired(current ServiceSettings, spec ServiceSpec) ServiceSettings {
+	desired := current
+	if spec.StartType != "" {
+		desired.StartType = spec.StartType
+		if spec.StartType != StartTypeAutomatic {
+			desired.DelayedStart = false
+		}
+	}
+	if spec.DelayedStart != nil {
+		desired.DelayedStart = *spec.DelayedStart
+	}
+	if spec.DependsOn != nil {
+		desired.DependsOn = nil
+		for _, dependency := range current.DependsOn {
+			if !o.managed(dependency) {
+				desired.DependsOn = append(desired.DependsOn, dependency)
+			}
+		}
+		desired.DependsOn = append(desired.DependsOn, spec.DependsOn...)
+	}
+	return desired
+}
+
+// ManagedServices returns the names of the services whose ordering settings are recorded in the initial state
+func ManagedServices(ordering ServiceOrdering) []string {
+	names := []string{defaults.WindowsServiceName, "rke2", csiProxyServiceName}
+	for _, name := range ordering.names() {
+		known := false
+		for _, n := range names {
+			known = known || strings.EqualFold(n, name)
+		}
+		if !known {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// PlanServiceOrdering returns the changes ConfigureServiceOrdering would make to apply the ordering. Services that
+// do not exist are skipped, but every dependency has to exist. An error is returned if the resulting
+// dependencies would form a cycle.
+func PlanServiceOrdering(ordering ServiceOrdering) ([]ServiceChange, error) {
+	// dependencies are keyed by lower case names, as service names are case-insensitive
+	dependencies := map[string][]string{}
+	var changes []ServiceChange
+	var errs []error
+	for _, name := range ordering.names() {
+		svc, exists, err := Open(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !exists {
+			logrus.Warnf("Could not find %s service, will not configure its ordering", name)
+			continue
+		}
+
+		current := settingsOf(&svc.Config)
+		desired := ordering.desired(current, ordering[name])
+		dependencies[strings.ToLower(name)] = desired.DependsOn
+		if !current.equal(desired) {
+			changes = append(changes, ServiceChange{Name: name, Current: current, Desired: desired})
+		}
+
+		for _, dependency := range ordering[name].DependsOn {
+			exists, err := controller.Exists(dependency)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to open service %s via service manager: %w", dependency, err))
+			} else if !exists {
+				errs = append(errs, fmt.Errorf("service %s cannot depend on %s, which does not exist", name, dependency))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// the services outside the ordering keep their dependencies, which can close a cycle as well
+	lookup := func(name string) ([]string, error) {
+		if deps, ok := dependencies[strings.ToLower(name)]; ok {
+			return deps, nil
+		}
+		exists, err := controller.Exists(name)
+		if err != nil || !exists {
+			return nil, err
+		}
+		cfg, err := controller.Config(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config for service %s via service manager: %w", name, err)
+		}
+		dependencies[strings.ToLower(name)] = cfg.Dependencies
+		return cfg.Dependencies, nil
+	}
+	cycle, err := findCycle(ordering.names(), lookup)
+	if err != nil {
+		return nil, err
+	}
+	if cycle != nil {
+		return nil, fmt.Errorf("service ordering would create a dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return changes, nil
+}
+
+// ConfigureServiceOrdering applies the ServiceOrderingEnvVar. Every service is checked, including for dependency
+// cycles, before any of them is changed. Neither start types nor dependencies require a restart of the services.
+func ConfigureServiceOrdering() error {
+	logrus.Info("Configuring service ordering")
+	ordering, err := ServiceOrderingFromEnvVars()
+	if err != nil {
+		return err
+	}
+	if ordering == nil {
+		logrus.Infof("%s not set, nothing to do", ServiceOrderingEnvVar)
+		return nil
+	}
+
+	changes, err := PlanServiceOrdering(ordering)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		logrus.Info("Service ordering already configured, nothing to do")
+		return nil
+	}
+	return applyServiceChanges(changes)
+}
+
+// SnapshotServices records the ordering settings of the managed services
+func SnapshotServices(ordering ServiceOrdering) ([]ServiceSnapshot, error) {
+	var snapshots []ServiceSnapshot
+	for _, name := range ManagedServices(ordering) {
+		svc, exists, err := Open(name)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := ServiceSnapshot{Name: name, Exists: exists}
+		if exists {
+			snapshot.Settings = settingsOf(&svc.Config)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// RestoreServices restores the ordering settings recorded by SnapshotServices as a unit. Services that did not
+// exist when the snapshot was taken or do not exist anymore are skipped.
+func RestoreServices(snapshots []ServiceSnapshot) error {
+	var changes []ServiceChange
+	var errs []error
+	for _, snapshot := range snapshots {
+		if !snapshot.Exists {
+			continue
+		}
+		svc, exists, err := Open(snapshot.Name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !exists {
+			logrus.Warnf("Could not find %s service, will not restore its ordering", snapshot.Name)
+			continue
+		}
+		current := settingsOf(&svc.Config)
+		if !current.equal(snapshot.Settings) {
+			changes = append(changes, ServiceChange{Name: snapshot.Name, Current: current, Desired: snapshot.Settings})
+		}
+	}
+	if err := applyServiceChanges(changes); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// applyServiceChanges removes the dependencies that are no longer desired from all services before adding the new
+// ones, so that the service manager never sees a cycle while the services are changed one by one
+func applyServiceChanges(changes []ServiceChange) error {
+	var errs []error
+	for _, change := range changes {
+		var kept []string
+		for _, dependency := range change.Current.DependsOn {
+			if containsFold(change.Desired.DependsOn, dependency) {
+				kept = append(kept, dependency)
+			}
+		}
+		if len(kept) == len(change.Current.DependsOn) {
+			continue
+		}
+		logrus.Infof("Removing dependencies of %s service", change.Name)
+		if err := updateServiceSettings(change.Name, ServiceSettings{DependsOn: kept, StartType: change.Current.StartType, DelayedStart: change.Current.DelayedStart}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, change := range changes {
+		logrus.Infof("Updating ordering of %s service from '%s' to '%s'", change.Name, change.Current, change.Desired)
+		if err := updateServiceSettings(change.Name, change.Desired); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func updateServiceSettings(name string, settings ServiceSettings) error {
+	svc, exists, err := Open(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("service %s does not exist", name)
+	}
+
+	startType, err := parseStartType(settings.StartType)
+	if err != nil {
+		return fmt.Errorf("service %s: %w", name, err)
+	}
+	svc.Config.StartType = startType
+	svc.Config.DelayedAutoStart = settings.DelayedStart
+	svc.Config.Dependencies = settings.DependsOn
+	if err := svc.UpdateConfig(); err != nil {
+		return fmt.Errorf("failed to update ordering of %s service: %w", name, err)
+	}
+	return nil
+}
+
+func settingsOf(cfg *winservice.Config) ServiceSettings {
+	return ServiceSettings{
+		DependsOn:    cfg.Dependencies,
+		StartType:    startTypeName(cfg.StartType),
+		DelayedStart: cfg.DelayedAutoStart,
+	}
+}
+
+func startTypeName(t winservice.StartType) string {
+	switch t {
+	case 0, winservice.StartAutomatic:
+		return StartTypeAutomatic
+	case winservice.StartManual:
+		return StartTypeManual
+	case winservice.StartDisabled:
+		return StartTypeDisabled
+	}
+	return fmt.Sprintf("%d", t)
+}
+
+func parseStartType(s string) (winservice.StartType, error) {
+	switch s {
+	case StartTypeAutomatic:
+		return winservice.StartAutomatic, nil
+	case StartTypeManual:
+		return winservice.StartManual, nil
+	case StartTypeDisabled:
+		return winservice.StartDisabled, nil
+	}
+	return 0, fmt.Errorf("unknown start type '%s', expected '%s', '%s' or '%s'", s, StartTypeAutomatic, StartTypeManual, StartTypeDisabled)
+}
+
+// findCycle walks the dependencies from each of the services and returns the first cycle it finds as the path
+// of services leading back to the first of them, or nil if there is none
+func findCycle(services []string, dependencies func(name string) ([]string, error)) ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := map[string]int{}
+	var path []string
+
+	var visit func(name string) ([]string, error)
+	visit = func(name string) ([]string, error) {
+		key := strings.ToLower(name)
+		switch marks[key] {
+		case visited:
+			return nil, nil
+		case visiting:
+			for i, n := range path {
+				if strings.EqualFold(n, name) {
+					return append(append([]string{}, path[i:]...), name), nil
+				}
+			}
+		}
+
+		marks[key] = visiting
+		path = append(path, name)
+		deps, err := dependencies(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependency := range deps {
+			cycle, err := visit(dependency)
+			if cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+		path = path[:len(path)-1]
+		marks[key] = visited
+		return nil, nil
+	}
+
+	for _, name := range services {
+		cycle, err := visit(name)
+		if cycle != nil || err != nil {
+			return cycle, err
+		}
+	}
+	return nil, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, e := range list {
+		if strings.EqualFold(e, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/pkg/winservice"
+)
+
+func Test_ParseServiceOrdering(t *testing.T) {
+	type test struct {
+		name        string
+		document    string
+		expectedErr string
+	}
+
+	tests := []test{
+		{
+			name: "Valid",
+			document: `
+rke2:
+  dependsOn: [rancher-wins]
+csiproxy:
+  dependsOn: [rancher-wins]
+  startType: manual
+rancher-wins:
+  delayedStart: true
+`,
+		},
+		{
+			name:     "Valid json",
+			document: `{"rke2": {"dependsOn": ["rancher-wins"], "startType": "automatic", "delayedStart": true}}`,
+		},
+		{
+			name:        "Unknown setting",
+			document:    `rke2: {after: [rancher-wins]}`,
+			expectedErr: "unknown field",
+		},
+		{
+			name:        "Unknown start type",
+			document:    `rke2: {startType: boot}`,
+			expectedErr: "unknown start type 'boot'",
+		},
+		{
+			name:        "Delayed start of manual service",
+			document:    `rke2: {startType: manual, delayedStart: true}`,
+			expectedErr: "delayed start requires the automatic start type",
+		},
+		{
+			name:        "Self dependency",
+			document:    `rke2: {dependsOn: [RKE2]}`,
+			expectedErr: "cannot depend on itself",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			_, err := ParseServiceOrdering([]byte(tst.document))
+			if tst.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("encountered unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tst.expectedErr) {
+				t.Fatalf("expected error containing '%s', got %v", tst.expectedErr, err)
+			}
+		})
+	}
+}
+
+func Test_findCycle(t *testing.T) {
+	type test struct {
+		name          string
+		dependencies  map[string][]string
+		expectedCycle []string
+	}
+
+	tests := []test{
+		{
+			name: "No cycle",
+			dependencies: map[string][]string{
+				"rke2":         {"rancher-wins", "csiproxy"},
+				"csiproxy":     {"rancher-wins"},
+				"rancher-wins": {"Tcpip"},
+			},
+		},
+		{
+			name: "Cycle",
+			dependencies: map[string][]string{
+				"rke2":         {"csiproxy"},
+				"csiproxy":     {"rancher-wins"},
+				"rancher-wins": {"RKE2"},
+			},
+			expectedCycle: []string{"rke2", "csiproxy", "rancher-wins", "RKE2"},
+		},
+		{
+			name: "Cycle outside of the start",
+			dependencies: map[string][]string{
+				"rke2":         {"rancher-wins"},
+				"rancher-wins": {"custom"},
+				"custom":       {"rancher-wins"},
+			},
+			expectedCycle: []string{"rancher-wins", "custom", "rancher-wins"},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			cycle, err := findCycle([]string{"rke2"}, func(name string) ([]string, error) {
+				return tst.dependencies[strings.ToLower(name)], nil
+			})
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(cycle, tst.expectedCycle) {
+				t.Fatalf("expected cycle %v, got %v", tst.expectedCycle, cycle)
+			}
+		})
+	}
+}
+
+func Test_ConfigureServiceOrdering(t *testing.T) {
+	type test struct {
+		name         string
+		ordering     string
+		expectedErr  string
+		expectedDeps map[string][]string
+	}
+
+	tests := []test{
+		{
+			name:     "Dependencies on managed services are replaced",
+			ordering: `{rke2: {dependsOn: [csiproxy]}, csiproxy: {dependsOn: [rancher-wins]}}`,
+			expectedDeps: map[string][]string{
+				"rke2":                      {"Tcpip", "csiproxy"},
+				csiProxyServiceName:         {"rancher-wins"},
+				defaults.WindowsServiceName: {"Tcpip"},
+			},
+		},
+		{
+			name:     "Missing services are skipped",
+			ordering: `{rke2: {dependsOn: []}, custom: {dependsOn: [rancher-wins]}}`,
+			expectedDeps: map[string][]string{
+				"rke2":              {"Tcpip"},
+				csiProxyServiceName: nil,
+			},
+		},
+		{
+			name:        "Missing dependency",
+			ordering:    `{rke2: {dependsOn: [custom]}}`,
+			expectedErr: "cannot depend on custom, which does not exist",
+		},
+		{
+			name:        "Cycle",
+			ordering:    `{rancher-wins: {dependsOn: [csiproxy]}, csiproxy: {dependsOn: [rke2]}}`,
+			expectedErr: "dependency cycle: csiproxy -> rke2 -> rancher-wins -> csiproxy",
+		},
+	}
+
+	defer func(c winservice.Controller) { controller = c }(controller)
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			fake := winservice.NewFake()
+			controller = fake
+			fake.Add(defaults.WindowsServiceName, &winservice.Config{Dependencies: []string{"Tcpip"}}, winservice.Running)
+			fake.Add("rke2", &winservice.Config{Dependencies: []string{"Tcpip", defaults.WindowsServiceName}}, winservice.Running)
+			fake.Add(csiProxyServiceName, &winservice.Config{}, winservice.Running)
+			fake.Add("Tcpip", &winservice.Config{}, winservice.Running)
+			t.Setenv(ServiceOrderingEnvVar, tst.ordering)
+
+			snapshots, err := SnapshotServices(nil)
+			if err != nil {
+				t.Fatalf("encountered unexpected error taking snapshot: %v", err)
+			}
+
+			err = ConfigureServiceOrdering()
+			if tst.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tst.expectedErr) {
+					t.Fatalf("expected error containing '%s', got %v", tst.expectedErr, err)
+				}
+				if calls := fake.TakeCalls(); len(calls) != 0 {
+					t.Fatalf("expected no service to be changed, got %v", calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			for name, expected := range tst.expectedDeps {
+				cfg, err := fake.Config(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(cfg.Dependencies, expected) {
+					t.Errorf("expected %s to depend on %v, got %v", name, expected, cfg.Dependencies)
+				}
+			}
+
+			if err := RestoreServices(snapshots); err != nil {
+				t.Fatalf("encountered unexpected error restoring services: %v", err)
+			}
+			restored, err := SnapshotServices(nil)
+			if err != nil {
+				t.Fatalf("encountered unexpected error taking snapshot: %v", err)
+			}
+			if !reflect.DeepEqual(restored, snapshots) {
+				t.Errorf("expected services to be restored to %+v, got %+v", snapshots, restored)
+			}
+		})
+	}
+}
+
+func Test_ConfigureServiceOrderingStartType(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	fake.Add(defaults.WindowsServiceName, &winservice.Config{}, winservice.Running)
+	fake.Add(csiProxyServiceName, &winservice.Config{DelayedAutoStart: true}, winservice.Running)
+	t.Setenv(ServiceOrderingEnvVar, `{rancher-wins: {delayedStart: true}, csiproxy: {startType: manual}}`)
+
+	changes, err := PlanServiceOrdering(mustServiceOrdering(t))
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	expected := []ServiceChange{
+		{
+			Name:    csiProxyServiceName,
+			Current: ServiceSettings{StartType: StartTypeAutomatic, DelayedStart: true},
+			Desired: ServiceSettings{StartType: StartTypeManual},
+		},
+		{
+			Name:    defaults.WindowsServiceName,
+			Current: ServiceSettings{StartType: StartTypeAutomatic},
+			Desired: ServiceSettings{StartType: StartTypeAutomatic, DelayedStart: true},
+		},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected changes %+v, got %+v", expected, changes)
+	}
+
+	if err := ConfigureServiceOrdering(); err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	if cfg, _ := fake.Config(csiProxyServiceName); cfg.StartType != winservice.StartManual || cfg.DelayedAutoStart {
+		t.Errorf("expected csiproxy to be started manually, got %+v", cfg)
+	}
+	if cfg, _ := fake.Config(defaults.WindowsServiceName); !cfg.DelayedAutoStart {
+		t.Errorf("expected rancher-wins to have delayed start enabled")
+	}
+}
+
+func Test_RefreshWinsServiceDependents(t *testing.T) {
+	fake := winservice.NewFake()
+	defer func(c winservice.Controller) { controller = c }(controller)
+	controller = fake
+
+	fake.Add(defaults.WindowsServiceName, &winservice.Config{}, winservice.Running)
+	fake.Add(csiProxyServiceName, &winservice.Config{Dependencies: []string{defaults.WindowsServiceName}}, winservice.Running)
+	fake.Add("custom", &winservice.Config{Dependencies: []string{defaults.WindowsServiceName}}, winservice.Running)
+	t.Setenv(ServiceOrderingEnvVar, `{custom: {dependsOn: [rancher-wins]}}`)
+
+	if err := RefreshWinsService(); err != nil {
+		t.Fatalf("RefreshWinsService returned an unexpected error: %v", err)
+	}
+
+	expectedCalls := []string{
+		"update " + csiProxyServiceName,
+		"update custom",
+		"stop " + defaults.WindowsServiceName,
+		"start " + defaults.WindowsServiceName,
+		"update " + csiProxyServiceName,
+		"update custom",
+	}
+	if calls := fake.TakeCalls(); !reflect.DeepEqual(calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, calls)
+	}
+	for _, name := range []string{csiProxyServiceName, "custom"} {
+		if cfg, _ := fake.Config(name); !reflect.DeepEqual(cfg.Dependencies, []string{defaults.WindowsServiceName}) {
+			t.Errorf("expected %s service dependency to be restored, got %v", name, cfg.Dependencies)
+		}
+	}
+}
+
+func mustServiceOrdering(t *testing.T) ServiceOrdering {
+	ordering, err := ServiceOrderingFromEnvVars()
+	if err != nil {
+		t.Fatalf("encountered unexpected error parsing ordering: %v", err)
+	}
+	return ordering
+}
@@ -5,11 +5,8 @@
 	"fmt"
 	"time"
 
-	"github.com/pkg/errors"
+	"github.com/rancher/wins/pkg/winservice"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/sys/windows"
-	"golang.org/x/sys/windows/svc"
-	"golang.org/x/sys/windows/svc/mgr"
 )
 
 const (
@@ -17,158 +14,120 @@
 	stateTransitionDelayInSeconds = 5
 )
 
-// Service is a wrapper around a mgr.Service which simplifies
+// controller manages the Windows services, it is replaced by a fake in tests
+var controller = winservice.New()
+
+// Service is a wrapper around a Windows service which simplifies
 // common operations and bundles relevant configuration information.
 type Service struct {
 	Name   string
-	svc    *mgr.Service
-	Config mgr.Config
+	Config winservice.Config
 }
 
-// Open opens a Windows service and returns a Service containing the relevant mgr.Config.
+// Open opens a Windows service and returns a Service containing the relevant winservice.Config.
 // If the provided service does not exist, a nil error and a false boolean will be returned.
-// The caller of Open is responsible for closing the returned Service (via Service.Close()).
 func Open(name string) (service *Service, serviceExists bool, err error) {
 	logrus.Debugf("Opening %s service", name)
-	svcMgr, err := mgr.Connect()
+	exists, err := controller.Exists(name)
 	if err != nil {
-		return nil, false, fmt.Errorf("failed to connect to service manager: %w", err)
-	}
-	defer svcMgr.Disconnect()
-
-	s, err := svcMgr.OpenService(name)
-	doesNotExist := errors.Is(err, windows.ERROR_SERVICE_DOES_NOT_EXIST)
-	if err != nil && !doesNotExist {
 		return nil, false, fmt.Errorf("failed to open service %s via service manager: %w", name, err)
 	}
 
-	if doesNotExist {
+	if !exists {
 		return nil, false, nil
 	}
 
-	if s == nil {
-		return nil, false, fmt.Errorf("failed to open service %s, a nil service was returned", name)
-	}
-
-	cfg, err := s.Config()
+	cfg, err := controller.Config(name)
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to open config for service %s via service manager: %w", name, err)
 	}
 
 	return &Service{
 		Name:   name,
-		svc:    s,
-		Config: cfg,
+		Config: *cfg,
 	}, true, nil
 }
 
-// GetState queries the Service and returns the svc.State
-func (s *Service) GetState() (svc.State, error) {
-	q, err := s.svc.Query()
+// GetState queries the Service and returns the winservice.State
+func (s *Service) GetState() (winservice.State, error) {
+	state, err := controller.State(s.Name)
 	if err != nil {
 		return 0, fmt.Errorf("could not query service %s: %w", s.Name, err)
 	}
 
-	return q.State, nil
+	return state, nil
 }
 
 // Restart explicitly stops and then starts the Service.
-// Restart blocks for up to 25 seconds, or until the svc.State transitions to svc.Running
+// Restart blocks for up to 60 seconds for each transition, or until the state transitions to winservice.Running
 func (s *Service) Restart() error {
 	logrus.Infof("Restarting %s service", s.Name)
-	currentState, err := s.GetState()
-	if err != nil {
-		return fmt.Errorf("failed to get state of service %s: %w", s.Name, err)
+	if err := s.Stop(); err != nil {
+		logrus.Errorf("Encountered error attempting to stop the %s service: %v", s.Name, err)
+		return fmt.Errorf("encountered error attempting to stop the %s service: %w", s.Name, err)
 	}
 
-	if currentState == svc.Running {
-		if err = s.Stop(); err != nil {
-			logrus.Errorf("Encountered error attempting to stop the %s service: %v", s.Name, err)
-			return fmt.Errorf("encountered error attempting to stop the %s service: %w", s.Name, err)
-		}
-	}
-
-	if err = s.svc.Start(); err != nil {
+	if err := winservice.Start(controller, s.Name, stateTransitionTimeout()); err != nil {
 		return fmt.Errorf("failed to start the %s service while attempting to restart: %w", s.Name, err)
 	}
 
-	return s.WaitForState(svc.Running, getStateTransitionDelayInSeconds(), getStateTransitionAttempts())
+	logrus.Infof("Service %s successfully transitioned to state %s", s.Name, winservice.Running)
+	return nil
 }
 
-// Stop sends a svc.Stop control signal to the Service and waits
-// for it to enter the svc.Stopped state
+// Stop stops the Service and waits for it to enter the winservice.Stopped state
 func (s *Service) Stop() error {
-	state, err := s.GetState()
-	if err != nil {
-		return fmt.Errorf("error getting state for %s service while attempting to send stop signal", s.Name)
+	if err := winservice.Stop(controller, s.Name, stateTransitionTimeout()); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", s.Name, err)
 	}
 
-	if state == windows.SERVICE_STOPPED {
-		logrus.Debugf("cannot stop service %s as it is not running", s.Name)
-		return nil
-	}
+	logrus.Infof("Service %s successfully transitioned to state %s", s.Name, winservice.Stopped)
+	return nil
+}
 
-	_, err = s.svc.Control(svc.Stop)
-	if err != nil {
-		return fmt.Errorf("failed to send Stop signal to %s: %w", s.Name, err)
+// Start starts the Service and waits for it to enter the winservice.Running state
+func (s *Service) Start() error {
+	if err := winservice.Start(controller, s.Name, stateTransitionTimeout()); err != nil {
+		return fmt.Errorf("failed to start %s: %w", s.Name, err)
 	}
 
-	return s.WaitForState(svc.Stopped, getStateTransitionDelayInSeconds(), getStateTransitionAttempts())
-}
-
-// Close closes the Service
-func (s *Service) Close() {
-	s.svc.Close()
+	logrus.Infof("Service %s successfully transitioned to state %s", s.Name, winservice.Running)
+	return nil
 }
 
 // WaitForState monitors the current state of the Service and waits for it to transition to the desiredState.
 // WaitForState will wait for the state to transition for up to (delayInSeconds * maxAttempts)
-func (s *Service) WaitForState(desiredState svc.State, delayInSeconds time.Duration, maxAttempts int) error {
-	transitionedSuccessfully := false
-	var err error
-	var state svc.State
-
-	logrus.Infof("Waiting for service %s to enter state %s", s.Name, serviceStateToString(desiredState))
-
-	for i := 0; i < maxAttempts; i++ {
-		state, err = s.GetState()
-		if err != nil {
-			return fmt.Errorf("failed to query service %s: %w", s.Name, err)
-		}
-		if state == desiredState {
-			transitionedSuccessfully = true
-			break
-		}
-		logrus.Infof("Waiting for service %s to enter state %s, current state: %s", s.Name, serviceStateToString(desiredState), serviceStateToString(state))
-		time.Sleep(time.Second * delayInSeconds)
-	}
-
-	if !transitionedSuccessfully {
-		return fmt.Errorf("%s failed to transition to desired state of %s within expected timeframe of %d seconds. last known state was %s", s.Name, serviceStateToString(desiredState), int(delayInSeconds.Seconds()*float64(maxAttempts)), serviceStateToString(state))
+func (s *Service) WaitForState(desiredState winservice.State, delayInSeconds time.Duration, maxAttempts int) error {
+	logrus.Infof("Waiting for service %s to enter state %s", s.Name, desiredState)
+	if err := winservice.WaitForState(controller, s.Name, desiredState, time.Second*delayInSeconds*time.Duration(maxAttempts)); err != nil {
+		return err
 	}
 
-	logrus.Infof("Service %s successfully transitioned to state %s", s.Name, serviceStateToString(desiredState))
+	logrus.Infof("Service %s successfully transitioned to state %s", s.Name, desiredState)
 	return nil
 }
 
-// UpdateConfig commits the stored Service.Config to the registry. Note that
-// the config can only be updated a single time after a service has been opened.
-// In order to update the config again, the service must be closed and reopened.
+// UpdateConfig commits the stored Service.Config to the service manager.
 func (s *Service) UpdateConfig() error {
 	j, err := json.MarshalIndent(s.Config, "", " ")
 	if err != nil {
 		return fmt.Errorf("error encountered while saving config, could not marshal to json: %w", err)
 	}
 	logrus.Debugf("Updating config for %s service. Config to be saved:\n%s ", s.Name, string(j))
-	return s.svc.UpdateConfig(s.Config)
+	return controller.Update(s.Name, &s.Config)
 }
 
 // RefreshConfig updates the Service.Config with the latest config used by the Windows Service.
 func (s *Service) RefreshConfig() error {
-	cfg, err := s.svc.Config()
+	cfg, err := controller.Config(s.Name)
 	if err != nil {
 		return fmt.Errorf("failed to refresh config for service '%s': %w", s.Name, err)
 	}
-	s.Config = cfg
+	s.Config = *cfg
 	return nil
 }
+
+// stateTransitionTimeout returns how long a state transition of a service is waited for
+func stateTransitionTimeout() time.Duration {
+	return time.Second * getStateTransitionDelayInSeconds() * time.Duration(getStateTransitionAttempts())
+}
@@ -11,6 +11,8 @@ type RancherWinsService struct {
 	Service
 }
 
+// OpenRancherWinsService opens the rancher-wins service. If the service does not exist,
+// a nil error and a false boolean will be returned.
 func OpenRancherWinsService() (*RancherWinsService, bool, error) {
 	winsSvc, exists, err := Open(defaults.WindowsServiceName)
 	if err != nil {
@@ -18,7 +20,7 @@ func OpenRancherWinsService() (*RancherWinsService, bool, error) {
 	}
 
 	if !exists {
-		return nil, false, fmt.Errorf("%s service does not exist", defaults.WindowsServiceName)
+		return nil, false, nil
 	}
 
 	x := &RancherWinsService{
@@ -47,10 +47,5 @@ func (rke2 *RKE2Service) AddRancherWinsServiceDependency() error {
 
 func (rke2 *RKE2Service) RemoveRancherWinsServiceDependency() error {
 	rke2.Config.Dependencies = removeAllFromSlice(defaults.WindowsServiceName, rke2.Config.Dependencies)
-	if len(rke2.Config.Dependencies) == 0 {
-		// Updating a service config with a nil or empty Dependencies slice will not have any effect.
-		// Instead, '/' must be used to clear any remaining service dependencies.
-		rke2.Config.Dependencies = append(rke2.Config.Dependencies, "/")
-	}
 	return rke2.UpdateConfig()
 }
@@ -4,8 +4,9 @@
 	"encoding/json"
 	"errors"
 	"fmt"
+
 	winsConfig "github.com/rancher/wins/cmd/server/config"
-	"github.com/rancher/wins/pkg/defaults"
+	"github.com/rancher/wins/suc/pkg/host"
 	"github.com/rancher/wins/suc/pkg/service"
 	sucConfig "github.com/rancher/wins/suc/pkg/service/config"
 	"github.com/sirupsen/logrus"
@@ -16,15 +17,15 @@
 // In the event of an error during reconfiguration of the
 // service or the related binaries, this struct should be used
 // to roll back all changes. Once an InitialState struct is created
-// (via BuildInitialState) it must not be updated.
+// (via BuildInitialState) it must not be updated. It is recorded in
+// the Journal, so that an interrupted run can be rolled back as well.
 type InitialState struct {
-	InitialConfig        *winsConfig.Config
-	InitialServiceConfig Configuration
-}
-
-type Configuration struct {
-	winsDelayedStart bool
-	rke2Dependencies []string
+	InitialConfig *winsConfig.Config `json:"config"`
+	// InitialServiceConfig records the dependencies, start type and delayed start of the managed services
+	InitialServiceConfig []service.ServiceSnapshot `json:"services"`
+	InitialBinaries      []host.BinaryBackup       `json:"binaries"`
+	// InitialCSIProxy backs up the CSI Proxy binary and its recorded installation if an upgrade of CSI Proxy is requested
+	InitialCSIProxy []host.BinaryBackup `json:"csiProxy,omitempty"`
 }
 
 // BuildInitialState retrieves the rancher-wins config file and any relevant service
@@ -38,7 +39,7 @@ func BuildInitialState() (InitialState, error) {
 		return InitialState{}, fmt.Errorf("could not open rancher-wins config while building initial state: %w", err)
 	}
 
-	winsSvc, winsExists, err := service.OpenRancherWinsService()
+	_, winsExists, err := service.OpenRancherWinsService()
 	if err != nil {
 		return InitialState{}, fmt.Errorf("could not open rancher-wins service while building initial state: %w", err)
 	}
@@ -46,87 +47,52 @@ func BuildInitialState() (InitialState, error) {
 	if !winsExists {
 		return InitialState{}, fmt.Errorf("the rancher-wins service does not exist")
 	}
-	defer winsSvc.Close()
 
-	rke2Svc, rke2Exists, err := service.OpenRKE2Service()
+	// an invalid ordering fails the run once it is configured, the default services are recorded regardless
+	ordering, err := service.ServiceOrderingFromEnvVars()
 	if err != nil {
-		return InitialState{}, fmt.Errorf("encountered error getting config file for %s service: %w", "rke2", err)
+		logrus.Warnf("Only recording the default services in the initial state: %v", err)
 	}
-
-	var rke2Deps []string
-	if rke2Exists {
-		rke2Deps = rke2Svc.Config.Dependencies
-		rke2Svc.Close()
-	} else {
-		logrus.Warn("Could not find rke2 service while building initial state")
+	services, err := service.SnapshotServices(ordering)
+	if err != nil {
+		return InitialState{}, fmt.Errorf("could not record service configuration while building initial state: %w", err)
+	}
+	for _, svc := range services {
+		if !svc.Exists {
+			logrus.Warnf("Could not find %s service while building initial state", svc.Name)
+			continue
+		}
+		logrus.Debugf("%s service ordering: %s", svc.Name, svc.Settings)
 	}
 
-	logrus.Debugf("rancher-wins delayed start is set to %t", winsSvc.Config.DelayedAutoStart)
-	logrus.Debugf("rke2 service dependencies: %v", rke2Deps)
 	j, err := json.MarshalIndent(winsCfg, "", " ")
 	if err != nil {
 		return InitialState{}, fmt.Errorf("could not marshal rancher-wins config to json while building initial state: %w", err)
 	}
 	logrus.Debugf("initial rancher-wins config file:\n%s", string(j))
 
-	return InitialState{
-		InitialConfig: winsCfg,
-		InitialServiceConfig: Configuration{
-			winsDelayedStart: winsSvc.Config.DelayedAutoStart,
-			rke2Dependencies: rke2Deps,
-		},
-	}, nil
-}
-
-// RestoreInitialState will clear all changes made to the host and reinstate the values contained within InitialState.
-func RestoreInitialState(state InitialState) error {
-	var errs []error
-	// restore rancher-wins service configuration
-	winsSvc, _, err := service.OpenRancherWinsService()
-	if err != nil {
-		errs = append(errs, fmt.Errorf("failed to open %s while restoring initial configuration: %w", defaults.WindowsServiceName, err))
-	}
-
-	if winsSvc.Config.DelayedAutoStart != state.InitialServiceConfig.winsDelayedStart {
-		err = winsSvc.ConfigureDelayedStart(state.InitialServiceConfig.winsDelayedStart)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to revert rancher-wins delayed start to %t: %w", state.InitialServiceConfig.winsDelayedStart, err))
-		}
-	}
-
-	// restore rke2 service configuration
-	saveRke2Config := false
-	rke2Srv, rke2Exists, err := service.OpenRKE2Service()
+	binaries, err := host.BackupBinaries()
 	if err != nil {
-		errs = append(errs, fmt.Errorf("failed to open %s while restoring initial configuration: %w", "rke2", err))
+		return InitialState{}, fmt.Errorf("could not back up wins.exe binaries while building initial state: %w", err)
 	}
 
-	if rke2Exists {
-		logrus.Infof("Restoring rke2 service configuration")
-		if !service.UnorderedSlicesEqual(rke2Srv.Config.Dependencies, state.InitialServiceConfig.rke2Dependencies) {
-			saveRke2Config = true
-			rke2Srv.Config.Dependencies = state.InitialServiceConfig.rke2Dependencies
-		}
-
-		if saveRke2Config {
-			err = rke2Srv.UpdateConfig()
-			if err != nil {
-				errs = append(errs, fmt.Errorf("failed to restore initial configuration of %s service: %w", "rke2", err))
-			}
-		}
-		rke2Srv.Close()
-	}
-
-	// restore rancher-wins config file
-	logrus.Infof("Restoring rancher-wins configuration file")
-	err = sucConfig.SaveConfig(state.InitialConfig, "")
+	csiProxy, err := host.BackupCSIProxy()
 	if err != nil {
-		errs = append(errs, err)
+		return InitialState{}, errors.Join(
+			fmt.Errorf("could not back up CSI Proxy while building initial state: %w", err),
+			host.RemoveBackups(binaries),
+		)
 	}
 
-	if len(errs) > 0 {
-		return errors.Join(errs...)
-	}
+	return InitialState{
+		InitialConfig:        winsCfg,
+		InitialServiceConfig: services,
+		InitialBinaries:      binaries,
+		InitialCSIProxy:      csiProxy,
+	}, nil
+}
 
-	return service.RefreshWinsService()
+// DiscardInitialState removes the backups taken by BuildInitialState once they are no longer needed
+func DiscardInitialState(state InitialState) error {
+	return errors.Join(host.RemoveBackups(state.InitialBinaries), host.RemoveBackups(state.InitialCSIProxy))
 }
@@ -0,0 +1,246 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rancher/wins/suc/pkg/host"
+	"github.com/rancher/wins/suc/pkg/service"
+	sucConfig "github.com/rancher/wins/suc/pkg/service/config"
+	"github.com/sirupsen/logrus"
+)
+
+// JournalFileName is the name of the file in the rancher-wins config directory the progress of a run is journaled to
+const JournalFileName = "suc-journal.json"
+
+// RecoveryModeEnvVar decides what happens to a run that was interrupted, e.g. because the node rebooted. By default
+// the next run resumes it, rolling back to the initial state recorded by the interrupted run if it fails as well.
+// If it is set to RecoveryRevert, the next run rolls back the interrupted run first and then starts over.
+const RecoveryModeEnvVar = "CATTLE_WINS_RECOVERY_MODE"
+
+// Recovery modes of an interrupted run
+const (
+	RecoveryResume = "resume"
+	RecoveryRevert = "revert"
+)
+
+// Names of the steps that can be undone, the other steps of a run leave nothing behind that has to be reverted
+const (
+	StepBuildInitialState        = "build initial state"
+	StepUpdateConfig             = "update config"
+	StepConfigureDelayedStart    = "configure delayed start"
+	StepConfigureRKE2Dependency  = "configure rke2 service dependency"
+	StepConfigureServiceOrdering = "configure service ordering"
+	StepUpgradeBinary            = "upgrade wins.exe"
+	StepUpgradeCSIProxy          = "upgrade csi-proxy"
+)
+
+// Statuses of a JournalEntry
+const (
+	journalStepApplying = "applying"
+	journalStepApplied  = "applied"
+	journalStepFailed   = "failed"
+	journalStepUndone   = "undone"
+)
+
+var (
+	// buildInitialState and undoers are replaced by fakes in tests
+	buildInitialState = BuildInitialState
+
+	// undoers revert the step of the same name to the initial state. They only depend on the initial state,
+	// so that the steps of an interrupted run can be undone by the next run.
+	undoers = map[string]func(InitialState) error{
+		// the first step is undone last, once the config and binaries have been restored
+		StepBuildInitialState: func(InitialState) error {
+			return service.RefreshWinsService()
+		},
+		StepUpdateConfig: func(state InitialState) error {
+			return sucConfig.SaveConfig(state.InitialConfig, "")
+		},
+		StepConfigureDelayedStart:    restoreServices,
+		StepConfigureRKE2Dependency:  restoreServices,
+		StepConfigureServiceOrdering: restoreServices,
+		StepUpgradeBinary: func(state InitialState) error {
+			// the rancher-wins service is stopped if the binaries have been upgraded
+			_, err := host.RestoreBinaries(state.InitialBinaries)
+			return err
+		},
+		StepUpgradeCSIProxy: func(state InitialState) error {
+			// the CSI Proxy version is updated in the config along with the binary
+			return errors.Join(
+				host.RestoreCSIProxy(state.InitialCSIProxy),
+				sucConfig.SaveConfig(state.InitialConfig, ""),
+			)
+		},
+	}
+)
+
+func restoreServices(state InitialState) error {
+	return service.RestoreServices(state.InitialServiceConfig)
+}
+
+// Journal records the initial state of a run and the progress of its steps
+type Journal struct {
+	StartedAt    time.Time      `json:"startedAt"`
+	InitialState InitialState   `json:"initialState"`
+	Steps        []JournalEntry `json:"steps"`
+	// RollingBack is set once the run started to roll back, an interrupted roll back is always completed by the next run
+	RollingBack bool `json:"rollingBack"`
+}
+
+// JournalEntry is the status of a step of the run
+type JournalEntry struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Transaction applies the steps of a run in order, journaling their progress so that the host can be rolled back
+// to its initial state by undoing the applied steps in reverse order, even by the next run if this one is interrupted.
+type Transaction struct {
+	path        string
+	journal     Journal
+	interrupted bool
+}
+
+// Begin starts a transaction journaled to path. If the journal of an interrupted run exists, its initial state is
+// used and Recover has to be called before any steps are applied. Otherwise, the initial state is built as the
+// first step of the transaction.
+func Begin(path string) (*Transaction, error) {
+	tx := &Transaction{path: path}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &tx.journal); err != nil {
+			return nil, fmt.Errorf("could not decode journal %s of interrupted run: %w", path, err)
+		}
+		tx.interrupted = true
+		logrus.Warnf("Found journal %s of a run interrupted since %s", path, tx.journal.StartedAt.Format(time.RFC3339))
+		return tx, nil
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("could not read journal %s: %w", path, err)
+	}
+
+	initialState, err := buildInitialState()
+	if err != nil {
+		return nil, fmt.Errorf("could not build initial state for rancher-wins: %w", err)
+	}
+	tx.journal = Journal{
+		StartedAt:    time.Now(),
+		InitialState: initialState,
+		Steps:        []JournalEntry{{Name: StepBuildInitialState, Status: journalStepApplied}},
+	}
+	if err := tx.save(); err != nil {
+		return nil, errors.Join(err, DiscardInitialState(initialState))
+	}
+	return tx, nil
+}
+
+// Interrupted returns whether the transaction continues the journal of an interrupted run
+func (t *Transaction) Interrupted() bool {
+	return t.interrupted
+}
+
+// Recover handles the interrupted run according to the RecoveryModeEnvVar. It returns true if the interrupted run
+// has been rolled back, in which case the transaction is finished and a new one has to be begun for this run.
+func (t *Transaction) Recover() (bool, error) {
+	mode := strings.ToLower(os.Getenv(RecoveryModeEnvVar))
+	switch mode {
+	case "", RecoveryResume, RecoveryRevert:
+	default:
+		return false, fmt.Errorf("unknown %s '%s', expected '%s' or '%s'", RecoveryModeEnvVar, mode, RecoveryResume, RecoveryRevert)
+	}
+
+	if t.journal.RollingBack || mode == RecoveryRevert {
+		logrus.Warn("Rolling back the interrupted run")
+		return true, t.Rollback()
+	}
+	logrus.Warn("Resuming the interrupted run, the host is rolled back to the state before it if this run fails")
+	return false, nil
+}
+
+// Apply runs apply as the step called name. The step is journaled before it runs, so that it is undone
+// on roll back even if it failed or the run was interrupted part way through it.
+func (t *Transaction) Apply(name string, apply func() error) error {
+	t.journal.Steps = append(t.journal.Steps, JournalEntry{Name: name, Status: journalStepApplying})
+	entry := &t.journal.Steps[len(t.journal.Steps)-1]
+	if err := t.save(); err != nil {
+		return err
+	}
+
+	err := apply()
+	entry.Status = journalStepApplied
+	if err != nil {
+		entry.Status, entry.Error = journalStepFailed, err.Error()
+	}
+	return errors.Join(err, t.save())
+}
+
+// Rollback undoes the steps in reverse order. A step that fails to be undone does not stop the others from being
+// undone, the errors of all steps are returned. Once every step has been undone, the journal and the backups of the
+// initial state are removed. Otherwise, they are kept, so that the next run completes the roll back.
+func (t *Transaction) Rollback() error {
+	t.journal.RollingBack = true
+	if err := t.save(); err != nil {
+		logrus.Errorf("Could not journal the roll back, continuing regardless: %v", err)
+	}
+
+	var errs []error
+	for i := len(t.journal.Steps) - 1; i >= 0; i-- {
+		entry := &t.journal.Steps[i]
+		undo, ok := undoers[entry.Name]
+		if !ok || entry.Status == journalStepUndone {
+			continue
+		}
+
+		logrus.Infof("Undoing step '%s'", entry.Name)
+		if err := undo(t.journal.InitialState); err != nil {
+			errs = append(errs, fmt.Errorf("failed to undo step '%s': %w", entry.Name, err))
+			continue
+		}
+		entry.Status = journalStepUndone
+		if err := t.save(); err != nil {
+			logrus.Errorf("Could not journal the roll back, continuing regardless: %v", err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return t.finish()
+}
+
+// Commit finishes the transaction after all steps have been applied, removing the journal and the backups of the initial state
+func (t *Transaction) Commit() error {
+	return t.finish()
+}
+
+func (t *Transaction) finish() error {
+	if err := DiscardInitialState(t.journal.InitialState); err != nil {
+		logrus.Warnf("Could not remove backups of the initial state: %v", err)
+	}
+	if err := os.Remove(t.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove journal %s: %w", t.path, err)
+	}
+	return nil
+}
+
+// save writes the journal to a temporary file which replaces the journal, so that it is never left half written
+func (t *Transaction) save() error {
+	b, err := json.MarshalIndent(t.journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode journal: %w", err)
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return fmt.Errorf("could not write journal %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		return fmt.Errorf("could not replace journal %s: %w", t.path, err)
+	}
+	return nil
+}
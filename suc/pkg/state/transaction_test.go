@@ -0,0 +1,201 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeUndoers replaces the undoers with ones recording the steps they undo, the steps in failing fail to be undone
+func fakeUndoers(t *testing.T, failing ...string) *[]string {
+	previousUndoers, previousBuild := undoers, buildInitialState
+	t.Cleanup(func() { undoers, buildInitialState = previousUndoers, previousBuild })
+	buildInitialState = func() (InitialState, error) { return InitialState{}, nil }
+
+	var undone []string
+	undoers = map[string]func(InitialState) error{}
+	for _, name := range []string{StepBuildInitialState, StepUpdateConfig, StepConfigureServiceOrdering, StepUpgradeBinary} {
+		name := name
+		undoers[name] = func(InitialState) error {
+			undone = append(undone, name)
+			for _, f := range failing {
+				if f == name {
+					return errors.New("access denied")
+				}
+			}
+			return nil
+		}
+	}
+	return &undone
+}
+
+func applySteps(t *testing.T, tx *Transaction, names ...string) {
+	for _, name := range names {
+		if err := tx.Apply(name, func() error { return nil }); err != nil {
+			t.Fatalf("encountered unexpected error applying %s: %v", name, err)
+		}
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	undone := fakeUndoers(t, StepUpdateConfig)
+	path := filepath.Join(t.TempDir(), JournalFileName)
+
+	tx, err := Begin(path)
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	if tx.Interrupted() {
+		t.Fatalf("expected a new transaction")
+	}
+	applySteps(t, tx, "update connection info", StepUpdateConfig, StepConfigureServiceOrdering)
+	if err := tx.Apply(StepUpgradeBinary, func() error { return errors.New("disk full") }); err == nil {
+		t.Fatalf("expected error of the step to be returned")
+	}
+
+	// steps are undone in reverse order, failing ones do not stop the others
+	err = tx.Rollback()
+	if err == nil || !strings.Contains(err.Error(), "failed to undo step 'update config': access denied") {
+		t.Fatalf("expected error undoing the config update, got %v", err)
+	}
+	expected := []string{StepUpgradeBinary, StepConfigureServiceOrdering, StepUpdateConfig, StepBuildInitialState}
+	if !reflect.DeepEqual(*undone, expected) {
+		t.Fatalf("expected steps %v to be undone, got %v", expected, *undone)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected journal to be kept after a failed roll back, got %v", err)
+	}
+
+	// the next run completes the roll back, regardless of the recovery mode
+	*undone = nil
+	undoers[StepUpdateConfig] = func(InitialState) error {
+		*undone = append(*undone, StepUpdateConfig)
+		return nil
+	}
+	t.Setenv(RecoveryModeEnvVar, RecoveryResume)
+	tx, err = Begin(path)
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	if !tx.Interrupted() {
+		t.Fatalf("expected the transaction to continue the interrupted run")
+	}
+	rolledBack, err := tx.Recover()
+	if err != nil || !rolledBack {
+		t.Fatalf("expected the interrupted run to be rolled back, got %t: %v", rolledBack, err)
+	}
+	if expected := []string{StepUpdateConfig}; !reflect.DeepEqual(*undone, expected) {
+		t.Fatalf("expected only the steps that were not undone to be undone, got %v", *undone)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected journal to be removed, got %v", err)
+	}
+}
+
+func TestTransactionRecover(t *testing.T) {
+	type test struct {
+		name               string
+		mode               string
+		expectedRolledBack bool
+		expectedErr        string
+		expectedUndone     []string
+	}
+
+	tests := []test{
+		{
+			name: "Resume by default",
+			// the steps of the interrupted run are undone together with the ones of the resumed run
+			expectedUndone: []string{StepUpgradeBinary, StepUpgradeBinary, StepUpdateConfig, StepBuildInitialState},
+		},
+		{
+			name:               "Revert",
+			mode:               RecoveryRevert,
+			expectedRolledBack: true,
+			expectedUndone:     []string{StepUpgradeBinary, StepUpdateConfig, StepBuildInitialState},
+		},
+		{
+			name:        "Unknown mode",
+			mode:        "retry",
+			expectedErr: "unknown CATTLE_WINS_RECOVERY_MODE 'retry'",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			undone := fakeUndoers(t)
+			path := filepath.Join(t.TempDir(), JournalFileName)
+			t.Setenv(RecoveryModeEnvVar, tst.mode)
+
+			// the run is interrupted while it upgrades the binary
+			interrupted, err := Begin(path)
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			applySteps(t, interrupted, StepUpdateConfig)
+			if err := interrupted.Apply(StepUpgradeBinary, func() error {
+				// the journal has to be written before the step changes the host
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("expected journal to exist while the step is applied, got %v", err)
+				}
+				return nil
+			}); err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+
+			tx, err := Begin(path)
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			if !tx.Interrupted() {
+				t.Fatalf("expected the transaction to continue the interrupted run")
+			}
+			rolledBack, err := tx.Recover()
+			if tst.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tst.expectedErr) {
+					t.Fatalf("expected error containing '%s', got %v", tst.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("encountered unexpected error: %v", err)
+			}
+			if rolledBack != tst.expectedRolledBack {
+				t.Fatalf("expected rolled back to be %t, got %t", tst.expectedRolledBack, rolledBack)
+			}
+
+			if !rolledBack {
+				applySteps(t, tx, StepUpgradeBinary)
+				if err := tx.Rollback(); err != nil {
+					t.Fatalf("encountered unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(*undone, tst.expectedUndone) {
+				t.Fatalf("expected steps %v to be undone, got %v", tst.expectedUndone, *undone)
+			}
+		})
+	}
+}
+
+func TestTransactionCommit(t *testing.T) {
+	undone := fakeUndoers(t)
+	path := filepath.Join(t.TempDir(), JournalFileName)
+
+	tx, err := Begin(path)
+	if err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+	applySteps(t, tx, StepUpdateConfig, StepUpgradeBinary)
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("encountered unexpected error: %v", err)
+	}
+
+	if len(*undone) != 0 {
+		t.Fatalf("expected no step to be undone, got %v", *undone)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected journal to be removed, got %v", err)
+	}
+}